@@ -0,0 +1,404 @@
+// Package cache provides typed caches on top of byte based drivers, e.g.
+// Redis shared by all instances or an in-process LRU.
+//
+// Each cache stores its entries in a namespace of the driver:
+//
+//	users, err := cache.NewCache[int64, User](manager, "users", nil)
+//
+//	user, err := users.GetOrLoad(ctx, id, func(ctx context.Context) (User, error) {
+//		return loadUser(ctx, id)
+//	}, &cache.EntryOptions{Tags: []string{"org:" + orgID}})
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/fruitsco/goji/x/driver"
+)
+
+// DefaultTTL is the TTL of entries if neither the options nor the config set one
+const DefaultTTL = 5 * time.Minute
+
+// Driver stores encoded entries
+type Driver interface {
+	// Get returns the value of the entry, ErrNotFound if it is not cached
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Set caches the value for the TTL and adds the entry to the tags
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags []string) error
+
+	// Delete removes the entries, missing entries are ignored
+	Delete(ctx context.Context, keys ...string) error
+
+	// InvalidateTags removes the entries added to any of the tags
+	InvalidateTags(ctx context.Context, tags ...string) error
+}
+
+type CacheParams struct {
+	fx.In
+
+	Drivers []*driver.Factory[DriverName, Driver] `group:"drivers"`
+	Config  *Config
+
+	// Log is the logger for failed cache reads and writes of GetOrLoad
+	Log *zap.Logger `optional:"true"`
+}
+
+// Manager resolves the drivers of the caches created with NewCache
+type Manager struct {
+	drivers *driver.Pool[DriverName, Driver]
+	config  *Config
+	log     *zap.Logger
+	loads   *loads
+}
+
+func New(params CacheParams) *Manager {
+	log := params.Log
+	if log == nil {
+		log = zap.NewNop()
+	}
+
+	return &Manager{
+		drivers: driver.NewPool(params.Drivers),
+		config:  params.Config,
+		log:     log,
+		loads:   newLoads(),
+	}
+}
+
+func (m *Manager) Driver(name DriverName) (Driver, error) {
+	return m.drivers.Resolve(name)
+}
+
+// InvalidateTags removes the entries of the tags from the default driver,
+// across all caches using it
+func (m *Manager) InvalidateTags(ctx context.Context, tags ...string) error {
+	d, err := m.drivers.Resolve(m.config.Driver)
+	if err != nil {
+		return err
+	}
+
+	m.loads.invalidateTags(tags...)
+
+	return d.InvalidateTags(ctx, tags...)
+}
+
+// MARK: - Cache
+
+// Options configures a cache
+type Options struct {
+	// Driver is the driver of the cache, the configured driver if empty
+	Driver DriverName
+
+	// Codec encodes the values, JSONCodec if nil
+	Codec Codec
+
+	// TTL is the default TTL of entries, the configured TTL if zero
+	TTL time.Duration
+
+	// TTLJitter is the maximum fraction the TTL of an entry is shortened
+	// by, the configured jitter if zero. Jitter is disabled if negative.
+	TTLJitter float64
+}
+
+// EntryOptions configures a cached entry
+type EntryOptions struct {
+	// TTL is the time the entry is cached, the TTL of the cache if zero
+	TTL time.Duration
+
+	// Tags are invalidated together using InvalidateTags, e.g. all
+	// entries of an organization
+	Tags []string
+}
+
+// Cache is a typed cache. Keys are formatted using fmt.Sprint, key types
+// may implement fmt.Stringer.
+type Cache[K comparable, V any] struct {
+	driver    Driver
+	codec     Codec
+	namespace string
+	ttl       time.Duration
+	jitter    float64
+	log       *zap.Logger
+	loads     *loads
+
+	group singleflight.Group
+}
+
+// NewCache creates a cache whose entries are stored in the namespace
+func NewCache[K comparable, V any](m *Manager, namespace string, opts *Options) (*Cache[K, V], error) {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+
+	if o.Driver == "" {
+		o.Driver = m.config.Driver
+	}
+
+	d, err := m.drivers.Resolve(o.Driver)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.Codec == nil {
+		o.Codec = JSONCodec{}
+	}
+
+	if o.TTL <= 0 {
+		o.TTL = time.Duration(m.config.TTLSeconds) * time.Second
+	}
+
+	if o.TTL <= 0 {
+		o.TTL = DefaultTTL
+	}
+
+	if o.TTLJitter == 0 {
+		o.TTLJitter = m.config.TTLJitter
+	}
+
+	return &Cache[K, V]{
+		driver:    d,
+		codec:     o.Codec,
+		namespace: namespace,
+		ttl:       o.TTL,
+		jitter:    min(max(o.TTLJitter, 0), 1),
+		log:       m.log.With(zap.String("namespace", namespace)),
+		loads:     m.loads,
+	}, nil
+}
+
+// Get returns the cached value, ErrNotFound if it is not cached
+func (c *Cache[K, V]) Get(ctx context.Context, key K) (V, error) {
+	var v V
+
+	data, err := c.driver.Get(ctx, c.key(key))
+	if err != nil {
+		return v, err
+	}
+
+	if err := c.codec.Unmarshal(data, &v); err != nil {
+		return v, fmt.Errorf("failed to decode cache entry: %w", err)
+	}
+
+	return v, nil
+}
+
+// Set caches the value
+func (c *Cache[K, V]) Set(ctx context.Context, key K, value V, opts *EntryOptions) error {
+	data, err := c.codec.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+
+	var o EntryOptions
+	if opts != nil {
+		o = *opts
+	}
+
+	return c.driver.Set(ctx, c.key(key), data, c.entryTTL(o.TTL), o.Tags)
+}
+
+// GetOrLoad returns the cached value or loads and caches it on a miss.
+// Concurrent loads of a key are deduplicated and run with a context that
+// is not canceled with the context of the caller. Failed cache reads and
+// writes are logged and fall back to the loader, load errors are not cached.
+// Loaded values are not cached if the key is deleted or tags are
+// invalidated during the load by a cache of the same manager, as the value
+// might be stale. Invalidations of other instances are not detected.
+func (c *Cache[K, V]) GetOrLoad(
+	ctx context.Context,
+	key K,
+	load func(ctx context.Context) (V, error),
+	opts *EntryOptions,
+) (V, error) {
+	v, err := c.Get(ctx, key)
+	if err == nil {
+		return v, nil
+	}
+
+	if !errors.Is(err, ErrNotFound) {
+		c.log.Warn("failed reading cache entry", zap.Any("key", key), zap.Error(err))
+	}
+
+	ch := c.group.DoChan(c.key(key), func() (any, error) {
+		loadCtx := context.WithoutCancel(ctx)
+
+		l := c.loads.start(c.key(key))
+		defer c.loads.finish(l)
+
+		v, err := load(loadCtx)
+		if err != nil {
+			return v, err
+		}
+
+		l.store(func() {
+			if err := c.Set(loadCtx, key, v, opts); err != nil {
+				c.log.Warn("failed writing cache entry", zap.Any("key", key), zap.Error(err))
+			}
+		})
+
+		return v, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return v, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return v, res.Err
+		}
+
+		// nil interface values do not assert to V
+		v, _ = res.Val.(V)
+		return v, nil
+	}
+}
+
+// Delete removes the cached values
+func (c *Cache[K, V]) Delete(ctx context.Context, keys ...K) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = c.key(key)
+	}
+
+	c.loads.invalidateKeys(names...)
+
+	return c.driver.Delete(ctx, names...)
+}
+
+// InvalidateTags removes the entries of the tags, including the entries
+// of other caches using the same driver
+func (c *Cache[K, V]) InvalidateTags(ctx context.Context, tags ...string) error {
+	c.loads.invalidateTags(tags...)
+
+	return c.driver.InvalidateTags(ctx, tags...)
+}
+
+func (c *Cache[K, V]) key(key K) string {
+	return c.namespace + ":" + fmt.Sprint(key)
+}
+
+// entryTTL returns the TTL of an entry, shortened by a random jitter
+func (c *Cache[K, V]) entryTTL(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		ttl = c.ttl
+	}
+
+	if c.jitter <= 0 {
+		return ttl
+	}
+
+	return ttl - time.Duration(rand.Float64()*c.jitter*float64(ttl))
+}
+
+// MARK: - Loads
+
+// loads tracks the in-flight loads of GetOrLoad, so that values loaded
+// while their key is deleted or tags are invalidated are not cached.
+// Stores of loaded values hold a read lock, invalidations mark the loads
+// holding the write lock before they are passed to the driver, so that
+// every store either happens before the invalidation or is skipped.
+type loads struct {
+	mu sync.RWMutex
+
+	// epoch is incremented by tag invalidations, which may affect any key
+	epoch uint64
+
+	// keys are the in-flight loads by key
+	keys map[string]*keyLoads
+}
+
+// keyLoads are the in-flight loads of a key, of caches sharing a namespace
+type keyLoads struct {
+	count   int
+	deletes uint64
+}
+
+// load is an in-flight load of a key
+type load struct {
+	loads   *loads
+	key     string
+	state   *keyLoads
+	epoch   uint64
+	deletes uint64
+}
+
+func newLoads() *loads {
+	return &loads{keys: map[string]*keyLoads{}}
+}
+
+// start registers a load of the key
+func (l *loads) start(key string) *load {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state, ok := l.keys[key]
+	if !ok {
+		state = &keyLoads{}
+		l.keys[key] = state
+	}
+	state.count++
+
+	return &load{loads: l, key: key, state: state, epoch: l.epoch, deletes: state.deletes}
+}
+
+// finish unregisters the load
+func (l *loads) finish(ld *load) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if ld.state.count--; ld.state.count == 0 {
+		delete(l.keys, ld.key)
+	}
+}
+
+// invalidateKeys marks the loads of the keys as stale
+func (l *loads) invalidateKeys(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range keys {
+		if state, ok := l.keys[key]; ok {
+			state.deletes++
+		}
+	}
+}
+
+// invalidateTags marks all loads as stale, the tags of the loads are not
+// known before they are stored
+func (l *loads) invalidateTags(tags ...string) {
+	if len(tags) == 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.epoch++
+}
+
+// store calls fn unless the load became stale
+func (ld *load) store(fn func()) {
+	ld.loads.mu.RLock()
+	defer ld.loads.mu.RUnlock()
+
+	if ld.loads.epoch != ld.epoch || ld.state.deletes != ld.deletes {
+		return
+	}
+
+	fn()
+}
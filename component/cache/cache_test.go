@@ -0,0 +1,248 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fruitsco/goji/component/cache"
+	cachememory "github.com/fruitsco/goji/component/cache/memory"
+	"github.com/fruitsco/goji/x/driver"
+)
+
+type user struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// ttlDriver records the TTL of the entries set
+type ttlDriver struct {
+	cache.Driver
+	ttls []time.Duration
+}
+
+func (d *ttlDriver) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags []string) error {
+	d.ttls = append(d.ttls, ttl)
+	return d.Driver.Set(ctx, key, value, ttl, tags)
+}
+
+func newManager(d cache.Driver, config *cache.Config) *cache.Manager {
+	config.Driver = cache.Memory
+
+	return cache.New(cache.CacheParams{
+		Drivers: []*driver.Factory[cache.DriverName, cache.Driver]{
+			driver.NewFactory(cache.Memory, func() (cache.Driver, error) {
+				return d, nil
+			}).Factory,
+		},
+		Config: config,
+	})
+}
+
+func TestCache(t *testing.T) {
+	codecs := map[string]cache.Codec{
+		"json": cache.JSONCodec{},
+		"gob":  cache.GobCodec{},
+	}
+
+	for name, codec := range codecs {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			m := newManager(cachememory.NewMemoryDriver(cachememory.MemoryDriverParams{}), &cache.Config{})
+
+			users, err := cache.NewCache[int64, user](m, "users", &cache.Options{Codec: codec})
+			require.NoError(t, err)
+
+			_, err = users.Get(ctx, 1)
+			assert.ErrorIs(t, err, cache.ErrNotFound)
+
+			require.NoError(t, users.Set(ctx, 1, user{ID: 1, Name: "ada"}, nil))
+
+			u, err := users.Get(ctx, 1)
+			require.NoError(t, err)
+			assert.Equal(t, user{ID: 1, Name: "ada"}, u)
+
+			require.NoError(t, users.Delete(ctx, 1))
+
+			_, err = users.Get(ctx, 1)
+			assert.ErrorIs(t, err, cache.ErrNotFound)
+		})
+	}
+}
+
+func TestCache_Namespaces(t *testing.T) {
+	ctx := context.Background()
+	m := newManager(cachememory.NewMemoryDriver(cachememory.MemoryDriverParams{}), &cache.Config{})
+
+	users, err := cache.NewCache[int64, string](m, "users", nil)
+	require.NoError(t, err)
+
+	orgs, err := cache.NewCache[int64, string](m, "orgs", nil)
+	require.NoError(t, err)
+
+	require.NoError(t, users.Set(ctx, 1, "ada", &cache.EntryOptions{Tags: []string{"org:1"}}))
+	require.NoError(t, orgs.Set(ctx, 1, "fruits", &cache.EntryOptions{Tags: []string{"org:1"}}))
+
+	_, err = orgs.Get(ctx, 2)
+	assert.ErrorIs(t, err, cache.ErrNotFound)
+
+	name, err := orgs.Get(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "fruits", name)
+
+	// tags span the caches of a driver
+	require.NoError(t, m.InvalidateTags(ctx, "org:1"))
+
+	_, err = users.Get(ctx, 1)
+	assert.ErrorIs(t, err, cache.ErrNotFound)
+	_, err = orgs.Get(ctx, 1)
+	assert.ErrorIs(t, err, cache.ErrNotFound)
+}
+
+func TestCache_GetOrLoad(t *testing.T) {
+	ctx := context.Background()
+	m := newManager(cachememory.NewMemoryDriver(cachememory.MemoryDriverParams{}), &cache.Config{})
+
+	users, err := cache.NewCache[int64, user](m, "users", nil)
+	require.NoError(t, err)
+
+	var loads atomic.Int32
+	release := make(chan struct{})
+
+	load := func(ctx context.Context) (user, error) {
+		loads.Add(1)
+		<-release
+		return user{ID: 1, Name: "ada"}, nil
+	}
+
+	results := make([]user, 10)
+
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = users.GetOrLoad(ctx, 1, load, nil)
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), loads.Load(), "concurrent loads are deduplicated")
+	for _, u := range results {
+		assert.Equal(t, "ada", u.Name)
+	}
+
+	u, err := users.GetOrLoad(ctx, 1, func(context.Context) (user, error) {
+		t.Fatal("cached value is loaded")
+		return user{}, nil
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "ada", u.Name)
+
+	// load errors are not cached
+	_, err = users.GetOrLoad(ctx, 2, func(context.Context) (user, error) {
+		return user{}, errors.New("unavailable")
+	}, nil)
+	assert.EqualError(t, err, "unavailable")
+
+	_, err = users.Get(ctx, 2)
+	assert.ErrorIs(t, err, cache.ErrNotFound)
+}
+
+func TestCache_GetOrLoadInvalidated(t *testing.T) {
+	ctx := context.Background()
+	m := newManager(cachememory.NewMemoryDriver(cachememory.MemoryDriverParams{}), &cache.Config{})
+
+	users, err := cache.NewCache[int64, user](m, "users", nil)
+	require.NoError(t, err)
+
+	invalidations := map[string]func() error{
+		"delete":       func() error { return users.Delete(ctx, 1) },
+		"cache tags":   func() error { return users.InvalidateTags(ctx, "org:1") },
+		"manager tags": func() error { return m.InvalidateTags(ctx, "org:1") },
+	}
+
+	for name, invalidate := range invalidations {
+		t.Run(name, func(t *testing.T) {
+			started, release := make(chan struct{}), make(chan struct{})
+
+			done := make(chan user)
+			go func() {
+				u, _ := users.GetOrLoad(ctx, 1, func(context.Context) (user, error) {
+					close(started)
+					<-release
+					return user{ID: 1, Name: "stale"}, nil
+				}, &cache.EntryOptions{Tags: []string{"org:1"}})
+				done <- u
+			}()
+
+			<-started
+			require.NoError(t, invalidate())
+			close(release)
+
+			// the caller gets the loaded value, which is not cached
+			assert.Equal(t, "stale", (<-done).Name)
+
+			_, err := users.Get(ctx, 1)
+			assert.ErrorIs(t, err, cache.ErrNotFound)
+		})
+	}
+
+	// loads of other keys are not affected by deletes
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = users.GetOrLoad(ctx, 2, func(context.Context) (user, error) {
+			close(started)
+			<-release
+			return user{ID: 2, Name: "grace"}, nil
+		}, nil)
+	}()
+
+	<-started
+	require.NoError(t, users.Delete(ctx, 1))
+	close(release)
+	<-done
+
+	u, err := users.Get(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "grace", u.Name)
+}
+
+func TestCache_TTL(t *testing.T) {
+	ctx := context.Background()
+
+	d := &ttlDriver{Driver: cachememory.NewMemoryDriver(cachememory.MemoryDriverParams{})}
+	m := newManager(d, &cache.Config{TTLSeconds: 100, TTLJitter: 0.2})
+
+	c, err := cache.NewCache[string, string](m, "jitter", nil)
+	require.NoError(t, err)
+
+	for range 20 {
+		require.NoError(t, c.Set(ctx, "a", "v", nil))
+	}
+	require.NoError(t, c.Set(ctx, "a", "v", &cache.EntryOptions{TTL: 10 * time.Second}))
+
+	for _, ttl := range d.ttls[:20] {
+		assert.LessOrEqual(t, ttl, 100*time.Second)
+		assert.GreaterOrEqual(t, ttl, 80*time.Second)
+	}
+	assert.LessOrEqual(t, d.ttls[20], 10*time.Second)
+	assert.GreaterOrEqual(t, d.ttls[20], 8*time.Second)
+
+	c, err = cache.NewCache[string, string](m, "exact", &cache.Options{TTL: time.Minute, TTLJitter: -1})
+	require.NoError(t, err)
+
+	require.NoError(t, c.Set(ctx, "a", "v", nil))
+	assert.Equal(t, time.Minute, d.ttls[21])
+}
@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"github.com/fruitsco/goji/component/redis"
+	"github.com/fruitsco/goji/conf"
+)
+
+type DriverName string
+
+const (
+	// Redis is a driver for Redis, shared by all instances of the app
+	Redis DriverName = "redis"
+
+	// Memory is an in-process LRU driver
+	Memory DriverName = "memory"
+)
+
+type Config struct {
+	// Driver is the default driver of caches
+	Driver DriverName `conf:"driver"`
+
+	// TTLSeconds is the default time in seconds an entry is cached
+	TTLSeconds int `conf:"ttl_seconds"`
+
+	// TTLJitter shortens the TTL of each entry by a random fraction of up
+	// to the given value between 0 and 1, so that entries cached at the
+	// same time do not expire at once
+	TTLJitter float64 `conf:"ttl_jitter"`
+
+	// Memory is the configuration for the in-process LRU driver
+	Memory *MemoryConfig `conf:"memory"`
+
+	// Redis is the configuration for Redis
+	Redis *RedisConfig `conf:"redis"`
+}
+
+// DefaultConfig is the default configuration for caches
+var DefaultConfig = conf.DefaultConfig{
+	"cache.driver":                "redis",
+	"cache.ttl_seconds":           "300",
+	"cache.ttl_jitter":            "0.1",
+	"cache.memory.max_entries":    "10000",
+	"cache.redis.connection_name": "default",
+	"cache.redis.key_prefix":      "cache:",
+}
+
+// MARK: - Memory
+
+// MemoryConfig is the configuration for the in-process LRU driver
+type MemoryConfig struct {
+	// MaxEntries is the number of entries kept, the least recently used
+	// entries are evicted first. Unlimited if zero.
+	MaxEntries int `conf:"max_entries"`
+}
+
+// MARK: - Redis
+
+// RedisConfig is the configuration for the Redis driver
+type RedisConfig struct {
+	// ConnectionName is the name of the Redis connection to use
+	ConnectionName redis.ConnectionName `conf:"connection_name"`
+
+	// KeyPrefix is prepended to the keys of the entries and tags
+	KeyPrefix string `conf:"key_prefix"`
+}
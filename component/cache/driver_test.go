@@ -0,0 +1,139 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fruitsco/goji/component/cache"
+	cachememory "github.com/fruitsco/goji/component/cache/memory"
+	cacheredis "github.com/fruitsco/goji/component/cache/redis"
+	"github.com/fruitsco/goji/component/redis/redistest"
+)
+
+// ttl is the TTL of the entries set by the driver tests
+const ttl = 100 * time.Millisecond
+
+// driverTest tests a driver, advance expires entries by moving its clock
+type driverTest func(t *testing.T, ctx context.Context, d cache.Driver, advance func(time.Duration))
+
+// testDrivers runs the test against an empty memory and redis cache
+func testDrivers(t *testing.T, test driverTest) {
+	t.Run("memory", func(t *testing.T) {
+		now := time.Now()
+
+		d := cachememory.NewMemoryDriver(cachememory.MemoryDriverParams{
+			Now: func() time.Time { return now },
+		})
+
+		test(t, context.Background(), d, func(d time.Duration) { now = now.Add(d) })
+	})
+
+	t.Run("redis", func(t *testing.T) {
+		r, srv := redistest.New(t)
+
+		d, err := cacheredis.NewRedisDriver(cacheredis.RedisDriverParams{
+			Config: &cache.RedisConfig{KeyPrefix: "cache:"},
+			Redis:  r,
+		})
+		require.NoError(t, err)
+
+		test(t, context.Background(), d, srv.FastForward)
+	})
+}
+
+func TestDriver_Set(t *testing.T) {
+	testDrivers(t, func(t *testing.T, ctx context.Context, d cache.Driver, _ func(time.Duration)) {
+		_, err := d.Get(ctx, "users:1")
+		assert.ErrorIs(t, err, cache.ErrNotFound)
+
+		require.NoError(t, d.Set(ctx, "users:1", []byte("v1"), ttl, nil))
+
+		value, err := d.Get(ctx, "users:1")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v1"), value)
+
+		require.NoError(t, d.Set(ctx, "users:1", []byte("v2"), ttl, nil))
+
+		value, err = d.Get(ctx, "users:1")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v2"), value)
+	})
+}
+
+func TestDriver_Expiry(t *testing.T) {
+	testDrivers(t, func(t *testing.T, ctx context.Context, d cache.Driver, advance func(time.Duration)) {
+		require.NoError(t, d.Set(ctx, "users:1", []byte("v"), ttl, nil))
+		require.NoError(t, d.Set(ctx, "users:2", []byte("v"), 10*ttl, nil))
+
+		advance(2 * ttl)
+
+		_, err := d.Get(ctx, "users:1")
+		assert.ErrorIs(t, err, cache.ErrNotFound)
+
+		_, err = d.Get(ctx, "users:2")
+		assert.NoError(t, err)
+	})
+}
+
+func TestDriver_Delete(t *testing.T) {
+	testDrivers(t, func(t *testing.T, ctx context.Context, d cache.Driver, _ func(time.Duration)) {
+		for _, key := range []string{"users:1", "users:2", "users:3"} {
+			require.NoError(t, d.Set(ctx, key, []byte("v"), ttl, nil))
+		}
+
+		require.NoError(t, d.Delete(ctx, "users:1", "users:2", "users:4"))
+
+		for _, key := range []string{"users:1", "users:2"} {
+			_, err := d.Get(ctx, key)
+			assert.ErrorIs(t, err, cache.ErrNotFound, key)
+		}
+
+		_, err := d.Get(ctx, "users:3")
+		assert.NoError(t, err)
+	})
+}
+
+func TestDriver_InvalidateTags(t *testing.T) {
+	testDrivers(t, func(t *testing.T, ctx context.Context, d cache.Driver, _ func(time.Duration)) {
+		require.NoError(t, d.Set(ctx, "users:1", []byte("v"), 10*ttl, []string{"red"}))
+		require.NoError(t, d.Set(ctx, "users:2", []byte("v"), 10*ttl, []string{"red", "blue"}))
+		require.NoError(t, d.Set(ctx, "users:3", []byte("v"), 10*ttl, []string{"green"}))
+
+		require.NoError(t, d.InvalidateTags(ctx, "blue"))
+
+		_, err := d.Get(ctx, "users:2")
+		assert.ErrorIs(t, err, cache.ErrNotFound)
+
+		_, err = d.Get(ctx, "users:1")
+		assert.NoError(t, err, "entries of other tags are kept")
+
+		require.NoError(t, d.InvalidateTags(ctx, "red", "missing"))
+
+		_, err = d.Get(ctx, "users:1")
+		assert.ErrorIs(t, err, cache.ErrNotFound)
+
+		_, err = d.Get(ctx, "users:3")
+		assert.NoError(t, err)
+	})
+}
+
+func TestDriver_TagsDoNotCollideWithEntries(t *testing.T) {
+	testDrivers(t, func(t *testing.T, ctx context.Context, d cache.Driver, _ func(time.Duration)) {
+		// an entry of a cache named `tags`, next to the tag `org`
+		require.NoError(t, d.Set(ctx, "tags:org", []byte("entry"), ttl, nil))
+		require.NoError(t, d.Set(ctx, "users:1", []byte("ada"), ttl, []string{"org"}))
+
+		require.NoError(t, d.InvalidateTags(ctx, "org"))
+
+		value, err := d.Get(ctx, "tags:org")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("entry"), value)
+
+		_, err = d.Get(ctx, "users:1")
+		assert.ErrorIs(t, err, cache.ErrNotFound)
+	})
+}
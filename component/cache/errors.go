@@ -0,0 +1,8 @@
+package cache
+
+import "errors"
+
+var (
+	// ErrNotFound is returned if an entry is not cached or expired
+	ErrNotFound = errors.New("cache entry not found")
+)
@@ -0,0 +1,175 @@
+package cachememory
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/fx"
+
+	"github.com/fruitsco/goji/component/cache"
+	"github.com/fruitsco/goji/x/driver"
+)
+
+// memoryEntry is a cached value with the tags it was added to
+type memoryEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+	tags    []string
+}
+
+// MemoryDriver is an in-process LRU cache driver. Entries are not shared
+// between instances of the app.
+type MemoryDriver struct {
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+	tags    map[string]map[string]struct{}
+}
+
+// MemoryDriverParams is the parameters for the memory driver
+type MemoryDriverParams struct {
+	fx.In
+
+	// Config is the configuration for the memory driver, optional
+	Config *cache.MemoryConfig `optional:"true"`
+
+	// Now returns the current time, optional, defaults to time.Now
+	Now func() time.Time `optional:"true"`
+}
+
+// NewMemoryDriverFactory creates a new memory driver factory
+func NewMemoryDriverFactory(params MemoryDriverParams) driver.FactoryResult[cache.DriverName, cache.Driver] {
+	return driver.NewFactory(cache.Memory, func() (cache.Driver, error) {
+		return NewMemoryDriver(params), nil
+	})
+}
+
+// NewMemoryDriver creates a new memory driver
+func NewMemoryDriver(params MemoryDriverParams) *MemoryDriver {
+	d := &MemoryDriver{
+		now:     time.Now,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+		tags:    make(map[string]map[string]struct{}),
+	}
+
+	if params.Config != nil {
+		d.maxEntries = params.Config.MaxEntries
+	}
+
+	if params.Now != nil {
+		d.now = params.Now
+	}
+
+	return d
+}
+
+var _ = cache.Driver(&MemoryDriver{})
+
+// Get returns the value and marks the entry as recently used
+func (d *MemoryDriver) Get(_ context.Context, key string) ([]byte, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	el, ok := d.entries[key]
+	if !ok {
+		return nil, cache.ErrNotFound
+	}
+
+	e := el.Value.(*memoryEntry)
+	if !d.now().Before(e.expires) {
+		d.remove(el)
+		return nil, cache.ErrNotFound
+	}
+
+	d.lru.MoveToFront(el)
+
+	return e.value, nil
+}
+
+// Set caches the value, evicting the least recently used entry if full
+func (d *MemoryDriver) Set(_ context.Context, key string, value []byte, ttl time.Duration, tags []string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if el, ok := d.entries[key]; ok {
+		d.remove(el)
+	}
+
+	e := &memoryEntry{
+		key:     key,
+		value:   value,
+		expires: d.now().Add(ttl),
+		tags:    tags,
+	}
+
+	d.entries[key] = d.lru.PushFront(e)
+
+	for _, tag := range tags {
+		keys, ok := d.tags[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			d.tags[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+
+	if d.maxEntries > 0 && d.lru.Len() > d.maxEntries {
+		d.remove(d.lru.Back())
+	}
+
+	return nil
+}
+
+// Delete removes the entries
+func (d *MemoryDriver) Delete(_ context.Context, keys ...string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, key := range keys {
+		if el, ok := d.entries[key]; ok {
+			d.remove(el)
+		}
+	}
+
+	return nil
+}
+
+// InvalidateTags removes the entries of the tags
+func (d *MemoryDriver) InvalidateTags(_ context.Context, tags ...string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range d.tags[tag] {
+			if el, ok := d.entries[key]; ok {
+				d.remove(el)
+			}
+		}
+
+		delete(d.tags, tag)
+	}
+
+	return nil
+}
+
+// remove removes the entry from the list, the index and its tags
+func (d *MemoryDriver) remove(el *list.Element) {
+	e := d.lru.Remove(el).(*memoryEntry)
+	delete(d.entries, e.key)
+
+	for _, tag := range e.tags {
+		if keys, ok := d.tags[tag]; ok {
+			delete(keys, e.key)
+			if len(keys) == 0 {
+				delete(d.tags, tag)
+			}
+		}
+	}
+}
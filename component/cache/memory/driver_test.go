@@ -0,0 +1,38 @@
+package cachememory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fruitsco/goji/component/cache"
+	cachememory "github.com/fruitsco/goji/component/cache/memory"
+)
+
+func TestMemoryDriver_Evict(t *testing.T) {
+	ctx := context.Background()
+
+	d := cachememory.NewMemoryDriver(cachememory.MemoryDriverParams{
+		Config: &cache.MemoryConfig{MaxEntries: 2},
+	})
+
+	require.NoError(t, d.Set(ctx, "a", []byte("a"), time.Minute, nil))
+	require.NoError(t, d.Set(ctx, "b", []byte("b"), time.Minute, nil))
+
+	// a is used more recently than b
+	_, err := d.Get(ctx, "a")
+	require.NoError(t, err)
+
+	require.NoError(t, d.Set(ctx, "c", []byte("c"), time.Minute, nil))
+
+	_, err = d.Get(ctx, "b")
+	assert.ErrorIs(t, err, cache.ErrNotFound)
+
+	for _, key := range []string{"a", "c"} {
+		_, err := d.Get(ctx, key)
+		assert.NoError(t, err, key)
+	}
+}
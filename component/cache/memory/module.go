@@ -0,0 +1,16 @@
+package cachememory
+
+import (
+	"go.uber.org/fx"
+
+	"github.com/fruitsco/goji/component/cache"
+)
+
+func Module() fx.Option {
+	return fx.Options(
+		fx.Provide(func(cfg *cache.Config) *cache.MemoryConfig {
+			return cfg.Memory
+		}),
+		fx.Provide(NewMemoryDriverFactory),
+	)
+}
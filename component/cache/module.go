@@ -0,0 +1,16 @@
+package cache
+
+import (
+	"go.uber.org/fx"
+
+	"github.com/fruitsco/goji/x/logging"
+)
+
+func Module(cfg *Config) fx.Option {
+	return fx.Module("cache",
+		fx.Decorate(logging.NamedLogger("cache")),
+
+		fx.Supply(cfg),
+		fx.Provide(New),
+	)
+}
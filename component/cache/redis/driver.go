@@ -0,0 +1,169 @@
+package cacheredis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"go.uber.org/fx"
+
+	"github.com/fruitsco/goji/component/cache"
+	"github.com/fruitsco/goji/component/redis"
+	"github.com/fruitsco/goji/x/driver"
+)
+
+// RedisDriver is the cache driver for Redis. Tags are sets of entry keys,
+// which expire with their last entry. Tags require Redis 7 or later.
+//
+// Only single key commands are used, so that entries and tags can be
+// spread across the nodes of a Redis Cluster.
+type RedisDriver struct {
+	config *cache.RedisConfig
+	redis  redis.Client
+}
+
+// RedisDriverParams is the parameters for the Redis driver
+type RedisDriverParams struct {
+	fx.In
+
+	// Config is the configuration for the Redis driver
+	Config *cache.RedisConfig
+
+	// Redis is the Redis connection
+	Redis *redis.Redis
+}
+
+// NewRedisDriverFactory creates a new Redis driver factory
+func NewRedisDriverFactory(params RedisDriverParams) driver.FactoryResult[cache.DriverName, cache.Driver] {
+	return driver.NewFactory(cache.Redis, func() (cache.Driver, error) {
+		return NewRedisDriver(params)
+	})
+}
+
+// NewRedisDriver creates a new Redis driver
+func NewRedisDriver(params RedisDriverParams) (*RedisDriver, error) {
+	if params.Config == nil {
+		return nil, fmt.Errorf("config is required for Redis driver")
+	}
+
+	if params.Config.ConnectionName == "" {
+		params.Config.ConnectionName = redis.DefaultConnectionName
+	}
+
+	connection, err := params.Redis.Connection(params.Config.ConnectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RedisDriver{
+		config: params.Config,
+		redis:  connection,
+	}, nil
+}
+
+var _ = cache.Driver(&RedisDriver{})
+
+// Get returns the value of the entry
+func (d *RedisDriver) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := d.redis.Get(ctx, d.getKeyName(key)).Bytes()
+	if errors.Is(err, goredis.Nil) {
+		return nil, cache.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cache entry: %w", err)
+	}
+
+	return value, nil
+}
+
+// Set stores the entry and adds it to the tags, whose expiry is extended
+// to the expiry of the entry
+func (d *RedisDriver) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags []string) error {
+	name := d.getKeyName(key)
+
+	_, err := d.redis.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		pipe.Set(ctx, name, value, ttl)
+
+		for _, tag := range tags {
+			tagName := d.getTagKeyName(tag)
+
+			pipe.SAdd(ctx, tagName, name)
+			pipe.Do(ctx, "PEXPIRE", tagName, ttl.Milliseconds(), "NX")
+			pipe.Do(ctx, "PEXPIRE", tagName, ttl.Milliseconds(), "GT")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set cache entry: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the entries
+func (d *RedisDriver) Delete(ctx context.Context, keys ...string) error {
+	_, err := d.redis.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		for _, key := range keys {
+			pipe.Del(ctx, d.getKeyName(key))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete cache entries: %w", err)
+	}
+
+	return nil
+}
+
+// InvalidateTags removes the entries of the tags. Entries added to a tag
+// while it is invalidated are kept.
+func (d *RedisDriver) InvalidateTags(ctx context.Context, tags ...string) error {
+	for _, tag := range tags {
+		tagName := d.getTagKeyName(tag)
+
+		names, err := d.redis.SMembers(ctx, tagName).Result()
+		if err != nil {
+			return fmt.Errorf("failed to read cache tag %s: %w", tag, err)
+		}
+
+		if len(names) == 0 {
+			continue
+		}
+
+		members := make([]any, len(names))
+		for i, name := range names {
+			members[i] = name
+		}
+
+		_, err = d.redis.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+			for _, name := range names {
+				pipe.Del(ctx, name)
+			}
+
+			pipe.SRem(ctx, tagName, members...)
+
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to invalidate cache tag %s: %w", tag, err)
+		}
+	}
+
+	return nil
+}
+
+// getKeyName returns the key of an entry. Entries and tags have separate
+// prefixes, so that entry keys cannot collide with tags, e.g. of a cache
+// named `tags`.
+func (d *RedisDriver) getKeyName(key string) string {
+	return d.config.KeyPrefix + "entries:" + key
+}
+
+// getTagKeyName returns the key of the set of entries of a tag
+func (d *RedisDriver) getTagKeyName(tag string) string {
+	return d.config.KeyPrefix + "tags:" + tag
+}
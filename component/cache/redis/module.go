@@ -0,0 +1,16 @@
+package cacheredis
+
+import (
+	"go.uber.org/fx"
+
+	"github.com/fruitsco/goji/component/cache"
+)
+
+func Module() fx.Option {
+	return fx.Options(
+		fx.Provide(func(cfg *cache.Config) *cache.RedisConfig {
+			return cfg.Redis
+		}),
+		fx.Provide(NewRedisDriverFactory),
+	)
+}
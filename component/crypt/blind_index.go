@@ -0,0 +1,180 @@
+package crypt
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// blindIndexPrefix is the prefix of an encoded blind index
+const blindIndexPrefix = "b1:"
+
+// minHMACKeySize is the minimum size of HMAC keys, e.g. for blind indexes
+const minHMACKeySize = 16
+
+// BlindIndex is a keyed HMAC-SHA256 of a value. It is stored next to
+// the capsule of the value and allows equality lookups without revealing
+// the value. Values should be normalized before indexing, e.g. lowercased
+// emails, as the index only matches identical values.
+type BlindIndex struct {
+	// KeyVersion is the version of the key the index was computed with.
+	KeyVersion int
+
+	// Hash is the HMAC of the value.
+	Hash []byte
+}
+
+// String encodes the blind index as `b1:<key version>:<base64 hash>`
+func (b BlindIndex) String() string {
+	return blindIndexPrefix + strconv.Itoa(b.KeyVersion) + ":" + base64.RawURLEncoding.EncodeToString(b.Hash)
+}
+
+// ParseBlindIndex decodes a blind index encoded by String
+func ParseBlindIndex(s string) (BlindIndex, error) {
+	rest, ok := strings.CutPrefix(s, blindIndexPrefix)
+	if !ok {
+		return BlindIndex{}, errors.New("invalid blind index: missing prefix")
+	}
+
+	version, hash, ok := strings.Cut(rest, ":")
+	if !ok {
+		return BlindIndex{}, errors.New("invalid blind index: missing key version")
+	}
+
+	v, err := strconv.Atoi(version)
+	if err != nil {
+		return BlindIndex{}, fmt.Errorf("invalid blind index: %w", err)
+	}
+
+	h, err := base64.RawURLEncoding.DecodeString(hash)
+	if err != nil {
+		return BlindIndex{}, fmt.Errorf("invalid blind index: %w", err)
+	}
+
+	return BlindIndex{KeyVersion: v, Hash: h}, nil
+}
+
+var _ = driver.Valuer(&BlindIndex{})
+
+// Value implements the driver.Valuer interface
+func (b BlindIndex) Value() (driver.Value, error) {
+	return b.String(), nil
+}
+
+var _ = sql.Scanner(&BlindIndex{})
+
+// Scan implements the sql.Scanner interface
+func (b *BlindIndex) Scan(value interface{}) error {
+	var s string
+
+	switch v := value.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return fmt.Errorf("unsupported blind index type %T", value)
+	}
+
+	parsed, err := ParseBlindIndex(s)
+	if err != nil {
+		return err
+	}
+
+	*b = parsed
+
+	return nil
+}
+
+// BlindIndex computes the blind index of the value using the latest
+// version of the key with the given name.
+func (c *Crypto) BlindIndex(ctx context.Context, keyName string, value []byte) (BlindIndex, error) {
+	key, err := c.keyProvider.GetKey(ctx, keyName)
+	if err != nil {
+		return BlindIndex{}, fmt.Errorf("failed to get blind index key: %w", err)
+	}
+
+	return blindIndexWithKey(key, value)
+}
+
+// BlindIndexes computes the blind indexes of the value for the latest
+// versions of the key, newest first. While a key rotation is migrated,
+// rows are looked up by all of them, e.g. `WHERE email_idx = ANY($1)`.
+func (c *Crypto) BlindIndexes(ctx context.Context, keyName string, value []byte, versions int) ([]BlindIndex, error) {
+	latest, err := c.keyProvider.GetKey(ctx, keyName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blind index key: %w", err)
+	}
+
+	first, err := blindIndexWithKey(latest, value)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := []BlindIndex{first}
+
+	for v := latest.Version - 1; v >= 1 && len(indexes) < versions; v-- {
+		key, err := c.keyProvider.GetKeyVersion(ctx, keyName, v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get blind index key version %d: %w", v, err)
+		}
+
+		index, err := blindIndexWithKey(key, value)
+		if err != nil {
+			return nil, err
+		}
+
+		indexes = append(indexes, index)
+	}
+
+	return indexes, nil
+}
+
+// RotateBlindIndex migrates a blind index to the latest version of the key.
+// The value is recovered by decrypting the capsule stored next to the index.
+// The index is returned as is if it already uses the latest key version.
+func (c *Crypto) RotateBlindIndex(
+	ctx context.Context,
+	keyName string,
+	index BlindIndex,
+	capsule Capsule,
+	aad []byte,
+) (BlindIndex, error) {
+	latest, err := c.keyProvider.GetKey(ctx, keyName)
+	if err != nil {
+		return BlindIndex{}, fmt.Errorf("failed to get blind index key: %w", err)
+	}
+
+	if index.KeyVersion == latest.Version {
+		return index, nil
+	}
+
+	value, err := c.DecryptWithAAD(ctx, capsule, aad)
+	if err != nil {
+		return BlindIndex{}, fmt.Errorf("failed to decrypt capsule: %w", err)
+	}
+	defer clear(value)
+
+	return blindIndexWithKey(latest, value)
+}
+
+func blindIndexWithKey(key Key, value []byte) (BlindIndex, error) {
+	if len(key.Data) < minHMACKeySize {
+		return BlindIndex{}, fmt.Errorf("blind index key must be at least %d bytes", minHMACKeySize)
+	}
+
+	mac := hmac.New(sha256.New, key.Data)
+	mac.Write(value)
+
+	return BlindIndex{
+		KeyVersion: key.Version,
+		Hash:       mac.Sum(nil),
+	}, nil
+}
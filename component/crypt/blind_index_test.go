@@ -0,0 +1,107 @@
+package crypt_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fruitsco/goji/component/crypt"
+)
+
+func TestCrypto_BlindIndex(t *testing.T) {
+	ctx := context.Background()
+
+	keys := memoryKeyProvider{}
+	keys.rotate("data")
+	keys.rotate("email-index")
+
+	c := newCrypto(t, keys, false)
+
+	email := []byte("alice@example.com")
+	aad := crypt.AssociatedData("users", "email", "42")
+
+	capsule, err := c.EncryptWithAAD(ctx, email, "data", aad)
+	require.NoError(t, err)
+
+	index, err := c.BlindIndex(ctx, "email-index", email)
+	require.NoError(t, err)
+
+	// the index is deterministic and survives a database round trip
+	again, err := c.BlindIndex(ctx, "email-index", email)
+	require.NoError(t, err)
+	assert.Equal(t, index.String(), again.String())
+
+	value, err := index.Value()
+	require.NoError(t, err)
+
+	var scanned crypt.BlindIndex
+	require.NoError(t, scanned.Scan([]byte(value.(string))))
+	assert.Equal(t, index, scanned)
+
+	other, err := c.BlindIndex(ctx, "email-index", []byte("bob@example.com"))
+	require.NoError(t, err)
+	assert.NotEqual(t, index.Hash, other.Hash)
+
+	// after rotation, lookups cover the previous version
+	keys.rotate("email-index")
+
+	candidates, err := c.BlindIndexes(ctx, "email-index", email, 2)
+	require.NoError(t, err)
+	require.Len(t, candidates, 2)
+	assert.Equal(t, 2, candidates[0].KeyVersion)
+	assert.Equal(t, index, candidates[1])
+
+	// and the migration helper moves rows to the latest version
+	rotated, err := c.RotateBlindIndex(ctx, "email-index", index, capsule, aad)
+	require.NoError(t, err)
+	assert.Equal(t, candidates[0], rotated)
+
+	same, err := c.RotateBlindIndex(ctx, "email-index", rotated, capsule, aad)
+	require.NoError(t, err)
+	assert.Equal(t, rotated, same)
+}
+
+func TestCrypto_EncryptDeterministic(t *testing.T) {
+	ctx := context.Background()
+
+	keys := memoryKeyProvider{}
+	keys.rotate("phone")
+
+	c := newCrypto(t, keys, true)
+
+	aad := crypt.AssociatedData("users", "phone")
+
+	a, err := c.EncryptDeterministic(ctx, []byte("+4912345"), "phone", aad)
+	require.NoError(t, err)
+	b, err := c.EncryptDeterministic(ctx, []byte("+4912345"), "phone", aad)
+	require.NoError(t, err)
+
+	// equal plaintexts result in equal encoded capsules
+	av, err := a.Value()
+	require.NoError(t, err)
+	bv, err := b.Value()
+	require.NoError(t, err)
+	assert.Equal(t, av, bv)
+	assert.Equal(t, crypt.AlgorithmAESSIV, a.Algorithm())
+
+	var scanned crypt.Capsule
+	require.NoError(t, scanned.Scan(av))
+	assert.True(t, scanned.Deterministic)
+
+	plaintext, err := c.DecryptWithAAD(ctx, scanned, aad)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("+4912345"), plaintext)
+
+	_, err = c.DecryptWithAAD(ctx, scanned, crypt.AssociatedData("users", "email"))
+	require.Error(t, err)
+
+	// recrypting keeps the capsule deterministic, even in envelope mode
+	keys.rotate("phone")
+
+	recrypted, err := c.RecryptWithAAD(ctx, a, aad)
+	require.NoError(t, err)
+	assert.True(t, recrypted.Deterministic)
+	assert.Equal(t, 2, recrypted.KeyVersion)
+}
@@ -3,6 +3,11 @@ package crypt
 import "github.com/fruitsco/goji/conf"
 
 type Config struct {
+	// Envelope enables envelope encryption, data is encrypted with a data key
+	// per capsule, which is wrapped by the KeyWrapper using the named key.
+	Envelope bool `conf:"envelope"`
 }
 
-var DefaultConfig = conf.DefaultConfig{}
+var DefaultConfig = conf.DefaultConfig{
+	"crypt.envelope": "false",
+}
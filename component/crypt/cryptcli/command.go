@@ -0,0 +1,195 @@
+// Package cryptcli provides CLI commands to manage the encryption keys of
+// crypt in the configured vault, to be added to the app using
+// `CLIRoot.AddCommand`.
+package cryptcli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v3"
+	"go.uber.org/fx"
+
+	"github.com/fruitsco/goji"
+	"github.com/fruitsco/goji/component/crypt"
+	"github.com/fruitsco/goji/component/vault"
+	"github.com/fruitsco/goji/component/vault/vaultcli"
+)
+
+// KeysCommand creates the `keys` command with the `create`, `rotate` and
+// `list-versions` subcommands. The shell must provide the vault component:
+//
+//	app keys create users-email
+//	app keys create --purpose deterministic users-email-lookup
+//	app keys rotate users-email
+func KeysCommand[C any](shell *goji.Shell[C]) *cli.Command {
+	return &cli.Command{
+		Name:  "keys",
+		Usage: "manage the encryption keys stored in the vault",
+		Commands: []*cli.Command{
+			createCommand(shell),
+			rotateCommand(shell),
+			listVersionsCommand(shell),
+		},
+	}
+}
+
+func keyFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "purpose",
+			Usage: "purpose of the key, which determines its valid sizes: encryption, deterministic, hmac or ed25519",
+			Value: string(crypt.KeyPurposeEncryption),
+		},
+		&cli.IntFlag{
+			Name:  "size",
+			Usage: "size of the generated key in bytes, defaults to the size for the purpose",
+		},
+		&cli.StringFlag{
+			Name:  "import",
+			Usage: "file to import the raw key material from instead of generating a key",
+		},
+	}
+}
+
+func createCommand[C any](shell *goji.Shell[C]) *cli.Command {
+	return &cli.Command{
+		Name:      "create",
+		Usage:     "create a new key",
+		ArgsUsage: "<name>",
+		Flags:     keyFlags(),
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			name, err := vaultcli.NameArg(cmd)
+			if err != nil {
+				return err
+			}
+
+			key, err := keyMaterial(cmd, cmd.Int("size"))
+			if err != nil {
+				return err
+			}
+			defer clear(key)
+
+			var v vault.Vault
+
+			return shell.RunTask(ctx, func(ctx context.Context) error {
+				secret, err := v.CreateSecret(ctx, name, key)
+				if err != nil {
+					return err
+				}
+
+				fmt.Fprintf(cmd.Root().Writer, "created key %s version %d\n", secret.Name, secret.Version)
+
+				return nil
+			}, fx.Populate(&v))
+		},
+	}
+}
+
+func rotateCommand[C any](shell *goji.Shell[C]) *cli.Command {
+	return &cli.Command{
+		Name:      "rotate",
+		Usage:     "add a new version to a key, of the same size as the latest version unless --size is given, valid for --purpose",
+		ArgsUsage: "<name>",
+		Flags:     keyFlags(),
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			name, err := vaultcli.NameArg(cmd)
+			if err != nil {
+				return err
+			}
+
+			var v vault.Vault
+
+			return shell.RunTask(ctx, func(ctx context.Context) error {
+				latest, err := v.GetLatestVersion(ctx, name)
+				if err != nil {
+					return err
+				}
+				defer clear(latest.Payload)
+
+				size := len(latest.Payload)
+				if cmd.IsSet("size") {
+					size = cmd.Int("size")
+				}
+
+				key, err := keyMaterial(cmd, size)
+				if err != nil {
+					return err
+				}
+				defer clear(key)
+
+				secret, err := v.AddVersion(ctx, name, key)
+				if err != nil {
+					return err
+				}
+
+				fmt.Fprintf(cmd.Root().Writer, "rotated key %s to version %d\n", secret.Name, secret.Version)
+
+				return nil
+			}, fx.Populate(&v))
+		},
+	}
+}
+
+func listVersionsCommand[C any](shell *goji.Shell[C]) *cli.Command {
+	return &cli.Command{
+		Name:      "list-versions",
+		Usage:     "list the versions of a key",
+		ArgsUsage: "<name>",
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			name, err := vaultcli.NameArg(cmd)
+			if err != nil {
+				return err
+			}
+
+			var v vault.Vault
+
+			return shell.RunTask(ctx, func(ctx context.Context) error {
+				versions, err := v.ListVersions(ctx, name)
+				if err != nil {
+					return err
+				}
+
+				w := tabwriter.NewWriter(cmd.Root().Writer, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "VERSION\tSTATE\tCREATED")
+
+				for _, version := range versions {
+					created := "-"
+					if !version.CreatedAt.IsZero() {
+						created = version.CreatedAt.Format(time.RFC3339)
+					}
+
+					fmt.Fprintf(w, "%d\t%s\t%s\n", version.Version, version.State, created)
+				}
+
+				return w.Flush()
+			}, fx.Populate(&v))
+		},
+	}
+}
+
+// keyMaterial reads the imported key, or generates a key of the given size
+// and the purpose of the command
+func keyMaterial(cmd *cli.Command, size int) ([]byte, error) {
+	purpose := crypt.KeyPurpose(cmd.String("purpose"))
+
+	path := cmd.String("import")
+	if path == "" {
+		return crypt.GenerateKey(purpose, size)
+	}
+
+	key, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := crypt.ValidateKey(purpose, key); err != nil {
+		clear(key)
+		return nil, fmt.Errorf("invalid key material in %s: %w", path, err)
+	}
+
+	return key, nil
+}
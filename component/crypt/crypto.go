@@ -5,11 +5,16 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"encoding/binary"
+	"errors"
 	"fmt"
 
 	"go.uber.org/fx"
 )
 
+// dataKeySize is the size of generated data keys, selecting AES-256.
+const dataKeySize = 32
+
 // CryptoParams is a struct that holds the dependencies of the Crypto module.
 type CryptoParams struct {
 	fx.In
@@ -19,50 +24,124 @@ type CryptoParams struct {
 
 	// KeyProvider is the key provider for the Crypto module.
 	KeyProvider KeyProvider
+
+	// KeyWrapper wraps the data keys of envelope encrypted capsules.
+	KeyWrapper KeyWrapper `optional:"true"`
 }
 
 // Crypto is a struct that provides encryption and decryption functionality.
 type Crypto struct {
 	config      *Config
 	keyProvider KeyProvider
+	keyWrapper  KeyWrapper
 }
 
 // New creates a new instance of the Crypto module.
 func New(params CryptoParams) (*Crypto, error) {
+	if params.Config != nil && params.Config.Envelope && params.KeyWrapper == nil {
+		return nil, errors.New("envelope encryption requires a key wrapper")
+	}
+
 	return &Crypto{
 		config:      params.Config,
 		keyProvider: params.KeyProvider,
+		keyWrapper:  params.KeyWrapper,
 	}, nil
 }
 
 // Encrypt encrypts the given data using the key with the given name.
+// If envelope encryption is enabled, the data is encrypted with a new
+// data key, which in turn is wrapped with the key with the given name.
 func (c *Crypto) Encrypt(ctx context.Context, data []byte, keyName string) (Capsule, error) {
+	return c.EncryptWithAAD(ctx, data, keyName, nil)
+}
+
+// EncryptWithAAD encrypts the given data like Encrypt, binding the capsule
+// to the additional authenticated data. The capsule can only be decrypted
+// with the same additional data, e.g. `AssociatedData(table, column, id)`,
+// so that it cannot be moved to another row or column unnoticed.
+func (c *Crypto) EncryptWithAAD(ctx context.Context, data []byte, keyName string, aad []byte) (Capsule, error) {
+	if c.config != nil && c.config.Envelope {
+		return c.encryptEnvelope(ctx, data, keyName, aad)
+	}
+
 	key, err := c.keyProvider.GetKey(ctx, keyName)
 	if err != nil {
 		return Capsule{}, fmt.Errorf("failed to get encryption key: %w", err)
 	}
 
-	return c.encryptWithKey(data, key)
+	return c.encryptWithKey(data, key, aad)
 }
 
-func (c *Crypto) encryptWithKey(data []byte, key Key) (Capsule, error) {
-	aes, err := aes.NewCipher([]byte(key.Data))
+// EncryptEnvelope encrypts the given data with a new data key, which is
+// wrapped with the master key with the given name and stored in the capsule.
+func (c *Crypto) EncryptEnvelope(ctx context.Context, data []byte, keyName string) (Capsule, error) {
+	return c.encryptEnvelope(ctx, data, keyName, nil)
+}
+
+func (c *Crypto) encryptEnvelope(ctx context.Context, data []byte, keyName string, aad []byte) (Capsule, error) {
+	if c.keyWrapper == nil {
+		return Capsule{}, errors.New("envelope encryption requires a key wrapper")
+	}
+
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return Capsule{}, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	defer clear(dataKey)
+
+	wrapped, err := c.keyWrapper.WrapKey(ctx, keyName, dataKey)
 	if err != nil {
-		return Capsule{}, fmt.Errorf("failed to create AES cipher: %w", err)
+		return Capsule{}, fmt.Errorf("failed to wrap data key: %w", err)
 	}
 
-	gcm, err := cipher.NewGCM(aes)
+	ciphertext, err := seal(dataKey, data, aad)
 	if err != nil {
-		return Capsule{}, fmt.Errorf("failed to create GCM cipher: %w", err)
+		return Capsule{}, err
 	}
 
-	nonce := make([]byte, gcm.NonceSize())
-	_, err = rand.Read(nonce)
+	return Capsule{
+		Data:       ciphertext,
+		KeyName:    wrapped.KeyName,
+		KeyVersion: wrapped.KeyVersion,
+		WrappedKey: wrapped.Data,
+	}, nil
+}
+
+// EncryptDeterministic encrypts the given data with AES-SIV using the key
+// with the given name, which must be 32, 48 or 64 bytes long. Equal data,
+// key versions and additional data result in equal capsules, which allows
+// equality lookups on the encoded capsule, but also reveals equal values.
+// Additional data must therefore not contain row specific values if the
+// capsule is used for lookups.
+func (c *Crypto) EncryptDeterministic(ctx context.Context, data []byte, keyName string, aad []byte) (Capsule, error) {
+	key, err := c.keyProvider.GetKey(ctx, keyName)
 	if err != nil {
-		return Capsule{}, fmt.Errorf("failed to generate nonce: %w", err)
+		return Capsule{}, fmt.Errorf("failed to get encryption key: %w", err)
 	}
 
-	ciphertext := gcm.Seal(nonce, nonce, data, nil)
+	return c.encryptDeterministicWithKey(data, key, aad)
+}
+
+func (c *Crypto) encryptDeterministicWithKey(data []byte, key Key, aad []byte) (Capsule, error) {
+	siv, err := newAESSIV(key.Data)
+	if err != nil {
+		return Capsule{}, err
+	}
+
+	return Capsule{
+		Data:          siv.Seal(data, sivAssociatedData(aad)...),
+		KeyName:       key.Name,
+		KeyVersion:    key.Version,
+		Deterministic: true,
+	}, nil
+}
+
+func (c *Crypto) encryptWithKey(data []byte, key Key, aad []byte) (Capsule, error) {
+	ciphertext, err := seal(key.Data, data, aad)
+	if err != nil {
+		return Capsule{}, err
+	}
 
 	return Capsule{
 		Data:       ciphertext,
@@ -73,40 +152,73 @@ func (c *Crypto) encryptWithKey(data []byte, key Key) (Capsule, error) {
 
 // Decrypt decrypts the given capsule.
 func (c *Crypto) Decrypt(ctx context.Context, capsule Capsule) ([]byte, error) {
+	return c.DecryptWithAAD(ctx, capsule, nil)
+}
+
+// DecryptWithAAD decrypts a capsule created by EncryptWithAAD. Decryption
+// fails if the additional data differs from the one used for encryption.
+func (c *Crypto) DecryptWithAAD(ctx context.Context, capsule Capsule, aad []byte) ([]byte, error) {
+	if capsule.IsEnvelope() {
+		return c.decryptEnvelope(ctx, capsule, aad)
+	}
+
 	key, err := c.keyProvider.GetKeyVersion(ctx, capsule.KeyName, capsule.KeyVersion)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get encryption key: %w", err)
 	}
 
-	return c.decryptWithKey(capsule, key)
+	return c.decryptWithKey(capsule, key, aad)
 }
 
-func (c *Crypto) decryptWithKey(capsule Capsule, key Key) ([]byte, error) {
-	aes, err := aes.NewCipher([]byte(key.Data))
+func (c *Crypto) decryptEnvelope(ctx context.Context, capsule Capsule, aad []byte) ([]byte, error) {
+	dataKey, err := c.unwrapDataKey(ctx, capsule)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+		return nil, err
 	}
+	defer clear(dataKey)
 
-	gcm, err := cipher.NewGCM(aes)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create GCM cipher: %w", err)
-	}
+	return open(dataKey, capsule.Data, aad)
+}
 
-	nonceSize := gcm.NonceSize()
-	nonce, ciphertext := capsule.Data[:nonceSize], capsule.Data[nonceSize:]
+func (c *Crypto) decryptWithKey(capsule Capsule, key Key, aad []byte) ([]byte, error) {
+	if capsule.Deterministic {
+		siv, err := newAESSIV(key.Data)
+		if err != nil {
+			return nil, err
+		}
 
-	plaintext, err := gcm.Open(nil, []byte(nonce), []byte(ciphertext), nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decrypt data: %w", err)
+		return siv.Open(capsule.Data, sivAssociatedData(aad)...)
 	}
 
-	return plaintext, nil
+	return open(key.Data, capsule.Data, aad)
 }
 
 // Recrypt re-encrypts the given capsule with the latest version of the key.
 // If the key version of the capsule is the same as the latest version,
-// the capsule is returned as is.
+// the capsule is returned as is. Envelope encrypted capsules are rewrapped,
+// while other capsules are converted if envelope encryption is enabled.
 func (c *Crypto) Recrypt(ctx context.Context, capsule Capsule) (Capsule, error) {
+	return c.RecryptWithAAD(ctx, capsule, nil)
+}
+
+// RecryptWithAAD re-encrypts a capsule created by EncryptWithAAD like Recrypt,
+// keeping it bound to the same additional data.
+func (c *Crypto) RecryptWithAAD(ctx context.Context, capsule Capsule, aad []byte) (Capsule, error) {
+	if capsule.IsEnvelope() {
+		return c.Rewrap(ctx, capsule)
+	}
+
+	// migrate direct capsules to envelope encryption once it is enabled,
+	// deterministic capsules cannot use per capsule data keys
+	if c.config != nil && c.config.Envelope && !capsule.Deterministic {
+		plaintext, err := c.DecryptWithAAD(ctx, capsule, aad)
+		if err != nil {
+			return Capsule{}, fmt.Errorf("failed to decrypt capsule: %w", err)
+		}
+
+		return c.encryptEnvelope(ctx, plaintext, capsule.KeyName, aad)
+	}
+
 	// get the latest encryption key version
 	latestKey, err := c.keyProvider.GetKey(ctx, capsule.KeyName)
 	if err != nil {
@@ -119,11 +231,136 @@ func (c *Crypto) Recrypt(ctx context.Context, capsule Capsule) (Capsule, error)
 	}
 
 	// decrypt the capsule with the current key version
-	plaintext, err := c.Decrypt(ctx, capsule)
+	plaintext, err := c.DecryptWithAAD(ctx, capsule, aad)
 	if err != nil {
 		return Capsule{}, fmt.Errorf("failed to decrypt capsule: %w", err)
 	}
 
 	// re-encrypt the plaintext with the latest key version
-	return c.encryptWithKey(plaintext, latestKey)
+	if capsule.Deterministic {
+		return c.encryptDeterministicWithKey(plaintext, latestKey, aad)
+	}
+
+	return c.encryptWithKey(plaintext, latestKey, aad)
+}
+
+// Rewrap wraps the data key of an envelope encrypted capsule with the
+// latest version of its master key. The encrypted data is left untouched,
+// so that rotating a master key does not require re-encrypting any data.
+func (c *Crypto) Rewrap(ctx context.Context, capsule Capsule) (Capsule, error) {
+	if !capsule.IsEnvelope() {
+		return Capsule{}, errors.New("capsule is not envelope encrypted")
+	}
+
+	dataKey, err := c.unwrapDataKey(ctx, capsule)
+	if err != nil {
+		return Capsule{}, err
+	}
+	defer clear(dataKey)
+
+	wrapped, err := c.keyWrapper.WrapKey(ctx, capsule.KeyName, dataKey)
+	if err != nil {
+		return Capsule{}, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	// return the capsule as is if the master key has not been rotated
+	if wrapped.KeyName == capsule.KeyName && wrapped.KeyVersion == capsule.KeyVersion {
+		return capsule, nil
+	}
+
+	capsule.KeyName = wrapped.KeyName
+	capsule.KeyVersion = wrapped.KeyVersion
+	capsule.WrappedKey = wrapped.Data
+
+	return capsule, nil
+}
+
+func (c *Crypto) unwrapDataKey(ctx context.Context, capsule Capsule) ([]byte, error) {
+	if c.keyWrapper == nil {
+		return nil, errors.New("capsule is envelope encrypted, but no key wrapper is configured")
+	}
+
+	dataKey, err := c.keyWrapper.UnwrapKey(ctx, WrappedKey{
+		KeyName:    capsule.KeyName,
+		KeyVersion: capsule.KeyVersion,
+		Data:       capsule.WrappedKey,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+
+	return dataKey, nil
+}
+
+// AssociatedData builds additional authenticated data from the given parts,
+// e.g. table, column and row id. Each part is length prefixed, so that
+// ("ab", "c") and ("a", "bc") result in different data.
+func AssociatedData(parts ...string) []byte {
+	var aad []byte
+	for _, part := range parts {
+		aad = binary.AppendUvarint(aad, uint64(len(part)))
+		aad = append(aad, part...)
+	}
+	return aad
+}
+
+// sivAssociatedData maps the optional additional data to S2V components
+func sivAssociatedData(aad []byte) [][]byte {
+	if aad == nil {
+		return nil
+	}
+
+	return [][]byte{aad}
+}
+
+// seal encrypts the plaintext with AES-GCM and prepends the random nonce.
+func seal(key []byte, plaintext []byte, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts a ciphertext created by seal.
+func open(key []byte, ciphertext []byte, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonceSize := gcm.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data: %w", err)
+	}
+
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	aes, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+
+	gcm, err := cipher.NewGCM(aes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM cipher: %w", err)
+	}
+
+	return gcm, nil
 }
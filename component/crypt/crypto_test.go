@@ -0,0 +1,144 @@
+package crypt_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fruitsco/goji/component/crypt"
+)
+
+// memoryKeyProvider is a key provider holding versioned keys in memory
+type memoryKeyProvider map[string][][]byte
+
+func (p memoryKeyProvider) rotate(name string) {
+	p[name] = append(p[name], bytes.Repeat([]byte{byte(len(p[name]) + 1)}, 32))
+}
+
+func (p memoryKeyProvider) GetKey(ctx context.Context, name string) (crypt.Key, error) {
+	return p.GetKeyVersion(ctx, name, len(p[name]))
+}
+
+func (p memoryKeyProvider) GetKeyVersion(_ context.Context, name string, version int) (crypt.Key, error) {
+	if version < 1 || version > len(p[name]) {
+		return crypt.Key{}, fmt.Errorf("key %s@%d not found", name, version)
+	}
+	return crypt.Key{Name: name, Version: version, Data: p[name][version-1]}, nil
+}
+
+func newCrypto(t *testing.T, keys memoryKeyProvider, envelope bool) *crypt.Crypto {
+	c, err := crypt.New(crypt.CryptoParams{
+		Config:      &crypt.Config{Envelope: envelope},
+		KeyProvider: keys,
+		KeyWrapper:  crypt.NewKeyProviderWrapper(crypt.KeyProviderWrapperParams{KeyProvider: keys}),
+	})
+	require.NoError(t, err)
+	return c
+}
+
+func TestCrypto_Envelope(t *testing.T) {
+	ctx := context.Background()
+
+	keys := memoryKeyProvider{}
+	keys.rotate("master")
+
+	c := newCrypto(t, keys, true)
+
+	capsule, err := c.Encrypt(ctx, []byte("secret"), "master")
+	require.NoError(t, err)
+	assert.True(t, capsule.IsEnvelope())
+	assert.Equal(t, 1, capsule.KeyVersion)
+
+	plaintext, err := c.Decrypt(ctx, capsule)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("secret"), plaintext)
+
+	// every capsule has its own data key
+	other, err := c.Encrypt(ctx, []byte("secret"), "master")
+	require.NoError(t, err)
+	assert.NotEqual(t, capsule.WrappedKey, other.WrappedKey)
+
+	// rewrapping without rotation keeps the capsule
+	same, err := c.Recrypt(ctx, capsule)
+	require.NoError(t, err)
+	assert.Equal(t, capsule, same)
+
+	// rotation only rewraps the data key
+	keys.rotate("master")
+
+	rewrapped, err := c.Recrypt(ctx, capsule)
+	require.NoError(t, err)
+	assert.Equal(t, 2, rewrapped.KeyVersion)
+	assert.Equal(t, capsule.Data, rewrapped.Data)
+	assert.NotEqual(t, capsule.WrappedKey, rewrapped.WrappedKey)
+
+	plaintext, err = c.Decrypt(ctx, rewrapped)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("secret"), plaintext)
+
+	// a wrapped key cannot be moved to another key version
+	tampered := rewrapped
+	tampered.KeyVersion = 1
+	_, err = c.Decrypt(ctx, tampered)
+	require.Error(t, err)
+}
+
+func TestCrypto_ConvertsDirectCapsulesInEnvelopeMode(t *testing.T) {
+	ctx := context.Background()
+
+	keys := memoryKeyProvider{}
+	keys.rotate("master")
+
+	capsule, err := newCrypto(t, keys, false).Encrypt(ctx, []byte("secret"), "master")
+	require.NoError(t, err)
+	assert.False(t, capsule.IsEnvelope())
+
+	c := newCrypto(t, keys, true)
+
+	plaintext, err := c.Decrypt(ctx, capsule)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("secret"), plaintext)
+
+	// recrypting converts the capsule to envelope encryption
+	converted, err := c.Recrypt(ctx, capsule)
+	require.NoError(t, err)
+	assert.True(t, converted.IsEnvelope())
+
+	plaintext, err = c.Decrypt(ctx, converted)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("secret"), plaintext)
+}
+
+func TestCrypto_AssociatedData(t *testing.T) {
+	ctx := context.Background()
+
+	keys := memoryKeyProvider{}
+	keys.rotate("master")
+
+	for _, envelope := range []bool{false, true} {
+		c := newCrypto(t, keys, envelope)
+
+		aad := crypt.AssociatedData("users", "email", "42")
+
+		capsule, err := c.EncryptWithAAD(ctx, []byte("alice@example.com"), "master", aad)
+		require.NoError(t, err)
+
+		plaintext, err := c.DecryptWithAAD(ctx, capsule, aad)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("alice@example.com"), plaintext)
+
+		// the capsule cannot be moved to another row
+		_, err = c.DecryptWithAAD(ctx, capsule, crypt.AssociatedData("users", "email", "43"))
+		require.Error(t, err)
+
+		_, err = c.Decrypt(ctx, capsule)
+		require.Error(t, err)
+	}
+
+	// parts are length prefixed
+	assert.NotEqual(t, crypt.AssociatedData("ab", "c"), crypt.AssociatedData("a", "bc"))
+}
@@ -0,0 +1,231 @@
+package crypt
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Algorithm identifies the algorithm a capsule is encrypted with
+type Algorithm uint8
+
+const (
+	// AlgorithmAESGCM encrypts the data with AES-GCM using the named key
+	AlgorithmAESGCM Algorithm = 1
+
+	// AlgorithmAESGCMEnvelope encrypts the data with AES-256-GCM using
+	// a data key, which is wrapped with the named key
+	AlgorithmAESGCMEnvelope Algorithm = 2
+
+	// AlgorithmAESSIV deterministically encrypts the data with
+	// AES-SIV-CMAC using the named key
+	AlgorithmAESSIV Algorithm = 3
+
+	// AlgorithmAESGCMStream encrypts a stream in authenticated segments
+	// with AES-256-GCM using a data key, which is wrapped with the named key
+	AlgorithmAESGCMStream Algorithm = 4
+)
+
+const (
+	// formatVersion is the version of the binary capsule format
+	formatVersion = 1
+
+	// binaryMagic is the first byte of a binary encoded capsule
+	binaryMagic = 0xC5
+
+	// textPrefix is the prefix of a text encoded capsule,
+	// followed by the base64 encoded binary format
+	textPrefix = "c1:"
+)
+
+var (
+	_ = encoding.BinaryMarshaler(Capsule{})
+	_ = encoding.BinaryUnmarshaler(&Capsule{})
+)
+
+// MarshalBinary encodes the capsule in the compact binary format:
+//
+//	magic (1) | format version (1) | algorithm (1) | key version (uvarint)
+//	| key name length (uvarint) | key name
+//	| wrapped key length (uvarint) | wrapped key   (envelope only)
+//	| data
+func (c Capsule) MarshalBinary() ([]byte, error) {
+	if c.KeyVersion < 0 {
+		return nil, fmt.Errorf("invalid key version %d", c.KeyVersion)
+	}
+
+	buf := make([]byte, 0, 3+3*binary.MaxVarintLen64+len(c.KeyName)+len(c.WrappedKey)+len(c.Data))
+	buf = c.appendHeader(buf, c.Algorithm())
+
+	return append(buf, c.Data...), nil
+}
+
+// appendHeader appends the fields of the binary format preceding the data.
+// Stream headers share the format of envelope capsules.
+func (c Capsule) appendHeader(buf []byte, algorithm Algorithm) []byte {
+	buf = append(buf, binaryMagic, formatVersion, byte(algorithm))
+	buf = binary.AppendUvarint(buf, uint64(c.KeyVersion))
+	buf = binary.AppendUvarint(buf, uint64(len(c.KeyName)))
+	buf = append(buf, c.KeyName...)
+
+	if algorithm == AlgorithmAESGCMEnvelope || algorithm == AlgorithmAESGCMStream {
+		buf = binary.AppendUvarint(buf, uint64(len(c.WrappedKey)))
+		buf = append(buf, c.WrappedKey...)
+	}
+
+	return buf
+}
+
+// UnmarshalBinary decodes a capsule encoded by MarshalBinary
+func (c *Capsule) UnmarshalBinary(data []byte) error {
+	if len(data) < 3 || data[0] != binaryMagic {
+		return errors.New("invalid capsule: unknown format")
+	}
+
+	if data[1] != formatVersion {
+		return fmt.Errorf("invalid capsule: unsupported format version %d", data[1])
+	}
+
+	algorithm := Algorithm(data[2])
+	if algorithm == AlgorithmAESGCMStream {
+		return errors.New("invalid capsule: streams must be decrypted using DecryptStream")
+	}
+	if algorithm != AlgorithmAESGCM && algorithm != AlgorithmAESGCMEnvelope && algorithm != AlgorithmAESSIV {
+		return fmt.Errorf("invalid capsule: unsupported algorithm %d", algorithm)
+	}
+
+	r := capsuleReader{data: data[3:]}
+
+	keyVersion := r.uvarint()
+	keyName := r.bytes()
+
+	var wrappedKey []byte
+	if algorithm == AlgorithmAESGCMEnvelope {
+		wrappedKey = r.bytes()
+		if r.err == nil && len(wrappedKey) == 0 {
+			return errors.New("invalid capsule: missing wrapped key")
+		}
+	}
+
+	if r.err != nil {
+		return fmt.Errorf("invalid capsule: %w", r.err)
+	}
+
+	if keyVersion > math.MaxInt32 {
+		return fmt.Errorf("invalid capsule: key version %d out of range", keyVersion)
+	}
+
+	*c = Capsule{
+		Data:          append([]byte(nil), r.data...),
+		KeyName:       string(keyName),
+		KeyVersion:    int(keyVersion),
+		WrappedKey:    append([]byte(nil), wrappedKey...),
+		Deterministic: algorithm == AlgorithmAESSIV,
+	}
+
+	if len(c.WrappedKey) == 0 {
+		c.WrappedKey = nil
+	}
+
+	return nil
+}
+
+// MarshalText encodes the capsule as prefixed base64 of the binary format.
+func (c Capsule) MarshalText() ([]byte, error) {
+	bin, err := c.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, len(textPrefix)+base64.RawURLEncoding.EncodedLen(len(bin)))
+	copy(buf, textPrefix)
+	base64.RawURLEncoding.Encode(buf[len(textPrefix):], bin)
+
+	return buf, nil
+}
+
+// UnmarshalText decodes a capsule encoded by MarshalText
+func (c *Capsule) UnmarshalText(text []byte) error {
+	if len(text) < len(textPrefix) || string(text[:len(textPrefix)]) != textPrefix {
+		return errors.New("invalid capsule: missing prefix")
+	}
+
+	bin := make([]byte, base64.RawURLEncoding.DecodedLen(len(text)-len(textPrefix)))
+
+	n, err := base64.RawURLEncoding.Decode(bin, text[len(textPrefix):])
+	if err != nil {
+		return fmt.Errorf("invalid capsule: %w", err)
+	}
+
+	return c.UnmarshalBinary(bin[:n])
+}
+
+// jsonCapsule has the fields of Capsule without its methods,
+// so that JSON keeps encoding capsules as objects
+type jsonCapsule Capsule
+
+// MarshalJSON encodes the capsule as JSON object for compatibility
+func (c Capsule) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonCapsule(c))
+}
+
+// UnmarshalJSON decodes a capsule from a JSON object or a JSON string
+// holding the text format
+func (c *Capsule) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		return c.UnmarshalText([]byte(text))
+	}
+
+	return json.Unmarshal(data, (*jsonCapsule)(c))
+}
+
+// ParseCapsule decodes a capsule in the text, binary or legacy JSON format
+func ParseCapsule(data []byte) (Capsule, error) {
+	var c Capsule
+	err := c.decode(data)
+	return c, err
+}
+
+// capsuleReader reads length prefixed fields, remembering the first error
+type capsuleReader struct {
+	data []byte
+	err  error
+}
+
+func (r *capsuleReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.err = errors.New("malformed length")
+		return 0
+	}
+
+	r.data = r.data[n:]
+
+	return v
+}
+
+func (r *capsuleReader) bytes() []byte {
+	n := r.uvarint()
+	if r.err != nil {
+		return nil
+	}
+
+	if n > uint64(len(r.data)) {
+		r.err = errors.New("truncated field")
+		return nil
+	}
+
+	b := r.data[:n]
+	r.data = r.data[n:]
+
+	return b
+}
@@ -0,0 +1,138 @@
+package crypt_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fruitsco/goji/component/crypt"
+)
+
+func TestCapsule_WireFormat(t *testing.T) {
+	capsules := map[string]crypt.Capsule{
+		"direct": {
+			Data:       []byte("nonce-and-ciphertext"),
+			KeyName:    "master",
+			KeyVersion: 3,
+		},
+		"envelope": {
+			Data:       []byte("nonce-and-ciphertext"),
+			KeyName:    "master",
+			KeyVersion: 300,
+			WrappedKey: []byte("wrapped"),
+		},
+	}
+
+	for name, capsule := range capsules {
+		t.Run(name, func(t *testing.T) {
+			// text values are scanned from text and binary columns
+			value, err := crypt.TextCapsule{Capsule: capsule}.Value()
+			require.NoError(t, err)
+
+			for _, v := range []any{value, []byte(value.(string))} {
+				var scanned crypt.TextCapsule
+				require.NoError(t, scanned.Scan(v))
+				assert.Equal(t, capsule, scanned.Capsule)
+			}
+
+			bin, err := crypt.BinaryCapsule{Capsule: capsule}.Value()
+			require.NoError(t, err)
+			assert.Less(t, len(bin.([]byte)), len(value.(string)))
+
+			var scanned crypt.Capsule
+			require.NoError(t, scanned.Scan(bin))
+			assert.Equal(t, capsule, scanned)
+
+			// values replacing a capsule keep its format
+			for _, v := range []any{value, bin} {
+				encoded, _ := v.([]byte)
+				if s, ok := v.(string); ok {
+					encoded = []byte(s)
+				}
+
+				like, err := capsule.ValueLike(encoded)
+				require.NoError(t, err)
+				assert.Equal(t, v, like)
+			}
+
+			// json keeps the object format
+			data, err := json.Marshal(capsule)
+			require.NoError(t, err)
+			assert.Equal(t, byte('{'), data[0])
+
+			var decoded crypt.Capsule
+			require.NoError(t, json.Unmarshal(data, &decoded))
+			assert.Equal(t, capsule, decoded)
+		})
+	}
+}
+
+func TestCapsule_ScansLegacyJSON(t *testing.T) {
+	legacy := `{"Data":"bm9uY2UtYW5kLWNpcGhlcnRleHQ=","KeyName":"master","KeyVersion":2}`
+
+	for _, v := range []any{legacy, []byte(legacy)} {
+		var c crypt.Capsule
+		require.NoError(t, c.Scan(v))
+		assert.Equal(t, crypt.Capsule{
+			Data:       []byte("nonce-and-ciphertext"),
+			KeyName:    "master",
+			KeyVersion: 2,
+		}, c)
+		assert.Equal(t, crypt.AlgorithmAESGCM, c.Algorithm())
+	}
+}
+
+func TestCapsule_ValueIsLegacyJSON(t *testing.T) {
+	// legacyCapsule is the capsule as stored before the wire format
+	type legacyCapsule struct {
+		Data       []byte
+		KeyName    string
+		KeyVersion int
+	}
+
+	capsule := crypt.Capsule{Data: []byte("nonce-and-ciphertext"), KeyName: "master", KeyVersion: 2}
+
+	value, err := capsule.Value()
+	require.NoError(t, err)
+
+	var legacy legacyCapsule
+	require.NoError(t, json.Unmarshal(value.([]byte), &legacy))
+	assert.Equal(t, legacyCapsule{Data: capsule.Data, KeyName: "master", KeyVersion: 2}, legacy)
+
+	old, err := json.Marshal(legacy)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(old), string(value.([]byte)))
+
+	var scanned crypt.Capsule
+	require.NoError(t, scanned.Scan(old))
+	assert.Equal(t, capsule, scanned)
+
+	like, err := scanned.ValueLike(old)
+	require.NoError(t, err)
+	assert.Equal(t, value, like)
+}
+
+func TestCapsule_RejectsMalformedData(t *testing.T) {
+	capsule := crypt.Capsule{Data: []byte("data"), KeyName: "master", KeyVersion: 1, WrappedKey: []byte("k")}
+
+	bin, err := capsule.MarshalBinary()
+	require.NoError(t, err)
+
+	for name, data := range map[string][]byte{
+		"empty":       {},
+		"truncated":   bin[:5],
+		"version":     append([]byte{bin[0], 9}, bin[2:]...),
+		"algorithm":   append([]byte{bin[0], bin[1], 9}, bin[3:]...),
+		"text base64": []byte("c1:!!!"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := crypt.ParseCapsule(data)
+			require.Error(t, err)
+		})
+	}
+
+	var c crypt.Capsule
+	require.Error(t, c.Scan(42))
+}
@@ -1,6 +1,11 @@
 package crypt
 
-import "context"
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+)
 
 // Key is a struct that represents an encryption key.
 type Key struct {
@@ -22,3 +27,97 @@ type KeyProvider interface {
 	// GetKeyVersion returns the key with the given name and version.
 	GetKeyVersion(context.Context, string, int) (Key, error)
 }
+
+// KeyPurpose is the use of a key, which determines its valid sizes
+type KeyPurpose string
+
+const (
+	// KeyPurposeEncryption keys encrypt with AES-GCM, directly or by
+	// wrapping data keys, and are 16, 24 or 32 bytes
+	KeyPurposeEncryption KeyPurpose = "encryption"
+
+	// KeyPurposeDeterministic keys encrypt deterministically with AES-SIV
+	// and are 32, 48 or 64 bytes
+	KeyPurposeDeterministic KeyPurpose = "deterministic"
+
+	// KeyPurposeHMAC keys compute blind indexes and HMAC signatures
+	// and are at least 16 bytes
+	KeyPurposeHMAC KeyPurpose = "hmac"
+
+	// KeyPurposeEd25519 keys create Ed25519 signatures
+	// and are a 32 byte seed or a 64 byte private key
+	KeyPurposeEd25519 KeyPurpose = "ed25519"
+)
+
+// DefaultKeySize is the size of generated keys in bytes, selecting AES-256.
+// Such keys are valid for every purpose.
+const DefaultKeySize = 32
+
+// DefaultSize returns the size of generated keys of the purpose in bytes
+func (p KeyPurpose) DefaultSize() int {
+	if p == KeyPurposeDeterministic {
+		// AES-256-SIV uses two AES-256 keys
+		return 64
+	}
+
+	return DefaultKeySize
+}
+
+// GenerateKey generates a random key of the given purpose and size in bytes.
+// A size of 0 selects the default size of the purpose.
+func GenerateKey(purpose KeyPurpose, size int) ([]byte, error) {
+	if size == 0 {
+		size = purpose.DefaultSize()
+	}
+
+	if err := ValidateKeySize(purpose, size); err != nil {
+		return nil, err
+	}
+
+	key := make([]byte, size)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	return key, nil
+}
+
+// ValidateKey checks that the key material is a valid key of the purpose.
+func ValidateKey(purpose KeyPurpose, key []byte) error {
+	return ValidateKeySize(purpose, len(key))
+}
+
+// ValidateKeySize checks that the size in bytes is valid for keys of the purpose.
+func ValidateKeySize(purpose KeyPurpose, size int) error {
+	switch purpose {
+	case KeyPurposeEncryption:
+		switch size {
+		case 16, 24, 32:
+			return nil
+		}
+
+		return fmt.Errorf("invalid key size %d for %s keys, must be 16, 24 or 32 bytes", size, purpose)
+	case KeyPurposeDeterministic:
+		switch size {
+		case 32, 48, 64:
+			return nil
+		}
+
+		return fmt.Errorf("invalid key size %d for %s keys, must be 32, 48 or 64 bytes", size, purpose)
+	case KeyPurposeHMAC:
+		if size >= minHMACKeySize {
+			return nil
+		}
+
+		return fmt.Errorf("invalid key size %d for %s keys, must be at least %d bytes", size, purpose, minHMACKeySize)
+	case KeyPurposeEd25519:
+		switch size {
+		case ed25519.SeedSize, ed25519.PrivateKeySize:
+			return nil
+		}
+
+		return fmt.Errorf("invalid key size %d for %s keys, must be %d or %d bytes", size, purpose, ed25519.SeedSize, ed25519.PrivateKeySize)
+	}
+
+	return fmt.Errorf("unknown key purpose %q", purpose)
+}
@@ -0,0 +1,47 @@
+package crypt_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fruitsco/goji/component/crypt"
+)
+
+func TestGenerateKey(t *testing.T) {
+	sizes := map[crypt.KeyPurpose]struct {
+		valid, invalid []int
+	}{
+		crypt.KeyPurposeEncryption:    {[]int{16, 24, 32}, []int{8, 31, 64}},
+		crypt.KeyPurposeDeterministic: {[]int{32, 48, 64}, []int{16, 24, 63}},
+		crypt.KeyPurposeHMAC:          {[]int{16, 32, 64}, []int{8, 15}},
+		crypt.KeyPurposeEd25519:       {[]int{32, 64}, []int{16, 48}},
+	}
+
+	for purpose, tt := range sizes {
+		t.Run(string(purpose), func(t *testing.T) {
+			for _, size := range tt.valid {
+				key, err := crypt.GenerateKey(purpose, size)
+				require.NoError(t, err)
+				assert.Len(t, key, size)
+				assert.NoError(t, crypt.ValidateKey(purpose, key))
+			}
+
+			for _, size := range tt.invalid {
+				_, err := crypt.GenerateKey(purpose, size)
+				assert.Error(t, err, "size %d", size)
+			}
+
+			key, err := crypt.GenerateKey(purpose, 0)
+			require.NoError(t, err)
+			assert.Len(t, key, purpose.DefaultSize())
+		})
+	}
+
+	_, err := crypt.GenerateKey("signing", 32)
+	assert.Error(t, err)
+
+	// hand-crafted keys are rejected before they reach aes.NewCipher
+	assert.Error(t, crypt.ValidateKey(crypt.KeyPurposeEncryption, []byte("too-short")))
+}
@@ -0,0 +1,90 @@
+package crypt
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"go.uber.org/fx"
+)
+
+// WrappedKey is a data key encrypted with a master key.
+type WrappedKey struct {
+	// KeyName is the name of the master key.
+	KeyName string
+
+	// KeyVersion is the version of the master key.
+	KeyVersion int
+
+	// Data is the encrypted data key.
+	Data []byte
+}
+
+// KeyWrapper is an interface for wrapping data keys with a master key.
+// Implementations backed by a KMS or HSM never expose the master key
+// to the process.
+type KeyWrapper interface {
+	// WrapKey encrypts the data key with the latest version of the
+	// master key with the given name.
+	WrapKey(context.Context, string, []byte) (WrappedKey, error)
+
+	// UnwrapKey decrypts a wrapped data key.
+	UnwrapKey(context.Context, WrappedKey) ([]byte, error)
+}
+
+// KeyProviderWrapper is a KeyWrapper that wraps data keys locally
+// using master keys fetched from a KeyProvider.
+type KeyProviderWrapper struct {
+	keyProvider KeyProvider
+}
+
+// KeyProviderWrapperParams is a struct that holds the dependencies of the KeyProviderWrapper.
+type KeyProviderWrapperParams struct {
+	fx.In
+
+	// KeyProvider is the provider of the master keys.
+	KeyProvider KeyProvider
+}
+
+// NewKeyProviderWrapper creates a new KeyWrapper using the given key provider.
+func NewKeyProviderWrapper(params KeyProviderWrapperParams) KeyWrapper {
+	return &KeyProviderWrapper{
+		keyProvider: params.KeyProvider,
+	}
+}
+
+var _ = KeyWrapper(&KeyProviderWrapper{})
+
+// WrapKey encrypts the data key with the latest version of the master key.
+func (w *KeyProviderWrapper) WrapKey(ctx context.Context, keyName string, dataKey []byte) (WrappedKey, error) {
+	key, err := w.keyProvider.GetKey(ctx, keyName)
+	if err != nil {
+		return WrappedKey{}, fmt.Errorf("failed to get master key: %w", err)
+	}
+
+	data, err := seal(key.Data, dataKey, wrapAssociatedData(key.Name, key.Version))
+	if err != nil {
+		return WrappedKey{}, err
+	}
+
+	return WrappedKey{
+		KeyName:    key.Name,
+		KeyVersion: key.Version,
+		Data:       data,
+	}, nil
+}
+
+// UnwrapKey decrypts the data key with the master key version it was wrapped with.
+func (w *KeyProviderWrapper) UnwrapKey(ctx context.Context, wrapped WrappedKey) ([]byte, error) {
+	key, err := w.keyProvider.GetKeyVersion(ctx, wrapped.KeyName, wrapped.KeyVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get master key: %w", err)
+	}
+
+	return open(key.Data, wrapped.Data, wrapAssociatedData(wrapped.KeyName, wrapped.KeyVersion))
+}
+
+// wrapAssociatedData binds a wrapped data key to its master key version.
+func wrapAssociatedData(keyName string, keyVersion int) []byte {
+	return []byte(keyName + "@" + strconv.Itoa(keyVersion))
+}
@@ -1,33 +1,126 @@
 package crypt
 
 import (
+	"bytes"
 	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
-	"errors"
+	"fmt"
 )
 
 type Capsule struct {
 	Data       []byte
 	KeyName    string
 	KeyVersion int
+
+	// WrappedKey is the data key wrapped with the key referenced by
+	// KeyName and KeyVersion. It is only set for envelope encryption.
+	WrappedKey []byte `json:",omitempty"`
+
+	// Deterministic marks capsules encrypted with AES-SIV,
+	// equal plaintexts result in equal capsules.
+	Deterministic bool `json:",omitempty"`
+}
+
+// IsEnvelope reports whether the capsule is envelope encrypted
+func (c Capsule) IsEnvelope() bool {
+	return len(c.WrappedKey) > 0
+}
+
+// Algorithm returns the algorithm the capsule is encrypted with
+func (c Capsule) Algorithm() Algorithm {
+	if c.Deterministic {
+		return AlgorithmAESSIV
+	}
+
+	if c.IsEnvelope() {
+		return AlgorithmAESGCMEnvelope
+	}
+
+	return AlgorithmAESGCM
 }
 
 var _ = driver.Valuer(&Capsule{})
 
-// Value implements the driver.Valuer interface
+// Value implements the driver.Valuer interface. The capsule is stored as
+// JSON object, which fits `json` columns and is read by all versions.
+// Use TextCapsule or BinaryCapsule to store the compact formats.
 func (c Capsule) Value() (driver.Value, error) {
-	return json.Marshal(c)
+	return json.Marshal(jsonCapsule(c))
+}
+
+// ValueLike encodes the capsule in the format of the encoded capsule, so
+// that a stored capsule can be replaced without changing its format
+func (c Capsule) ValueLike(encoded []byte) (driver.Value, error) {
+	switch {
+	case bytes.HasPrefix(encoded, []byte("{")):
+		return c.Value()
+	case bytes.HasPrefix(encoded, []byte(textPrefix)):
+		return TextCapsule{c}.Value()
+	default:
+		return BinaryCapsule{c}.Value()
+	}
+}
+
+// TextCapsule stores the capsule in the compact text format, which fits
+// text as well as binary columns. All formats are scanned.
+type TextCapsule struct {
+	Capsule
+}
+
+var _ = driver.Valuer(&TextCapsule{})
+
+// Value implements the driver.Valuer interface
+func (c TextCapsule) Value() (driver.Value, error) {
+	text, err := c.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+
+	return string(text), nil
+}
+
+// BinaryCapsule stores the capsule in the compact binary format, which
+// requires a binary column. All formats are scanned.
+type BinaryCapsule struct {
+	Capsule
+}
+
+var _ = driver.Valuer(&BinaryCapsule{})
+
+// Value implements the driver.Valuer interface
+func (c BinaryCapsule) Value() (driver.Value, error) {
+	return c.MarshalBinary()
 }
 
 var _ = sql.Scanner(&Capsule{})
 
-// Scan implements the sql.Scanner interface
+// Scan implements the sql.Scanner interface. It accepts `string` and
+// `[]byte` values in the text, binary and legacy JSON format.
 func (c *Capsule) Scan(value interface{}) error {
-	str, ok := value.(string)
-	if !ok {
-		return errors.New("type assertion to string failed")
+	var data []byte
+
+	switch v := value.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("unsupported capsule type %T", value)
 	}
 
-	return json.Unmarshal([]byte(str), c)
+	return c.decode(data)
+}
+
+// decode detects the format of the encoded capsule and decodes it
+func (c *Capsule) decode(data []byte) error {
+	switch {
+	case bytes.HasPrefix(data, []byte("{")):
+		// capsules stored before the wire format was introduced
+		return json.Unmarshal(data, (*jsonCapsule)(c))
+	case bytes.HasPrefix(data, []byte(textPrefix)):
+		return c.UnmarshalText(data)
+	default:
+		return c.UnmarshalBinary(data)
+	}
 }
@@ -12,6 +12,10 @@ func Module(cfg *Config) fx.Option {
 
 		fx.Provide(NewVaultKeyProvider),
 
+		// replace the key wrapper using `fx.Decorate`
+		// to keep master keys in a KMS or HSM
+		fx.Provide(NewKeyProviderWrapper),
+
 		fx.Supply(cfg),
 		fx.Provide(New),
 	)
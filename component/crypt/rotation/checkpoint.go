@@ -0,0 +1,113 @@
+package rotation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Checkpoint stores the cursor of a rotation between batches
+type Checkpoint interface {
+	// Load returns the saved cursor, or an empty string if there is none
+	Load(ctx context.Context) (string, error)
+
+	// Save stores the cursor
+	Save(ctx context.Context, cursor string) error
+
+	// Clear removes the cursor after the rotation completed
+	Clear(ctx context.Context) error
+}
+
+// MARK: - File
+
+// FileCheckpoint stores the cursor in a local file
+type FileCheckpoint struct {
+	path string
+}
+
+var _ = Checkpoint(&FileCheckpoint{})
+
+// NewFileCheckpoint creates a checkpoint stored at the given path
+func NewFileCheckpoint(path string) *FileCheckpoint {
+	return &FileCheckpoint{path: path}
+}
+
+func (c *FileCheckpoint) Load(_ context.Context) (string, error) {
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
+
+func (c *FileCheckpoint) Save(_ context.Context, cursor string) error {
+	// write to a temporary file first and rename it afterwards,
+	// so that an interrupted write does not corrupt the checkpoint
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(cursor + "\n"); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("failed to replace checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+func (c *FileCheckpoint) Clear(_ context.Context) error {
+	if err := os.Remove(c.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// MARK: - Memory
+
+// MemoryCheckpoint keeps the cursor in memory, e.g. to resume
+// a rotation within the same process after a transient error
+type MemoryCheckpoint struct {
+	mu     sync.Mutex
+	cursor string
+}
+
+var _ = Checkpoint(&MemoryCheckpoint{})
+
+func (c *MemoryCheckpoint) Load(_ context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.cursor, nil
+}
+
+func (c *MemoryCheckpoint) Save(_ context.Context, cursor string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cursor = cursor
+
+	return nil
+}
+
+func (c *MemoryCheckpoint) Clear(_ context.Context) error {
+	return c.Save(context.Background(), "")
+}
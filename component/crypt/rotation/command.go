@@ -0,0 +1,122 @@
+package rotation
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"github.com/fruitsco/goji"
+	"github.com/fruitsco/goji/component/crypt"
+	"github.com/fruitsco/goji/component/database"
+)
+
+// Command creates a CLI command rotating the capsules of a database column,
+// to be added to the app using `CLIRoot.AddCommand`. The shell must provide
+// the crypt and database components, e.g. using the core module:
+//
+//	app rotate --table users --column email --checkpoint users-email.checkpoint
+func Command[C any](shell *goji.Shell[C]) *cli.Command {
+	return &cli.Command{
+		Name:  "rotate",
+		Usage: "re-encrypt the capsules of a database column with the latest key versions",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "table",
+				Usage:    "table storing the capsules, optionally schema qualified",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "column",
+				Usage:    "column storing the capsules",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "key-column",
+				Usage: "unique column to paginate by",
+				Value: "id",
+			},
+			&cli.IntFlag{
+				Name:  "batch-size",
+				Usage: "number of rows per batch",
+				Value: DefaultBatchSize,
+			},
+			&cli.IntFlag{
+				Name:  "concurrency",
+				Usage: "number of rows recrypted concurrently",
+				Value: DefaultConcurrency,
+			},
+			&cli.StringFlag{
+				Name:  "checkpoint",
+				Usage: "file to store the progress in, to resume an interrupted rotation",
+			},
+			&cli.BoolFlag{
+				Name:  "bind-row",
+				Usage: "capsules are bound to crypt.AssociatedData(table, column, key)",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			config := SQLTableConfig{
+				Table:         cmd.String("table"),
+				KeyColumn:     cmd.String("key-column"),
+				CapsuleColumn: cmd.String("column"),
+			}
+
+			if cmd.Bool("bind-row") {
+				config.AssociatedData = func(key string) []byte {
+					return crypt.AssociatedData(config.Table, config.CapsuleColumn, key)
+				}
+			}
+
+			job := Job{
+				BatchSize:   cmd.Int("batch-size"),
+				Concurrency: cmd.Int("concurrency"),
+			}
+
+			if path := cmd.String("checkpoint"); path != "" {
+				job.Checkpoint = NewFileCheckpoint(path)
+			}
+
+			var (
+				log *zap.Logger
+				c   *crypt.Crypto
+				db  *database.EntDB
+			)
+
+			return shell.RunTask(ctx, func(ctx context.Context) error {
+				config.Dialect = db.Driver().Dialect()
+
+				table, err := NewSQLTable(db.DB(), config)
+				if err != nil {
+					return err
+				}
+
+				job.Source = table
+				job.Sink = table
+				job.OnProgress = func(p Progress) {
+					log.Info("rotation progress",
+						zap.Int64("total", p.Total),
+						zap.Int64("scanned", p.Scanned),
+						zap.Int64("updated", p.Updated),
+						zap.Int64("conflicts", p.Conflicts),
+						zap.String("cursor", p.Cursor),
+					)
+				}
+
+				progress, err := NewRunner(RunnerParams{Crypto: c, Log: log}).Run(ctx, job)
+				if err != nil {
+					return fmt.Errorf("rotation stopped at cursor %q: %w", progress.Cursor, err)
+				}
+
+				log.Info(fmt.Sprintf("rotated %d of %d rows", progress.Updated, progress.Scanned),
+					zap.Int64("unchanged", progress.Unchanged),
+					zap.Int64("conflicts", progress.Conflicts),
+				)
+
+				return nil
+			}, fx.Populate(&log, &c, &db))
+		},
+	}
+}
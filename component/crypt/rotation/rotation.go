@@ -0,0 +1,286 @@
+// Package rotation re-encrypts stored capsules with the latest versions of
+// their keys, e.g. after a key has been rotated in vault.
+//
+// Rows are read from a Source in batches ordered by their key, recrypted
+// concurrently and written back to a Sink. After each batch the key of its
+// last row is saved to a Checkpoint, so that an interrupted run resumes
+// where it stopped.
+package rotation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/fruitsco/goji/component/crypt"
+)
+
+const (
+	// DefaultBatchSize is the number of rows read per batch
+	DefaultBatchSize = 1000
+
+	// DefaultConcurrency is the number of rows recrypted concurrently
+	DefaultConcurrency = 8
+)
+
+// Row is a single stored capsule
+type Row struct {
+	// Key identifies the row. Sources return rows in ascending key order,
+	// the key of the last row of a batch is used as checkpoint.
+	Key string
+
+	// Capsule is the stored capsule
+	Capsule crypt.Capsule
+
+	// AssociatedData is the additional data the capsule is bound to
+	AssociatedData []byte
+
+	// Original is the stored value of the capsule. Sinks use it
+	// to detect rows that were updated while they were rotated.
+	Original []byte
+}
+
+// Source reads the rows to rotate
+type Source interface {
+	// Next returns up to limit rows with a key greater than after,
+	// ordered by key. An empty after starts at the first row, an
+	// empty result ends the rotation.
+	Next(ctx context.Context, after string, limit int) ([]Row, error)
+}
+
+// Counter is implemented by sources which can count their rows.
+// The count is reported as total in the progress.
+type Counter interface {
+	Count(ctx context.Context) (int64, error)
+}
+
+// Sink writes the rotated rows
+type Sink interface {
+	// Write stores the rotated capsules of the rows and returns the number of
+	// rows written. Rows updated concurrently may be left untouched.
+	Write(ctx context.Context, rows []Row) (int, error)
+}
+
+// Progress is the progress of a rotation
+type Progress struct {
+	// Total is the number of rows of the source, if known
+	Total int64
+
+	// Scanned is the number of rows read from the source
+	Scanned int64
+
+	// Updated is the number of rows written with a new capsule
+	Updated int64
+
+	// Unchanged is the number of rows already using the latest key version
+	Unchanged int64
+
+	// Conflicts is the number of rows the sink did not write,
+	// because they were updated during the rotation
+	Conflicts int64
+
+	// Cursor is the key of the last row processed
+	Cursor string
+}
+
+// Job describes a rotation
+type Job struct {
+	// Source reads the rows
+	Source Source
+
+	// Sink writes the rotated rows
+	Sink Sink
+
+	// Checkpoint stores the cursor between batches, optional
+	Checkpoint Checkpoint
+
+	// BatchSize is the number of rows per batch, defaults to DefaultBatchSize
+	BatchSize int
+
+	// Concurrency is the number of rows recrypted concurrently,
+	// defaults to DefaultConcurrency
+	Concurrency int
+
+	// OnProgress is called after each batch, optional
+	OnProgress func(Progress)
+}
+
+// Runner runs rotation jobs
+type Runner struct {
+	crypto *crypt.Crypto
+	log    *zap.Logger
+}
+
+// RunnerParams is the parameters for the runner
+type RunnerParams struct {
+	fx.In
+
+	// Crypto recrypts the capsules
+	Crypto *crypt.Crypto
+
+	// Log is the logger for the runner
+	Log *zap.Logger
+}
+
+// NewRunner creates a new rotation runner
+func NewRunner(params RunnerParams) *Runner {
+	log := params.Log
+	if log == nil {
+		log = zap.NewNop()
+	}
+
+	return &Runner{
+		crypto: params.Crypto,
+		log:    log.Named("rotation"),
+	}
+}
+
+// Run rotates all rows of the job's source. It resumes from the checkpoint
+// of the job and clears it once all rows have been rotated.
+func (r *Runner) Run(ctx context.Context, job Job) (Progress, error) {
+	if job.Source == nil || job.Sink == nil {
+		return Progress{}, errors.New("rotation job requires a source and a sink")
+	}
+
+	batchSize := job.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	concurrency := job.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	var progress Progress
+
+	if job.Checkpoint != nil {
+		cursor, err := job.Checkpoint.Load(ctx)
+		if err != nil {
+			return progress, fmt.Errorf("failed to load checkpoint: %w", err)
+		}
+
+		if cursor != "" {
+			r.log.Info("resuming rotation from checkpoint", zap.String("cursor", cursor))
+		}
+
+		progress.Cursor = cursor
+	}
+
+	if counter, ok := job.Source.(Counter); ok {
+		total, err := counter.Count(ctx)
+		if err != nil {
+			return progress, fmt.Errorf("failed to count rows: %w", err)
+		}
+
+		progress.Total = total
+	}
+
+	for {
+		rows, err := job.Source.Next(ctx, progress.Cursor, batchSize)
+		if err != nil {
+			return progress, fmt.Errorf("failed to read rows after %q: %w", progress.Cursor, err)
+		}
+
+		if len(rows) == 0 {
+			break
+		}
+
+		rotated, err := r.recrypt(ctx, rows, concurrency)
+		if err != nil {
+			return progress, err
+		}
+
+		if len(rotated) > 0 {
+			written, err := job.Sink.Write(ctx, rotated)
+			if err != nil {
+				return progress, fmt.Errorf("failed to write rows: %w", err)
+			}
+
+			progress.Updated += int64(written)
+			progress.Conflicts += int64(len(rotated) - written)
+		}
+
+		progress.Scanned += int64(len(rows))
+		progress.Unchanged += int64(len(rows) - len(rotated))
+		progress.Cursor = rows[len(rows)-1].Key
+
+		if job.Checkpoint != nil {
+			if err := job.Checkpoint.Save(ctx, progress.Cursor); err != nil {
+				return progress, fmt.Errorf("failed to save checkpoint: %w", err)
+			}
+		}
+
+		if job.OnProgress != nil {
+			job.OnProgress(progress)
+		}
+
+		if len(rows) < batchSize {
+			break
+		}
+	}
+
+	if job.Checkpoint != nil {
+		if err := job.Checkpoint.Clear(ctx); err != nil {
+			return progress, fmt.Errorf("failed to clear checkpoint: %w", err)
+		}
+	}
+
+	return progress, nil
+}
+
+// recrypt recrypts the rows concurrently and returns the rows
+// whose capsule changed, in the order of the batch
+func (r *Runner) recrypt(ctx context.Context, rows []Row, concurrency int) ([]Row, error) {
+	changed := make([]bool, len(rows))
+	recrypted := make([]Row, len(rows))
+
+	var count atomic.Int64
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+
+	for i, row := range rows {
+		g.Go(func() error {
+			capsule, err := r.crypto.RecryptWithAAD(gctx, row.Capsule, row.AssociatedData)
+			if err != nil {
+				return fmt.Errorf("failed to recrypt row %q: %w", row.Key, err)
+			}
+
+			if !capsuleChanged(row.Capsule, capsule) {
+				return nil
+			}
+
+			row.Capsule = capsule
+			recrypted[i] = row
+			changed[i] = true
+			count.Add(1)
+
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	result := make([]Row, 0, count.Load())
+	for i, row := range recrypted {
+		if changed[i] {
+			result = append(result, row)
+		}
+	}
+
+	return result, nil
+}
+
+func capsuleChanged(before, after crypt.Capsule) bool {
+	return before.KeyName != after.KeyName ||
+		before.KeyVersion != after.KeyVersion ||
+		before.Algorithm() != after.Algorithm()
+}
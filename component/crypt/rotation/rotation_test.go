@@ -0,0 +1,210 @@
+package rotation_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fruitsco/goji/component/crypt"
+	"github.com/fruitsco/goji/component/crypt/rotation"
+)
+
+// memoryKeyProvider is a key provider holding versioned keys in memory
+type memoryKeyProvider map[string][][]byte
+
+func (p memoryKeyProvider) rotate(name string) {
+	p[name] = append(p[name], bytes.Repeat([]byte{byte(len(p[name]) + 1)}, 32))
+}
+
+func (p memoryKeyProvider) GetKey(ctx context.Context, name string) (crypt.Key, error) {
+	return p.GetKeyVersion(ctx, name, len(p[name]))
+}
+
+func (p memoryKeyProvider) GetKeyVersion(_ context.Context, name string, version int) (crypt.Key, error) {
+	if version < 1 || version > len(p[name]) {
+		return crypt.Key{}, fmt.Errorf("key %s@%d not found", name, version)
+	}
+	return crypt.Key{Name: name, Version: version, Data: p[name][version-1]}, nil
+}
+
+// memoryTable is a source and sink backed by a sorted slice
+type memoryTable struct {
+	mu   sync.Mutex
+	rows []rotation.Row
+
+	// failAfter fails writes once the given number of rows was written
+	failAfter int
+	written   int
+}
+
+func (m *memoryTable) Next(_ context.Context, after string, limit int) ([]rotation.Row, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var result []rotation.Row
+	for _, row := range m.rows {
+		if row.Key > after && len(result) < limit {
+			result = append(result, row)
+		}
+	}
+
+	return result, nil
+}
+
+func (m *memoryTable) Write(_ context.Context, rows []rotation.Row) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.failAfter > 0 && m.written >= m.failAfter {
+		return 0, errors.New("connection lost")
+	}
+
+	for _, row := range rows {
+		i := slices.IndexFunc(m.rows, func(r rotation.Row) bool { return r.Key == row.Key })
+		m.rows[i] = row
+	}
+
+	m.written += len(rows)
+
+	return len(rows), nil
+}
+
+func newTable(t *testing.T, c *crypt.Crypto, n int) *memoryTable {
+	table := &memoryTable{}
+
+	for i := range n {
+		key := fmt.Sprintf("%03d", i)
+		aad := crypt.AssociatedData("users", "email", key)
+
+		capsule, err := c.EncryptWithAAD(context.Background(), []byte("user-"+key), "data", aad)
+		require.NoError(t, err)
+
+		table.rows = append(table.rows, rotation.Row{Key: key, Capsule: capsule, AssociatedData: aad})
+	}
+
+	return table
+}
+
+func TestRunner_Run(t *testing.T) {
+	ctx := context.Background()
+
+	keys := memoryKeyProvider{}
+	keys.rotate("data")
+
+	c, err := crypt.New(crypt.CryptoParams{KeyProvider: keys})
+	require.NoError(t, err)
+
+	table := newTable(t, c, 25)
+
+	// rows written after the rotation are already up to date
+	keys.rotate("data")
+	table.rows = append(table.rows, newTable(t, c, 30).rows[25:]...)
+
+	runner := rotation.NewRunner(rotation.RunnerParams{Crypto: c})
+
+	var reports []rotation.Progress
+
+	progress, err := runner.Run(ctx, rotation.Job{
+		Source:      table,
+		Sink:        table,
+		BatchSize:   10,
+		Concurrency: 4,
+		OnProgress: func(p rotation.Progress) {
+			reports = append(reports, p)
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(30), progress.Scanned)
+	assert.Equal(t, int64(25), progress.Updated)
+	assert.Equal(t, int64(5), progress.Unchanged)
+	assert.Equal(t, "029", progress.Cursor)
+	assert.Len(t, reports, 3)
+
+	for _, row := range table.rows {
+		assert.Equal(t, 2, row.Capsule.KeyVersion)
+
+		plaintext, err := c.DecryptWithAAD(ctx, row.Capsule, row.AssociatedData)
+		require.NoError(t, err)
+		assert.Equal(t, "user-"+row.Key, string(plaintext))
+	}
+}
+
+func TestRunner_ResumesFromCheckpoint(t *testing.T) {
+	ctx := context.Background()
+
+	keys := memoryKeyProvider{}
+	keys.rotate("data")
+
+	c, err := crypt.New(crypt.CryptoParams{KeyProvider: keys})
+	require.NoError(t, err)
+
+	table := newTable(t, c, 25)
+	table.failAfter = 10
+
+	keys.rotate("data")
+
+	checkpoint := rotation.NewFileCheckpoint(filepath.Join(t.TempDir(), "rotation.checkpoint"))
+	runner := rotation.NewRunner(rotation.RunnerParams{Crypto: c})
+
+	job := rotation.Job{
+		Source:     table,
+		Sink:       table,
+		Checkpoint: checkpoint,
+		BatchSize:  10,
+	}
+
+	// the second batch fails, the first one is checkpointed
+	progress, err := runner.Run(ctx, job)
+	require.ErrorContains(t, err, "connection lost")
+	assert.Equal(t, "009", progress.Cursor)
+
+	cursor, err := checkpoint.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "009", cursor)
+
+	// the next run only processes the remaining rows
+	table.failAfter = 0
+
+	progress, err = runner.Run(ctx, job)
+	require.NoError(t, err)
+	assert.Equal(t, int64(15), progress.Scanned)
+	assert.Equal(t, int64(15), progress.Updated)
+
+	for _, row := range table.rows {
+		assert.Equal(t, 2, row.Capsule.KeyVersion)
+	}
+
+	// a completed rotation clears the checkpoint
+	cursor, err = checkpoint.Load(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, cursor)
+}
+
+func TestRunner_FailsForUndecryptableRows(t *testing.T) {
+	ctx := context.Background()
+
+	keys := memoryKeyProvider{}
+	keys.rotate("data")
+
+	c, err := crypt.New(crypt.CryptoParams{KeyProvider: keys})
+	require.NoError(t, err)
+
+	table := newTable(t, c, 3)
+	table.rows[1].AssociatedData = []byte("wrong")
+
+	keys.rotate("data")
+
+	runner := rotation.NewRunner(rotation.RunnerParams{Crypto: c})
+
+	_, err = runner.Run(ctx, rotation.Job{Source: table, Sink: table})
+	require.ErrorContains(t, err, `row "001"`)
+}
@@ -0,0 +1,214 @@
+package rotation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"entgo.io/ent/dialect"
+	entsql "entgo.io/ent/dialect/sql"
+
+	"github.com/fruitsco/goji/component/crypt"
+)
+
+// SQLTableConfig is the configuration of a SQLTable
+type SQLTableConfig struct {
+	// Table is the name of the table, optionally schema qualified
+	Table string
+
+	// KeyColumn is the unique, ordered column used for keyset pagination,
+	// e.g. the primary key. Defaults to `id`.
+	KeyColumn string
+
+	// CapsuleColumn is the column storing the capsules. NULL values are skipped.
+	CapsuleColumn string
+
+	// AssociatedData returns the additional data the capsule
+	// of the row with the given key is bound to, optional
+	AssociatedData func(key string) []byte
+
+	// Dialect is the ent dialect of the database, e.g. dialect.MySQL,
+	// which selects placeholders and quoting. Defaults to PostgreSQL.
+	Dialect string
+}
+
+// SQLTable is a source and sink for capsules stored in a database
+// column. Rows are paginated by their key, so that every batch is an index
+// range scan regardless of how far the rotation has progressed.
+type SQLTable struct {
+	db     *sql.DB
+	config SQLTableConfig
+
+	countQuery  string
+	updateQuery string
+}
+
+var _ = Source(&SQLTable{})
+var _ = Counter(&SQLTable{})
+var _ = Sink(&SQLTable{})
+
+// NewSQLTable creates a new SQL table source and sink
+func NewSQLTable(db *sql.DB, config SQLTableConfig) (*SQLTable, error) {
+	if db == nil {
+		return nil, errors.New("db is required for sql table")
+	}
+
+	if config.Table == "" || config.CapsuleColumn == "" {
+		return nil, errors.New("table and capsule column are required for sql table")
+	}
+
+	if config.KeyColumn == "" {
+		config.KeyColumn = "id"
+	}
+
+	if config.Dialect == "" {
+		config.Dialect = dialect.Postgres
+	}
+
+	t := &SQLTable{
+		db:     db,
+		config: config,
+	}
+
+	t.countQuery, _ = entsql.Dialect(config.Dialect).
+		Select(entsql.Count("*")).
+		From(t.table()).
+		Where(entsql.NotNull(config.CapsuleColumn)).
+		Query()
+
+	// only update rows which still store the capsule that was read.
+	// the arguments are only placeholders of the prepared statement.
+	schema, name := splitTable(config.Table)
+	t.updateQuery, _ = entsql.Dialect(config.Dialect).
+		Update(name).
+		Schema(schema).
+		Set(config.CapsuleColumn, "").
+		Where(entsql.And(
+			entsql.EQ(config.KeyColumn, ""),
+			entsql.EQ(config.CapsuleColumn, ""),
+		)).
+		Query()
+
+	return t, nil
+}
+
+func (t *SQLTable) Count(ctx context.Context) (int64, error) {
+	var count int64
+	if err := t.db.QueryRowContext(ctx, t.countQuery).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (t *SQLTable) Next(ctx context.Context, after string, limit int) ([]Row, error) {
+	selector := entsql.Dialect(t.config.Dialect).
+		Select(t.config.KeyColumn, t.config.CapsuleColumn).
+		From(t.table()).
+		Where(entsql.NotNull(t.config.CapsuleColumn)).
+		OrderBy(t.config.KeyColumn).
+		Limit(limit)
+
+	if after != "" {
+		selector.Where(entsql.GT(t.config.KeyColumn, after))
+	}
+
+	query, args := selector.Query()
+
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]Row, 0, limit)
+
+	for rows.Next() {
+		var (
+			key      string
+			original []byte
+		)
+
+		if err := rows.Scan(&key, &original); err != nil {
+			return nil, err
+		}
+
+		var capsule crypt.Capsule
+		if err := capsule.Scan(original); err != nil {
+			return nil, fmt.Errorf("row %q: %w", key, err)
+		}
+
+		row := Row{
+			Key:      key,
+			Capsule:  capsule,
+			Original: original,
+		}
+
+		if t.config.AssociatedData != nil {
+			row.AssociatedData = t.config.AssociatedData(key)
+		}
+
+		result = append(result, row)
+	}
+
+	return result, rows.Err()
+}
+
+func (t *SQLTable) Write(ctx context.Context, rows []Row) (int, error) {
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, t.updateQuery)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	written := 0
+
+	for _, row := range rows {
+		// keep the format of the stored capsule, e.g. for json columns
+		value, err := row.Capsule.ValueLike(row.Original)
+		if err != nil {
+			return 0, fmt.Errorf("row %q: %w", row.Key, err)
+		}
+
+		res, err := stmt.ExecContext(ctx, value, row.Key, row.Original)
+		if err != nil {
+			return 0, fmt.Errorf("row %q: %w", row.Key, err)
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+
+		written += int(n)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return written, nil
+}
+
+// table returns the possibly schema qualified table
+func (t *SQLTable) table() *entsql.SelectTable {
+	schema, name := splitTable(t.config.Table)
+	return entsql.Table(name).Schema(schema)
+}
+
+// splitTable splits a schema qualified table name
+func splitTable(table string) (string, string) {
+	if schema, name, ok := strings.Cut(table, "."); ok {
+		return schema, name
+	}
+
+	return "", table
+}
@@ -0,0 +1,43 @@
+package rotation
+
+import (
+	"database/sql"
+	"testing"
+
+	"entgo.io/ent/dialect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLTable_Dialects(t *testing.T) {
+	tests := []struct {
+		dialect string
+		count   string
+		update  string
+	}{
+		{
+			dialect.Postgres,
+			`SELECT COUNT(*) FROM "app"."users" WHERE "email" IS NOT NULL`,
+			`UPDATE "app"."users" SET "email" = $1 WHERE "id" = $2 AND "email" = $3`,
+		},
+		{
+			dialect.MySQL,
+			"SELECT COUNT(*) FROM `app`.`users` WHERE `email` IS NOT NULL",
+			"UPDATE `app`.`users` SET `email` = ? WHERE `id` = ? AND `email` = ?",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dialect, func(t *testing.T) {
+			table, err := NewSQLTable(&sql.DB{}, SQLTableConfig{
+				Table:         "app.users",
+				CapsuleColumn: "email",
+				Dialect:       tt.dialect,
+			})
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.count, table.countQuery)
+			assert.Equal(t, tt.update, table.updateQuery)
+		})
+	}
+}
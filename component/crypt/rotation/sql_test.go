@@ -0,0 +1,77 @@
+package rotation_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"entgo.io/ent/dialect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+
+	"github.com/fruitsco/goji/component/crypt"
+	"github.com/fruitsco/goji/component/crypt/rotation"
+)
+
+func TestSQLTable(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	// a single connection keeps the in-memory database
+	db.SetMaxOpenConns(1)
+
+	_, err = db.ExecContext(ctx, `CREATE TABLE users (id TEXT PRIMARY KEY, email TEXT)`)
+	require.NoError(t, err)
+
+	old := crypt.Capsule{Data: []byte("old"), KeyName: "users", KeyVersion: 1}
+	value, err := old.Value()
+	require.NoError(t, err)
+
+	for _, id := range []string{"a", "b", "c"} {
+		_, err = db.ExecContext(ctx, `INSERT INTO users (id, email) VALUES (?, ?)`, id, value)
+		require.NoError(t, err)
+	}
+	_, err = db.ExecContext(ctx, `INSERT INTO users (id, email) VALUES ('d', NULL)`)
+	require.NoError(t, err)
+
+	table, err := rotation.NewSQLTable(db, rotation.SQLTableConfig{
+		Table:         "users",
+		CapsuleColumn: "email",
+		Dialect:       dialect.SQLite,
+	})
+	require.NoError(t, err)
+
+	count, err := table.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	rows, err := table.Next(ctx, "", 2)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "a", rows[0].Key)
+	assert.Equal(t, old, rows[0].Capsule)
+
+	rows, err = table.Next(ctx, "b", 2)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "c", rows[0].Key)
+
+	rows[0].Capsule = crypt.Capsule{Data: []byte("new"), KeyName: "users", KeyVersion: 2}
+
+	written, err := table.Write(ctx, rows)
+	require.NoError(t, err)
+	assert.Equal(t, 1, written)
+
+	// rows changed since they were read are not overwritten
+	written, err = table.Write(ctx, rows)
+	require.NoError(t, err)
+	assert.Zero(t, written)
+
+	var stored crypt.Capsule
+	require.NoError(t, db.QueryRowContext(ctx, `SELECT email FROM users WHERE id = 'c'`).Scan(&stored))
+	assert.Equal(t, 2, stored.KeyVersion)
+}
@@ -0,0 +1,334 @@
+package crypt
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureAlgorithm identifies the algorithm of a signature
+type SignatureAlgorithm string
+
+const (
+	// SignatureHMACSHA256 signs with HMAC-SHA256, the key is
+	// a secret of at least 16 bytes shared by signer and verifier
+	SignatureHMACSHA256 SignatureAlgorithm = "HS256"
+
+	// SignatureEd25519 signs with Ed25519, the key is a 32 byte seed
+	// or a 64 byte private key. Verifiers outside of the app use the
+	// key returned by PublicKey.
+	SignatureEd25519 SignatureAlgorithm = "EdDSA"
+)
+
+// tokenPrefix is the prefix of tokens created by NewToken
+const tokenPrefix = "t1"
+
+const (
+	// signatureContext and tokenContext prefix the signed messages, so that
+	// a signature of data passed to Sign is never valid for a token
+	signatureContext = "goji/crypt signature v1\x00"
+	tokenContext     = "goji/crypt token v1\x00"
+)
+
+// SignedMessage returns the message Sign signs for the data, for
+// verifiers outside of the app
+func SignedMessage(data []byte) []byte {
+	return append([]byte(signatureContext), data...)
+}
+
+var (
+	// ErrInvalidSignature is returned if a signature or token does not verify
+	ErrInvalidSignature = errors.New("invalid signature")
+
+	// ErrTokenExpired is returned for tokens whose expiry has passed
+	ErrTokenExpired = errors.New("token expired")
+)
+
+// Signature is a signature of data, referencing the key version used,
+// so that it can be verified after the key has been rotated
+type Signature struct {
+	Algorithm  SignatureAlgorithm
+	KeyName    string
+	KeyVersion int
+	Value      []byte
+}
+
+// String encodes the signature as `<algorithm>.<key name>.<key version>.<signature>`,
+// with the key name and signature base64 encoded, e.g. for webhook headers
+func (s Signature) String() string {
+	return strings.Join([]string{
+		string(s.Algorithm),
+		base64.RawURLEncoding.EncodeToString([]byte(s.KeyName)),
+		strconv.Itoa(s.KeyVersion),
+		base64.RawURLEncoding.EncodeToString(s.Value),
+	}, ".")
+}
+
+// ParseSignature decodes a signature encoded by String
+func ParseSignature(s string) (Signature, error) {
+	parts := strings.Split(s, ".")
+	if len(parts) != 4 {
+		return Signature{}, fmt.Errorf("%w: malformed signature", ErrInvalidSignature)
+	}
+
+	keyName, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return Signature{}, fmt.Errorf("%w: malformed key name: %w", ErrInvalidSignature, err)
+	}
+
+	keyVersion, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return Signature{}, fmt.Errorf("%w: malformed key version: %w", ErrInvalidSignature, err)
+	}
+
+	value, err := base64.RawURLEncoding.DecodeString(parts[3])
+	if err != nil {
+		return Signature{}, fmt.Errorf("%w: malformed signature: %w", ErrInvalidSignature, err)
+	}
+
+	return Signature{
+		Algorithm:  SignatureAlgorithm(parts[0]),
+		KeyName:    string(keyName),
+		KeyVersion: keyVersion,
+		Value:      value,
+	}, nil
+}
+
+// Sign signs the data using the latest version of the key with the given name
+func (c *Crypto) Sign(ctx context.Context, keyName string, algorithm SignatureAlgorithm, data []byte) (Signature, error) {
+	key, err := c.keyProvider.GetKey(ctx, keyName)
+	if err != nil {
+		return Signature{}, fmt.Errorf("failed to get signing key: %w", err)
+	}
+
+	value, err := sign(algorithm, key.Data, SignedMessage(data))
+	if err != nil {
+		return Signature{}, err
+	}
+
+	return Signature{
+		Algorithm:  algorithm,
+		KeyName:    key.Name,
+		KeyVersion: key.Version,
+		Value:      value,
+	}, nil
+}
+
+// Verify verifies the signature of the data. The signature must have been
+// created with the key with the given name, so that signatures created
+// for another purpose are rejected, and with the given algorithm, so that
+// the algorithm is not chosen by the signature.
+func (c *Crypto) Verify(
+	ctx context.Context,
+	keyName string,
+	algorithm SignatureAlgorithm,
+	data []byte,
+	signature Signature,
+) error {
+	return c.verify(ctx, keyName, algorithm, SignedMessage(data), signature)
+}
+
+func (c *Crypto) verify(
+	ctx context.Context,
+	keyName string,
+	algorithm SignatureAlgorithm,
+	message []byte,
+	signature Signature,
+) error {
+	if signature.KeyName != keyName {
+		return fmt.Errorf("%w: signed with key %s, expected %s", ErrInvalidSignature, signature.KeyName, keyName)
+	}
+
+	if signature.Algorithm != algorithm {
+		return fmt.Errorf("%w: signed with algorithm %s, expected %s", ErrInvalidSignature, signature.Algorithm, algorithm)
+	}
+
+	key, err := c.keyProvider.GetKeyVersion(ctx, keyName, signature.KeyVersion)
+	if err != nil {
+		return fmt.Errorf("failed to get signing key: %w", err)
+	}
+
+	return verify(algorithm, key.Data, message, signature.Value)
+}
+
+// PublicKey returns the Ed25519 public key of a version of the key with the
+// given name, for verifiers outside of the app, e.g. webhook receivers.
+// Signatures are created for the SignedMessage of the data.
+func (c *Crypto) PublicKey(ctx context.Context, keyName string, version int) (ed25519.PublicKey, error) {
+	key, err := c.keyProvider.GetKeyVersion(ctx, keyName, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get signing key: %w", err)
+	}
+
+	privateKey, err := ed25519PrivateKey(key.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	return privateKey.Public().(ed25519.PublicKey), nil
+}
+
+// NewToken creates a URL safe token carrying the payload, signed with the
+// latest version of the key with the given name. The token expires at the
+// given time, a zero time creates a token that does not expire. The payload
+// is signed, not encrypted.
+func (c *Crypto) NewToken(
+	ctx context.Context,
+	keyName string,
+	algorithm SignatureAlgorithm,
+	payload []byte,
+	expiresAt time.Time,
+) (string, error) {
+	key, err := c.keyProvider.GetKey(ctx, keyName)
+	if err != nil {
+		return "", fmt.Errorf("failed to get signing key: %w", err)
+	}
+
+	var expiry int64
+	if !expiresAt.IsZero() {
+		expiry = expiresAt.Unix()
+	}
+
+	// t1.<algorithm>.<key name>.<key version>.<expiry>.<payload>.<signature>
+	content := strings.Join([]string{
+		tokenPrefix,
+		string(algorithm),
+		base64.RawURLEncoding.EncodeToString([]byte(key.Name)),
+		strconv.Itoa(key.Version),
+		strconv.FormatInt(expiry, 10),
+		base64.RawURLEncoding.EncodeToString(payload),
+	}, ".")
+
+	value, err := sign(algorithm, key.Data, []byte(tokenContext+content))
+	if err != nil {
+		return "", err
+	}
+
+	return content + "." + base64.RawURLEncoding.EncodeToString(value), nil
+}
+
+// VerifyToken verifies a token created by NewToken with the key with the
+// given name and the given algorithm and returns its payload. Tokens remain
+// valid after the key has been rotated, as long as the version they were
+// signed with is enabled.
+func (c *Crypto) VerifyToken(
+	ctx context.Context,
+	keyName string,
+	algorithm SignatureAlgorithm,
+	token string,
+) ([]byte, error) {
+	content, encodedSignature, ok := cutLast(token, ".")
+	if !ok {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidSignature)
+	}
+
+	parts := strings.Split(content, ".")
+	if len(parts) != 6 || parts[0] != tokenPrefix {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidSignature)
+	}
+
+	signature, err := ParseSignature(strings.Join([]string{parts[1], parts[2], parts[3], encodedSignature}, "."))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.verify(ctx, keyName, algorithm, []byte(tokenContext+content), signature); err != nil {
+		return nil, err
+	}
+
+	// the remaining fields are only trusted after verification
+	expiry, err := strconv.ParseInt(parts[4], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed expiry: %w", ErrInvalidSignature, err)
+	}
+
+	if expiry != 0 && !time.Now().Before(time.Unix(expiry, 0)) {
+		return nil, ErrTokenExpired
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[5])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed payload: %w", ErrInvalidSignature, err)
+	}
+
+	return payload, nil
+}
+
+func sign(algorithm SignatureAlgorithm, key []byte, data []byte) ([]byte, error) {
+	switch algorithm {
+	case SignatureHMACSHA256:
+		if len(key) < minHMACKeySize {
+			return nil, fmt.Errorf("HMAC key must be at least %d bytes", minHMACKeySize)
+		}
+
+		mac := hmac.New(sha256.New, key)
+		mac.Write(data)
+
+		return mac.Sum(nil), nil
+	case SignatureEd25519:
+		privateKey, err := ed25519PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+
+		return ed25519.Sign(privateKey, data), nil
+	}
+
+	return nil, fmt.Errorf("unsupported signature algorithm %q", algorithm)
+}
+
+func verify(algorithm SignatureAlgorithm, key []byte, data []byte, signature []byte) error {
+	switch algorithm {
+	case SignatureHMACSHA256:
+		expected, err := sign(algorithm, key, data)
+		if err != nil {
+			return err
+		}
+
+		if !hmac.Equal(expected, signature) {
+			return ErrInvalidSignature
+		}
+
+		return nil
+	case SignatureEd25519:
+		privateKey, err := ed25519PrivateKey(key)
+		if err != nil {
+			return err
+		}
+
+		if !ed25519.Verify(privateKey.Public().(ed25519.PublicKey), data, signature) {
+			return ErrInvalidSignature
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("%w: unsupported signature algorithm %q", ErrInvalidSignature, algorithm)
+}
+
+func ed25519PrivateKey(key []byte) (ed25519.PrivateKey, error) {
+	switch len(key) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(key), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(key), nil
+	}
+
+	return nil, fmt.Errorf("Ed25519 key must be %d or %d bytes, got %d", ed25519.SeedSize, ed25519.PrivateKeySize, len(key))
+}
+
+func cutLast(s, sep string) (string, string, bool) {
+	i := strings.LastIndex(s, sep)
+	if i < 0 {
+		return s, "", false
+	}
+
+	return s[:i], s[i+len(sep):], true
+}
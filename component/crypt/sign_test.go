@@ -0,0 +1,141 @@
+package crypt_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fruitsco/goji/component/crypt"
+)
+
+func TestCrypto_Sign(t *testing.T) {
+	ctx := context.Background()
+
+	keys := memoryKeyProvider{}
+	keys.rotate("webhooks")
+
+	c := newCrypto(t, keys, false)
+
+	body := []byte(`{"event":"delivered"}`)
+
+	for _, algorithm := range []crypt.SignatureAlgorithm{crypt.SignatureHMACSHA256, crypt.SignatureEd25519} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			signature, err := c.Sign(ctx, "webhooks", algorithm, body)
+			require.NoError(t, err)
+
+			// signatures survive encoding, e.g. in a header
+			parsed, err := crypt.ParseSignature(signature.String())
+			require.NoError(t, err)
+			assert.Equal(t, signature, parsed)
+
+			require.NoError(t, c.Verify(ctx, "webhooks", algorithm, body, parsed))
+
+			err = c.Verify(ctx, "webhooks", algorithm, []byte(`{"event":"bounced"}`), parsed)
+			require.ErrorIs(t, err, crypt.ErrInvalidSignature)
+
+			// signatures for another purpose are rejected
+			err = c.Verify(ctx, "downloads", algorithm, body, parsed)
+			require.ErrorIs(t, err, crypt.ErrInvalidSignature)
+
+			// the algorithm is pinned by the verifier, not the signature
+			for _, other := range []crypt.SignatureAlgorithm{crypt.SignatureHMACSHA256, crypt.SignatureEd25519} {
+				if other != algorithm {
+					err = c.Verify(ctx, "webhooks", other, body, parsed)
+					require.ErrorIs(t, err, crypt.ErrInvalidSignature)
+				}
+			}
+		})
+	}
+
+	signature, err := c.Sign(ctx, "webhooks", crypt.SignatureEd25519, body)
+	require.NoError(t, err)
+
+	publicKey, err := c.PublicKey(ctx, "webhooks", signature.KeyVersion)
+	require.NoError(t, err)
+	assert.True(t, ed25519.Verify(publicKey, crypt.SignedMessage(body), signature.Value))
+}
+
+func TestCrypto_Token(t *testing.T) {
+	ctx := context.Background()
+
+	keys := memoryKeyProvider{}
+	keys.rotate("unsubscribe")
+
+	c := newCrypto(t, keys, false)
+
+	token, err := c.NewToken(ctx, "unsubscribe", crypt.SignatureHMACSHA256, []byte("user:42"), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(token, "t1.HS256."))
+
+	// tokens keep verifying after the key has been rotated
+	keys.rotate("unsubscribe")
+
+	payload, err := c.VerifyToken(ctx, "unsubscribe", crypt.SignatureHMACSHA256, token)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("user:42"), payload)
+
+	newer, err := c.NewToken(ctx, "unsubscribe", crypt.SignatureHMACSHA256, []byte("user:42"), time.Time{})
+	require.NoError(t, err)
+	assert.Contains(t, newer, ".2.0.")
+
+	_, err = c.VerifyToken(ctx, "unsubscribe", crypt.SignatureHMACSHA256, newer)
+	require.NoError(t, err)
+
+	// tokens of another algorithm are rejected, even if they are valid
+	ed, err := c.NewToken(ctx, "unsubscribe", crypt.SignatureEd25519, []byte("user:42"), time.Time{})
+	require.NoError(t, err)
+
+	_, err = c.VerifyToken(ctx, "unsubscribe", crypt.SignatureHMACSHA256, ed)
+	require.ErrorIs(t, err, crypt.ErrInvalidSignature)
+
+	payload, err = c.VerifyToken(ctx, "unsubscribe", crypt.SignatureEd25519, ed)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("user:42"), payload)
+
+	expired, err := c.NewToken(ctx, "unsubscribe", crypt.SignatureHMACSHA256, []byte("user:42"), time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+
+	_, err = c.VerifyToken(ctx, "unsubscribe", crypt.SignatureHMACSHA256, expired)
+	require.ErrorIs(t, err, crypt.ErrTokenExpired)
+
+	// tampering with the payload or the expiry invalidates the token
+	parts := strings.Split(token, ".")
+	parts[5] = "dXNlcjo0Mw"
+	_, err = c.VerifyToken(ctx, "unsubscribe", crypt.SignatureHMACSHA256, strings.Join(parts, "."))
+	require.ErrorIs(t, err, crypt.ErrInvalidSignature)
+
+	parts = strings.Split(expired, ".")
+	parts[4] = "0"
+	_, err = c.VerifyToken(ctx, "unsubscribe", crypt.SignatureHMACSHA256, strings.Join(parts, "."))
+	require.ErrorIs(t, err, crypt.ErrInvalidSignature)
+
+	_, err = c.VerifyToken(ctx, "unsubscribe", crypt.SignatureHMACSHA256, "garbage")
+	require.ErrorIs(t, err, crypt.ErrInvalidSignature)
+}
+
+func TestCrypto_SignIsNotAToken(t *testing.T) {
+	ctx := context.Background()
+
+	keys := memoryKeyProvider{}
+	keys.rotate("shared")
+
+	c := newCrypto(t, keys, false)
+
+	token, err := c.NewToken(ctx, "shared", crypt.SignatureHMACSHA256, []byte("user:42"), time.Time{})
+	require.NoError(t, err)
+
+	// a signature of data shaped like the content of a token
+	content := token[:strings.LastIndex(token, ".")]
+	forged := strings.Replace(content, "dXNlcjo0Mg", "YWRtaW4", 1)
+
+	signature, err := c.Sign(ctx, "shared", crypt.SignatureHMACSHA256, []byte(forged))
+	require.NoError(t, err)
+
+	_, err = c.VerifyToken(ctx, "shared", crypt.SignatureHMACSHA256, forged+"."+strings.Split(signature.String(), ".")[3])
+	require.ErrorIs(t, err, crypt.ErrInvalidSignature)
+}
@@ -0,0 +1,160 @@
+package crypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+)
+
+// sivTagSize is the size of the synthetic IV prepended to the ciphertext
+const sivTagSize = aes.BlockSize
+
+// aesSIV implements the deterministic authenticated encryption
+// AES-SIV-CMAC as specified in RFC 5297.
+type aesSIV struct {
+	mac cipher.Block
+	ctr cipher.Block
+}
+
+// newAESSIV creates an AES-SIV cipher. The key is split in halves for
+// CMAC and CTR, so it must be 32, 48 or 64 bytes long.
+func newAESSIV(key []byte) (*aesSIV, error) {
+	switch len(key) {
+	case 32, 48, 64:
+	default:
+		return nil, fmt.Errorf("invalid AES-SIV key size %d, must be 32, 48 or 64 bytes", len(key))
+	}
+
+	half := len(key) / 2
+
+	mac, err := aes.NewCipher(key[:half])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+
+	ctr, err := aes.NewCipher(key[half:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+
+	return &aesSIV{mac: mac, ctr: ctr}, nil
+}
+
+// Seal encrypts the plaintext, the result is the synthetic IV followed
+// by the ciphertext. Equal inputs always result in equal outputs.
+func (s *aesSIV) Seal(plaintext []byte, additionalData ...[]byte) []byte {
+	v := s.s2v(plaintext, additionalData)
+
+	out := make([]byte, sivTagSize+len(plaintext))
+	copy(out, v)
+
+	s.xorKeyStream(out[sivTagSize:], plaintext, v)
+
+	return out
+}
+
+// Open decrypts and authenticates a ciphertext created by Seal.
+func (s *aesSIV) Open(ciphertext []byte, additionalData ...[]byte) ([]byte, error) {
+	if len(ciphertext) < sivTagSize {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	v, ciphertext := ciphertext[:sivTagSize], ciphertext[sivTagSize:]
+
+	plaintext := make([]byte, len(ciphertext))
+	s.xorKeyStream(plaintext, ciphertext, v)
+
+	if subtle.ConstantTimeCompare(v, s.s2v(plaintext, additionalData)) != 1 {
+		return nil, errors.New("failed to decrypt data: message authentication failed")
+	}
+
+	return plaintext, nil
+}
+
+func (s *aesSIV) xorKeyStream(dst, src, v []byte) {
+	// the 31st and 63rd bit of the counter are cleared, see RFC 5297 2.5
+	q := make([]byte, aes.BlockSize)
+	copy(q, v)
+	q[8] &= 0x7f
+	q[12] &= 0x7f
+
+	cipher.NewCTR(s.ctr, q).XORKeyStream(dst, src)
+}
+
+// s2v derives the synthetic IV from the additional data and the plaintext
+func (s *aesSIV) s2v(plaintext []byte, additionalData [][]byte) []byte {
+	d := s.cmac(make([]byte, aes.BlockSize))
+
+	for _, ad := range additionalData {
+		dbl(d)
+		subtle.XORBytes(d, d, s.cmac(ad))
+	}
+
+	var t []byte
+	if len(plaintext) >= aes.BlockSize {
+		// xor the last block of the plaintext with d
+		t = append([]byte(nil), plaintext...)
+		end := t[len(t)-aes.BlockSize:]
+		subtle.XORBytes(end, end, d)
+	} else {
+		dbl(d)
+		t = pad(plaintext)
+		subtle.XORBytes(t, t, d)
+	}
+
+	return s.cmac(t)
+}
+
+// cmac computes the AES-CMAC of the message as specified in RFC 4493
+func (s *aesSIV) cmac(msg []byte) []byte {
+	k1 := make([]byte, aes.BlockSize)
+	s.mac.Encrypt(k1, k1)
+	dbl(k1)
+
+	n := (len(msg) + aes.BlockSize - 1) / aes.BlockSize
+
+	var last []byte
+	if n > 0 && len(msg)%aes.BlockSize == 0 {
+		last = append([]byte(nil), msg[(n-1)*aes.BlockSize:]...)
+		subtle.XORBytes(last, last, k1)
+	} else {
+		if n == 0 {
+			n = 1
+		}
+		k2 := append([]byte(nil), k1...)
+		dbl(k2)
+		last = pad(msg[(n-1)*aes.BlockSize:])
+		subtle.XORBytes(last, last, k2)
+	}
+
+	x := make([]byte, aes.BlockSize)
+	for i := 0; i < n-1; i++ {
+		subtle.XORBytes(x, x, msg[i*aes.BlockSize:(i+1)*aes.BlockSize])
+		s.mac.Encrypt(x, x)
+	}
+
+	subtle.XORBytes(x, x, last)
+	s.mac.Encrypt(x, x)
+
+	return x
+}
+
+// dbl multiplies the block by x in GF(2^128) in place
+func dbl(b []byte) {
+	carry := b[0] >> 7
+	for i := 0; i < len(b)-1; i++ {
+		b[i] = b[i]<<1 | b[i+1]>>7
+	}
+	b[len(b)-1] <<= 1
+	b[len(b)-1] ^= 0x87 * carry
+}
+
+// pad appends the 10* padding to a partial block
+func pad(b []byte) []byte {
+	padded := make([]byte, aes.BlockSize)
+	copy(padded, b)
+	padded[len(b)] = 0x80
+	return padded
+}
@@ -0,0 +1,68 @@
+package crypt
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+// TestAESSIV verifies the implementation against the test vectors of RFC 5297
+func TestAESSIV(t *testing.T) {
+	tests := []struct {
+		name       string
+		key        string
+		ad         []string
+		plaintext  string
+		ciphertext string
+	}{
+		{
+			name:       "deterministic",
+			key:        "fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff",
+			ad:         []string{"101112131415161718191a1b1c1d1e1f2021222324252627"},
+			plaintext:  "112233445566778899aabbccddee",
+			ciphertext: "85632d07c6e8f37f950acd320a2ecc9340c02b9690c4dc04daef7f6afe5c",
+		},
+		{
+			name: "nonce based",
+			key:  "7f7e7d7c7b7a79787776757473727170404142434445464748494a4b4c4d4e4f",
+			ad: []string{
+				"00112233445566778899aabbccddeeffdeaddadadeaddadaffeeddccbbaa99887766554433221100",
+				"102030405060708090a0",
+				"09f911029d74e35bd84156c5635688c0",
+			},
+			plaintext:  "7468697320697320736f6d6520706c61696e7465787420746f20656e6372797074207573696e67205349562d414553",
+			ciphertext: "7bdb6e3b432667eb06f4d14bff2fbd0fcb900f2fddbe404326601965c889bf17dba77ceb094fa663b7a3f748ba8af829ea64ad544a272e9c485b62a3fd5c0d",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			siv, err := newAESSIV(mustHex(t, tt.key))
+			require.NoError(t, err)
+
+			ad := make([][]byte, len(tt.ad))
+			for i, a := range tt.ad {
+				ad[i] = mustHex(t, a)
+			}
+
+			ciphertext := siv.Seal(mustHex(t, tt.plaintext), ad...)
+			assert.Equal(t, tt.ciphertext, hex.EncodeToString(ciphertext))
+
+			plaintext, err := siv.Open(ciphertext, ad...)
+			require.NoError(t, err)
+			assert.Equal(t, tt.plaintext, hex.EncodeToString(plaintext))
+
+			ciphertext[len(ciphertext)-1] ^= 1
+			_, err = siv.Open(ciphertext, ad...)
+			require.Error(t, err)
+		})
+	}
+}
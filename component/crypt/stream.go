@@ -0,0 +1,398 @@
+package crypt
+
+import (
+	"bufio"
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	// streamSegmentSize is the size of the plaintext segments of a stream
+	streamSegmentSize = 64 * 1024
+
+	// maxStreamSegmentSize limits the segment size accepted when decrypting
+	maxStreamSegmentSize = 16 * 1024 * 1024
+
+	// maxStreamHeaderField limits the length of header fields when decrypting
+	maxStreamHeaderField = 64 * 1024
+)
+
+// errStreamTruncated is returned if a stream ends before its last segment
+var errStreamTruncated = errors.New("encrypted stream is truncated")
+
+// EncryptStream returns a writer encrypting everything written to it into w,
+// using a new data key wrapped with the key with the given name. The stream
+// starts with a header in the binary capsule format, followed by segments
+// of 64 KiB, which are authenticated individually (STREAM construction).
+// The last segment is marked, so that truncated streams are detected.
+// The writer must be closed to write the last segment, which does not
+// close w.
+func (c *Crypto) EncryptStream(ctx context.Context, w io.Writer, keyName string) (io.WriteCloser, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	defer clear(dataKey)
+
+	wrapped, err := c.streamKeyWrapper().WrapKey(ctx, keyName, dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	header := Capsule{
+		KeyName:    wrapped.KeyName,
+		KeyVersion: wrapped.KeyVersion,
+		WrappedKey: wrapped.Data,
+	}.appendHeader(nil, AlgorithmAESGCMStream)
+	header = binary.AppendUvarint(header, streamSegmentSize)
+
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(header); err != nil {
+		return nil, fmt.Errorf("failed to write stream header: %w", err)
+	}
+
+	return &streamWriter{
+		w:      w,
+		aead:   gcm,
+		header: header,
+		buf:    make([]byte, 0, streamSegmentSize),
+	}, nil
+}
+
+// DecryptStream returns a reader decrypting the stream written by
+// EncryptStream from r. Reads fail if a segment has been modified,
+// reordered or removed, plaintext is only returned once the segment
+// it belongs to is authenticated.
+func (c *Crypto) DecryptStream(ctx context.Context, r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+
+	capsule, segmentSize, header, err := readStreamHeader(br)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey, err := c.streamKeyWrapper().UnwrapKey(ctx, WrappedKey{
+		KeyName:    capsule.KeyName,
+		KeyVersion: capsule.KeyVersion,
+		Data:       capsule.WrappedKey,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	defer clear(dataKey)
+
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &streamReader{
+		r:      br,
+		aead:   gcm,
+		header: header,
+		in:     make([]byte, segmentSize+gcm.Overhead()),
+	}, nil
+}
+
+// streamKeyWrapper returns the key wrapper for stream data keys. Streams
+// always use data keys, so the named key wraps them directly if no key
+// wrapper is configured.
+func (c *Crypto) streamKeyWrapper() KeyWrapper {
+	if c.keyWrapper != nil {
+		return c.keyWrapper
+	}
+
+	return NewKeyProviderWrapper(KeyProviderWrapperParams{KeyProvider: c.keyProvider})
+}
+
+// streamNonce returns the nonce of a segment, consisting of the segment
+// counter and a flag marking the last segment
+func streamNonce(nonce []byte, counter uint64, last bool) []byte {
+	clear(nonce)
+	binary.BigEndian.PutUint64(nonce[len(nonce)-9:], counter)
+
+	if last {
+		nonce[len(nonce)-1] = 1
+	}
+
+	return nonce
+}
+
+// MARK: - Writer
+
+type streamWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	header  []byte
+	buf     []byte
+	out     []byte
+	nonce   [12]byte
+	counter uint64
+	closed  bool
+	err     error
+}
+
+func (s *streamWriter) Write(p []byte) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+
+	if s.closed {
+		return 0, errors.New("write to closed encrypted stream")
+	}
+
+	written := 0
+
+	for len(p) > 0 {
+		// a full segment is only written once more data follows,
+		// as the last segment must be marked as such
+		if len(s.buf) == cap(s.buf) {
+			if err := s.flush(false); err != nil {
+				return written, err
+			}
+		}
+
+		n := min(len(p), cap(s.buf)-len(s.buf))
+		s.buf = append(s.buf, p[:n]...)
+		p = p[n:]
+		written += n
+	}
+
+	return written, nil
+}
+
+// Close writes the last segment
+func (s *streamWriter) Close() error {
+	if s.closed {
+		return s.err
+	}
+
+	s.closed = true
+
+	if s.err != nil {
+		return s.err
+	}
+
+	err := s.flush(true)
+	clear(s.buf)
+
+	return err
+}
+
+func (s *streamWriter) flush(last bool) error {
+	if s.counter == math.MaxUint64 {
+		s.err = errors.New("encrypted stream exceeds the maximum number of segments")
+		return s.err
+	}
+
+	nonce := streamNonce(s.nonce[:], s.counter, last)
+	s.out = s.aead.Seal(s.out[:0], nonce, s.buf, s.header)
+
+	if _, err := s.w.Write(s.out); err != nil {
+		s.err = fmt.Errorf("failed to write segment %d: %w", s.counter, err)
+		return s.err
+	}
+
+	s.counter++
+	s.buf = s.buf[:0]
+
+	return nil
+}
+
+// MARK: - Reader
+
+type streamReader struct {
+	r       *bufio.Reader
+	aead    cipher.AEAD
+	header  []byte
+	in      []byte
+	plain   []byte
+	pos     int
+	nonce   [12]byte
+	counter uint64
+	done    bool
+	err     error
+}
+
+func (s *streamReader) Read(p []byte) (int, error) {
+	for s.pos == len(s.plain) {
+		if s.err != nil {
+			return 0, s.err
+		}
+
+		if s.done {
+			return 0, io.EOF
+		}
+
+		s.err = s.readSegment()
+	}
+
+	n := copy(p, s.plain[s.pos:])
+	s.pos += n
+
+	return n, nil
+}
+
+func (s *streamReader) readSegment() error {
+	n, err := io.ReadFull(s.r, s.in)
+
+	var last bool
+
+	switch {
+	case err == io.EOF:
+		// the stream ended without a segment marked as last
+		return errStreamTruncated
+	case err == io.ErrUnexpectedEOF:
+		last = true
+	case err != nil:
+		return fmt.Errorf("failed to read segment %d: %w", s.counter, err)
+	default:
+		// a full segment is the last one if the stream ends after it
+		if _, err := s.r.Peek(1); err == io.EOF {
+			last = true
+		} else if err != nil {
+			return fmt.Errorf("failed to read segment %d: %w", s.counter, err)
+		}
+	}
+
+	if n < s.aead.Overhead() {
+		return errStreamTruncated
+	}
+
+	nonce := streamNonce(s.nonce[:], s.counter, last)
+
+	plain, err := s.aead.Open(s.plain[:0], nonce, s.in[:n], s.header)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt segment %d: %w", s.counter, err)
+	}
+
+	s.plain = plain
+	s.pos = 0
+	s.counter++
+	s.done = last
+
+	return nil
+}
+
+// readStreamHeader reads the header written by EncryptStream. The raw
+// header is returned as well, as it authenticates every segment.
+func readStreamHeader(r *bufio.Reader) (Capsule, int, []byte, error) {
+	h := streamHeaderReader{r: r}
+
+	magic := h.byte()
+	version := h.byte()
+	algorithm := Algorithm(h.byte())
+
+	if h.err == nil {
+		switch {
+		case magic != binaryMagic:
+			return Capsule{}, 0, nil, errors.New("invalid stream: unknown format")
+		case version != formatVersion:
+			return Capsule{}, 0, nil, fmt.Errorf("invalid stream: unsupported format version %d", version)
+		case algorithm != AlgorithmAESGCMStream:
+			return Capsule{}, 0, nil, fmt.Errorf("invalid stream: unsupported algorithm %d", algorithm)
+		}
+	}
+
+	keyVersion := h.uvarint()
+	keyName := h.bytes()
+	wrappedKey := h.bytes()
+	segmentSize := h.uvarint()
+
+	if h.err != nil {
+		return Capsule{}, 0, nil, fmt.Errorf("invalid stream: %w", h.err)
+	}
+
+	if keyVersion > math.MaxInt32 {
+		return Capsule{}, 0, nil, fmt.Errorf("invalid stream: key version %d out of range", keyVersion)
+	}
+
+	if segmentSize == 0 || segmentSize > maxStreamSegmentSize {
+		return Capsule{}, 0, nil, fmt.Errorf("invalid stream: segment size %d out of range", segmentSize)
+	}
+
+	capsule := Capsule{
+		KeyName:    string(keyName),
+		KeyVersion: int(keyVersion),
+		WrappedKey: wrappedKey,
+	}
+
+	return capsule, int(segmentSize), h.raw, nil
+}
+
+// streamHeaderReader reads header fields from a stream, recording the raw
+// bytes and remembering the first error
+type streamHeaderReader struct {
+	r   *bufio.Reader
+	raw []byte
+	err error
+}
+
+func (h *streamHeaderReader) ReadByte() (byte, error) {
+	b, err := h.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	h.raw = append(h.raw, b)
+
+	return b, nil
+}
+
+func (h *streamHeaderReader) byte() byte {
+	if h.err != nil {
+		return 0
+	}
+
+	b, err := h.ReadByte()
+	if err != nil {
+		h.err = errStreamTruncated
+	}
+
+	return b
+}
+
+func (h *streamHeaderReader) uvarint() uint64 {
+	if h.err != nil {
+		return 0
+	}
+
+	v, err := binary.ReadUvarint(h)
+	if err != nil {
+		h.err = fmt.Errorf("malformed length: %w", err)
+	}
+
+	return v
+}
+
+func (h *streamHeaderReader) bytes() []byte {
+	n := h.uvarint()
+	if h.err != nil {
+		return nil
+	}
+
+	if n > maxStreamHeaderField {
+		h.err = fmt.Errorf("header field of %d bytes exceeds limit", n)
+		return nil
+	}
+
+	b := make([]byte, n)
+	if _, err := io.ReadFull(h.r, b); err != nil {
+		h.err = errStreamTruncated
+		return nil
+	}
+
+	h.raw = append(h.raw, b...)
+
+	return b
+}
@@ -0,0 +1,107 @@
+package crypt_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fruitsco/goji/component/crypt"
+)
+
+// segmentSize is the plaintext segment size of encrypted streams
+const segmentSize = 64 * 1024
+
+func encryptStream(t *testing.T, c *crypt.Crypto, plaintext []byte) []byte {
+	var buf bytes.Buffer
+
+	w, err := c.EncryptStream(context.Background(), &buf, "files")
+	require.NoError(t, err)
+
+	// write in odd sized chunks to cross segment boundaries
+	for chunk := range slices.Chunk(plaintext, 1000) {
+		_, err := w.Write(chunk)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, w.Close())
+
+	return buf.Bytes()
+}
+
+func decryptStream(c *crypt.Crypto, ciphertext []byte) ([]byte, error) {
+	r, err := c.DecryptStream(context.Background(), bytes.NewReader(ciphertext))
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(r)
+}
+
+func TestCrypto_Stream(t *testing.T) {
+	keys := memoryKeyProvider{}
+	keys.rotate("files")
+
+	for _, envelope := range []bool{false, true} {
+		c := newCrypto(t, keys, envelope)
+
+		for _, size := range []int{0, 1, segmentSize - 1, segmentSize, segmentSize + 1, 3*segmentSize + 5} {
+			plaintext := make([]byte, size)
+			_, err := rand.Read(plaintext)
+			require.NoError(t, err)
+
+			ciphertext := encryptStream(t, c, plaintext)
+
+			decrypted, err := decryptStream(c, ciphertext)
+			require.NoError(t, err, "size %d", size)
+			assert.True(t, bytes.Equal(plaintext, decrypted), "size %d", size)
+		}
+	}
+}
+
+func TestCrypto_StreamDetectsTampering(t *testing.T) {
+	keys := memoryKeyProvider{}
+	keys.rotate("files")
+
+	c := newCrypto(t, keys, false)
+
+	plaintext := bytes.Repeat([]byte("x"), 2*segmentSize)
+	ciphertext := encryptStream(t, c, plaintext)
+
+	// the stream header is not a capsule
+	_, err := crypt.ParseCapsule(ciphertext)
+	require.Error(t, err)
+
+	segment := segmentSize + 16
+
+	tests := map[string][]byte{
+		// the first segment alone is not marked as last
+		"truncated at segment boundary": ciphertext[:len(ciphertext)-segment],
+		"truncated segment":             ciphertext[:len(ciphertext)-1],
+		"appended data":                 append(bytes.Clone(ciphertext), 0),
+		"modified segment":              flipByte(ciphertext, len(ciphertext)-segment-1),
+		"modified header":               flipByte(ciphertext, 3),
+		"reordered segments": append(
+			bytes.Clone(ciphertext[:len(ciphertext)-2*segment]),
+			append(bytes.Clone(ciphertext[len(ciphertext)-segment:]), ciphertext[len(ciphertext)-2*segment:len(ciphertext)-segment]...)...,
+		),
+	}
+
+	for name, tampered := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := decryptStream(c, tampered)
+			require.Error(t, err)
+		})
+	}
+}
+
+func flipByte(data []byte, i int) []byte {
+	data = bytes.Clone(data)
+	data[i] ^= 0x01
+	return data
+}
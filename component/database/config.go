@@ -3,19 +3,52 @@ package database
 import "github.com/fruitsco/goji/conf"
 
 type Config struct {
-	Host           string `conf:"host"`
-	Port           int    `conf:"port"`
-	Name           string `conf:"name"`
-	Username       string `conf:"username"`
-	Password       string `conf:"password"`
-	Schema         string `conf:"schema"`
-	Ssl            bool   `conf:"ssl"`
-	SslRootCert    string `conf:"ssl_root_cert"`
-	SslClientKey   string `conf:"ssl_client_key"`
-	SslClientCert  string `conf:"ssl_client_cert"`
-	MaxIdleSeconds int    `conf:"max_idle_seconds"`
-	MaxConnections int    `conf:"max_connections"`
+	// Dialect is the SQL dialect, one of `postgres`, `mysql` and `sqlite`
+	Dialect Dialect `conf:"dialect"`
 
+	// Path is the database file of the `sqlite` dialect, an in-memory
+	// database is used if empty or `:memory:`
+	Path string `conf:"path"`
+
+	Host          string `conf:"host"`
+	Port          int    `conf:"port"`
+	Name          string `conf:"name"`
+	Username      string `conf:"username"`
+	Password      string `conf:"password"`
+	Schema        string `conf:"schema"`
+	Ssl           bool   `conf:"ssl"`
+	SslRootCert   string `conf:"ssl_root_cert"`
+	SslClientKey  string `conf:"ssl_client_key"`
+	SslClientCert string `conf:"ssl_client_cert"`
+
+	// MaxIdleSeconds closes connections idle for longer than the given seconds
+	MaxIdleSeconds int `conf:"max_idle_seconds"`
+
+	// MaxConnections limits the number of open connections, unlimited if zero
+	MaxConnections int `conf:"max_connections"`
+
+	// MaxIdleConnections limits the number of idle connections kept open,
+	// the database/sql default of 2 is used if zero
+	MaxIdleConnections int `conf:"max_idle_connections"`
+
+	// MaxLifetimeSeconds closes connections older than the given seconds
+	MaxLifetimeSeconds int `conf:"max_lifetime_seconds"`
+
+	// ApplicationName is reported to the server, e.g. in pg_stat_activity
+	ApplicationName string `conf:"application_name"`
+
+	// StatementCacheMode is the pgx query execution mode, one of
+	// `cache_statement`, `cache_describe`, `describe_exec`, `exec` and
+	// `simple_protocol`. Poolers like PgBouncer in transaction mode
+	// require `exec` or `simple_protocol`. Defaults to the pgx default.
+	StatementCacheMode string `conf:"statement_cache_mode"`
+
+	// StatementCacheCapacity is the number of statements cached per
+	// connection, the pgx default is used if zero
+	StatementCacheCapacity int `conf:"statement_cache_capacity"`
+
+	// Replica enables the single replica configured by the replica_* keys,
+	// which is added to Replicas as `default`
 	Replica              bool   `conf:"replica"`
 	ReplicaHost          string `conf:"replica_host"`
 	ReplicaPort          int    `conf:"replica_port"`
@@ -24,15 +57,55 @@ type Config struct {
 	ReplicaSslClientKey  string `conf:"replica_ssl_client_key"`
 	ReplicaSslClientCert string `conf:"replica_ssl_client_cert"`
 
+	// Replicas are the read replicas by name. They share the credentials
+	// and database name of the primary.
+	Replicas map[string]*ReplicaConfig `conf:"replicas"`
+
+	// ReplicaWriteFraction is the fraction of read queries sent
+	// to the primary instead of a replica, between 0 and 1
+	ReplicaWriteFraction float64 `conf:"replica_write_fraction"`
+
+	// ReplicaSelection selects the replica for a read query
+	ReplicaSelection ReplicaSelection `conf:"replica_selection"`
+
+	// ReplicaHealthCheckSeconds is the interval of replica health checks,
+	// unhealthy replicas receive no queries until they recover.
+	// Health checks are disabled if zero.
+	ReplicaHealthCheckSeconds int `conf:"replica_health_check_seconds"`
+
+	// ReplicaMaxLagSeconds ejects replicas whose replication lag exceeds
+	// the given seconds during health checks. Lag is not checked if zero.
+	ReplicaMaxLagSeconds int `conf:"replica_max_lag_seconds"`
+
+	// PingOnStart pings the primary when the app starts, failing the start
+	// if the database is unreachable
+	PingOnStart bool `conf:"ping_on_start"`
+
+	// PingTimeoutSeconds is the timeout of a ping attempt on start
+	PingTimeoutSeconds int `conf:"ping_timeout_seconds"`
+
+	// PingAttempts is the number of ping attempts on start
+	PingAttempts int `conf:"ping_attempts"`
+
 	MigrationPath        string `conf:"migration_path"`
 	MigrationDevDatabase string `conf:"migration_dev_database"`
 
+	// MigrationLockTimeoutSeconds is how long an instance waits for another
+	// instance applying migrations before giving up
+	MigrationLockTimeoutSeconds int `conf:"migration_lock_timeout_seconds"`
+
+	// MigrationBaselineVersion is the version the schema of an existing,
+	// unmigrated database corresponds to. Migrations up to and including
+	// the version are skipped by the first migration run.
+	MigrationBaselineVersion string `conf:"migration_baseline_version"`
+
 	CloudSQL          bool `conf:"cloudsql_enabled"`
 	CloudSQLIAM       bool `conf:"cloudsql_iam"`
 	CloudSQLPrivateIP bool `conf:"cloudsql_private_ip"`
 }
 
 var DefaultConfig = conf.DefaultConfig{
+	"db.dialect":                "postgres",
 	"db.host":                   "127.0.0.1",
 	"db.port":                   "5432",
 	"db.username":               "fruits",
@@ -43,4 +116,47 @@ var DefaultConfig = conf.DefaultConfig{
 	"db.migration_path":         "internal/db/migrations",
 	"db.replica_host":           "127.0.0.1",
 	"db.replica_port":           "5432",
+
+	"db.replica_write_fraction":       "0.33",
+	"db.replica_selection":            "round_robin",
+	"db.replica_health_check_seconds": "10",
+
+	"db.migration_lock_timeout_seconds": "60",
+
+	"db.ping_timeout_seconds": "3",
+	"db.ping_attempts":        "3",
+}
+
+// ReplicaSelection is the strategy selecting a replica for a read query
+type ReplicaSelection string
+
+const (
+	// RoundRobin sends read queries to the healthy replicas in turn
+	RoundRobin ReplicaSelection = "round_robin"
+
+	// LeastConnections sends read queries to the healthy replica
+	// with the fewest queries in flight
+	LeastConnections ReplicaSelection = "least_connections"
+)
+
+// ReplicaConfig is the configuration of a read replica
+type ReplicaConfig struct {
+	Host          string `conf:"host"`
+	Port          int    `conf:"port"`
+	Ssl           bool   `conf:"ssl"`
+	SslRootCert   string `conf:"ssl_root_cert"`
+	SslClientKey  string `conf:"ssl_client_key"`
+	SslClientCert string `conf:"ssl_client_cert"`
+
+	// MaxConnections overrides the pool setting of the primary if not zero
+	MaxConnections int `conf:"max_connections"`
+
+	// MaxIdleConnections overrides the pool setting of the primary if not zero
+	MaxIdleConnections int `conf:"max_idle_connections"`
+
+	// MaxIdleSeconds overrides the pool setting of the primary if not zero
+	MaxIdleSeconds int `conf:"max_idle_seconds"`
+
+	// MaxLifetimeSeconds overrides the pool setting of the primary if not zero
+	MaxLifetimeSeconds int `conf:"max_lifetime_seconds"`
 }
@@ -2,7 +2,9 @@ package database
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"sync/atomic"
 
 	"cloud.google.com/go/cloudsqlconn"
@@ -17,6 +19,12 @@ var driverCount int64
 type CleanupFn func() error
 
 type ConnectionParams struct {
+	// Dialect is the SQL dialect, Postgres if empty
+	Dialect Dialect
+
+	// Path is the database file of the SQLite dialect, in-memory if empty
+	Path string
+
 	Username      string
 	Password      string
 	Name          string
@@ -28,6 +36,17 @@ type ConnectionParams struct {
 	SslClientCert string
 	SslClientKey  string
 
+	// ApplicationName is reported to the server, optional
+	ApplicationName string
+
+	// StatementCacheMode is the pgx `default_query_exec_mode`, optional
+	StatementCacheMode string
+
+	// StatementCacheCapacity is the pgx `statement_cache_capacity`, optional
+	StatementCacheCapacity int
+
+	Pool PoolParams
+
 	CloudSQL CloudSQLConnectorParams
 }
 
@@ -59,7 +78,7 @@ func NewConnection(params ConnectionParams) (*singleConnection, error) {
 		return nil, err
 	}
 
-	driver := entsql.OpenDB(dialect.Postgres, db)
+	driver := entsql.OpenDB(params.Dialect.entDialect(), db)
 
 	return &singleConnection{
 		db:      db,
@@ -92,22 +111,33 @@ func (c *singleConnection) Close() error {
 }
 
 func createDB(params ConnectionParams) (*sql.DB, CleanupFn, error) {
+	create := createBasicDB
 	if params.CloudSQL.Enabled {
-		return createCloudSQLConnectorDB(params)
+		create = createCloudSQLConnectorDB
+	}
+
+	db, cleanup, err := create(params)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return createBasicDB(params)
+	params.Pool.apply(db)
+
+	return db, cleanup, nil
 }
 
 var dummyCleanup = func() error { return nil }
 
-// createBasicDB creates a basic DB connection using the pgx driver.
-// This method does not use any connector. SSL certificates must be
-// provided manually. IAM authentication is not supported.
+// createBasicDB creates a basic DB connection using the driver of the
+// dialect. This method does not use any connector. SSL certificates must
+// be provided manually. IAM authentication is not supported.
 func createBasicDB(params ConnectionParams) (*sql.DB, CleanupFn, error) {
-	dbURI := DsnForConnection(params)
+	dbURI, err := dsnForDialect(params)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	db, err := sql.Open("pgx", dbURI)
+	db, err := sql.Open(params.Dialect.driverName(), dbURI)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -118,7 +148,10 @@ func createBasicDB(params ConnectionParams) (*sql.DB, CleanupFn, error) {
 // MARK: - Multi Connection
 
 type multiConnection struct {
-	r, w Connection
+	w        Connection
+	replicas []Connection
+	driver   *multiDriver
+	health   *replicaHealthChecker
 }
 
 var _ Connection = (*multiConnection)(nil)
@@ -129,27 +162,21 @@ func (c *multiConnection) DB() *sql.DB {
 }
 
 func (c *multiConnection) Driver() dialect.Driver {
-	return &multiDriver{
-		r:                  c.r.Driver(),
-		w:                  c.w.Driver(),
-		queryTotal:         3,
-		queryWriteFraction: 1,
-	}
+	return c.driver
 }
 
 func (c *multiConnection) Close() error {
-	rerr := c.r.Close()
-	werr := c.w.Close()
-
-	if rerr != nil {
-		return rerr
+	if c.health != nil {
+		c.health.Stop()
 	}
 
-	if werr != nil {
-		return werr
+	errs := []error{c.w.Close()}
+
+	for _, r := range c.replicas {
+		errs = append(errs, r.Close())
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
 
 // MARK: - Create Connections
@@ -194,6 +221,7 @@ func createCloudSQLConnectorDB(params ConnectionParams) (*sql.DB, CleanupFn, err
 	return db, close, nil
 }
 
+// DsnForConnection returns the pgx connection string of the Postgres dialect
 func DsnForConnection(params ConnectionParams) string {
 	dbURI := fmt.Sprintf(
 		"user=%s dbname=%s host=%s port=%d search_path=%s",
@@ -214,5 +242,22 @@ func DsnForConnection(params ConnectionParams) string {
 		dbURI += " sslmode=disable"
 	}
 
+	if params.ApplicationName != "" {
+		dbURI += fmt.Sprintf(" application_name=%s", quoteDsnValue(params.ApplicationName))
+	}
+
+	if params.StatementCacheMode != "" {
+		dbURI += fmt.Sprintf(" default_query_exec_mode=%s", params.StatementCacheMode)
+	}
+
+	if params.StatementCacheCapacity > 0 {
+		dbURI += fmt.Sprintf(" statement_cache_capacity=%d", params.StatementCacheCapacity)
+	}
+
 	return dbURI
 }
+
+// quoteDsnValue quotes a value of a keyword/value connection string
+func quoteDsnValue(v string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+}
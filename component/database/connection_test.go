@@ -0,0 +1,49 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDsnForConnection(t *testing.T) {
+	dsn := DsnForConnection(ConnectionParams{
+		Username:               "fruits",
+		Password:               "secret",
+		Name:                   "roma",
+		Host:                   "localhost",
+		Port:                   5432,
+		Schema:                 "public",
+		ApplicationName:        "billing worker's",
+		StatementCacheMode:     "exec",
+		StatementCacheCapacity: 64,
+	})
+
+	assert.Contains(t, dsn, `application_name='billing worker\'s'`)
+	assert.Contains(t, dsn, "default_query_exec_mode=exec")
+	assert.Contains(t, dsn, "statement_cache_capacity=64")
+}
+
+func TestReplicaConfig_PoolParams(t *testing.T) {
+	primary := (&Config{
+		MaxConnections:     20,
+		MaxIdleConnections: 5,
+		MaxIdleSeconds:     60,
+	}).poolParams()
+
+	p := (&ReplicaConfig{MaxConnections: 50, MaxLifetimeSeconds: 300}).poolParams(primary)
+
+	assert.Equal(t, PoolParams{
+		MaxOpenConnections: 50,
+		MaxIdleConnections: 5,
+		MaxLifetime:        5 * time.Minute,
+		MaxIdleTime:        time.Minute,
+	}, p)
+}
+
+func TestValidateStatementCacheMode(t *testing.T) {
+	assert.NoError(t, validateStatementCacheMode(""))
+	assert.NoError(t, validateStatementCacheMode("simple_protocol"))
+	assert.Error(t, validateStatementCacheMode("none"))
+}
@@ -0,0 +1,64 @@
+package database
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+)
+
+type contextKey int
+
+const (
+	primaryKey contextKey = iota
+	stickyPrimaryKey
+	txKey
+)
+
+// WithPrimary returns a context whose queries are all sent to the primary,
+// e.g. for critical reads which must not see stale rows of a replica.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey, true)
+}
+
+// WithStickyPrimary returns a context whose queries are sent to the primary
+// once a write has been executed with it or a context derived from it, so
+// that a request reads its own writes. Reads before the first write are
+// still offloaded to the replicas.
+func WithStickyPrimary(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(stickyPrimaryKey).(*stickyPrimary); ok {
+		return ctx
+	}
+
+	return context.WithValue(ctx, stickyPrimaryKey, &stickyPrimary{})
+}
+
+// StickyPrimaryMiddleware scopes WithStickyPrimary to each request, so that
+// requests read their own writes while others keep using the replicas
+func StickyPrimaryMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(WithStickyPrimary(r.Context())))
+	})
+}
+
+// UsesPrimary reports whether queries with the context are sent to the primary
+func UsesPrimary(ctx context.Context) bool {
+	if primary, _ := ctx.Value(primaryKey).(bool); primary {
+		return true
+	}
+
+	sticky, ok := ctx.Value(stickyPrimaryKey).(*stickyPrimary)
+
+	return ok && sticky.written.Load()
+}
+
+// stickyPrimary records whether a write was executed within a context
+type stickyPrimary struct {
+	written atomic.Bool
+}
+
+// markWritten makes a sticky context use the primary for subsequent reads
+func markWritten(ctx context.Context) {
+	if sticky, ok := ctx.Value(stickyPrimaryKey).(*stickyPrimary); ok {
+		sticky.written.Store(true)
+	}
+}
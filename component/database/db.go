@@ -4,19 +4,27 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"maps"
+	"slices"
+	"time"
 
 	"entgo.io/ent/dialect"
 	"go.uber.org/fx"
+	"go.uber.org/zap"
 )
 
 type EntDB struct {
 	connection Connection
+	driver     *txDriver
 }
 
 type EntDBParams struct {
 	fx.In
 
 	Config *Config
+
+	// Log is the logger for replica health checks
+	Log *zap.Logger `optional:"true"`
 }
 
 func NewLifecycleDB(lc fx.Lifecycle, params EntDBParams) (*EntDB, error) {
@@ -26,6 +34,24 @@ func NewLifecycleDB(lc fx.Lifecycle, params EntDBParams) (*EntDB, error) {
 	}
 
 	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			if !params.Config.PingOnStart {
+				return nil
+			}
+
+			log := params.Log
+			if log == nil {
+				log = zap.NewNop()
+			}
+
+			return ping(
+				ctx,
+				db.connection.DB(),
+				params.Config.PingAttempts,
+				time.Duration(params.Config.PingTimeoutSeconds)*time.Second,
+				log.Named("database"),
+			)
+		},
 		OnStop: func(_ context.Context) error {
 			return db.Close()
 		},
@@ -39,6 +65,14 @@ func NewDB(params EntDBParams) (*EntDB, error) {
 		return nil, fmt.Errorf("no db config provided")
 	}
 
+	if err := params.Config.validateDialect(); err != nil {
+		return nil, err
+	}
+
+	if err := validateStatementCacheMode(params.Config.StatementCacheMode); err != nil {
+		return nil, err
+	}
+
 	cloudSql := CloudSQLConnectorParams{
 		Enabled:   params.Config.CloudSQL,
 		IAM:       params.Config.CloudSQLIAM,
@@ -46,6 +80,8 @@ func NewDB(params EntDBParams) (*EntDB, error) {
 	}
 
 	mainConnection, err := NewConnection(ConnectionParams{
+		Dialect:       params.Config.Dialect,
+		Path:          params.Config.Path,
 		Username:      params.Config.Username,
 		Password:      params.Config.Password,
 		Name:          params.Config.Name,
@@ -56,49 +92,164 @@ func NewDB(params EntDBParams) (*EntDB, error) {
 		SslRootCert:   params.Config.SslRootCert,
 		SslClientCert: params.Config.SslClientCert,
 		SslClientKey:  params.Config.SslClientKey,
+		Pool:          params.Config.poolParams(),
 		CloudSQL:      cloudSql,
+
+		ApplicationName:        params.Config.ApplicationName,
+		StatementCacheMode:     params.Config.StatementCacheMode,
+		StatementCacheCapacity: params.Config.StatementCacheCapacity,
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	var connection Connection = mainConnection
+	replicaConfigs := params.Config.replicaConfigs()
+
+	if len(replicaConfigs) == 0 {
+		return newEntDB(mainConnection), nil
+	}
+
+	connection, err := newMultiConnection(params, mainConnection, replicaConfigs, cloudSql)
+	if err != nil {
+		mainConnection.Close()
+		return nil, err
+	}
+
+	return newEntDB(connection), nil
+}
+
+func newEntDB(connection Connection) *EntDB {
+	db := &EntDB{
+		connection: connection,
+	}
+
+	db.driver = &txDriver{
+		Driver: connection.Driver(),
+		db:     db,
+	}
+
+	return db
+}
+
+func newMultiConnection(
+	params EntDBParams,
+	mainConnection Connection,
+	replicaConfigs map[string]*ReplicaConfig,
+	cloudSql CloudSQLConnectorParams,
+) (*multiConnection, error) {
+	connection := &multiConnection{
+		w: mainConnection,
+	}
+
+	var replicas []*replica
+
+	for _, name := range slices.Sorted(maps.Keys(replicaConfigs)) {
+		cfg := replicaConfigs[name]
 
-	if params.Config.Replica {
 		replicaConnection, err := NewConnection(ConnectionParams{
+			Dialect:       params.Config.Dialect,
 			Username:      params.Config.Username,
 			Password:      params.Config.Password,
 			Name:          params.Config.Name,
-			Host:          params.Config.ReplicaHost,
-			Port:          params.Config.ReplicaPort,
+			Host:          cfg.Host,
+			Port:          cfg.Port,
 			Schema:        params.Config.Schema,
-			Ssl:           params.Config.ReplicaSsl,
-			SslRootCert:   params.Config.ReplicaSslRootCert,
-			SslClientCert: params.Config.ReplicaSslClientCert,
-			SslClientKey:  params.Config.ReplicaSslClientKey,
+			Ssl:           cfg.Ssl,
+			SslRootCert:   cfg.SslRootCert,
+			SslClientCert: cfg.SslClientCert,
+			SslClientKey:  cfg.SslClientKey,
+			Pool:          cfg.poolParams(params.Config.poolParams()),
 			CloudSQL:      cloudSql,
+
+			ApplicationName:        params.Config.ApplicationName,
+			StatementCacheMode:     params.Config.StatementCacheMode,
+			StatementCacheCapacity: params.Config.StatementCacheCapacity,
 		})
 		if err != nil {
-			return nil, err
+			for _, r := range connection.replicas {
+				r.Close()
+			}
+			return nil, fmt.Errorf("replica %s: %w", name, err)
 		}
 
-		connection = &multiConnection{
-			w: mainConnection,
-			r: replicaConnection,
+		connection.replicas = append(connection.replicas, replicaConnection)
+		replicas = append(replicas, newReplica(name, replicaConnection))
+	}
+
+	connection.driver = &multiDriver{
+		w:             mainConnection.Driver(),
+		replicas:      replicas,
+		selection:     params.Config.ReplicaSelection,
+		writeFraction: params.Config.ReplicaWriteFraction,
+	}
+
+	if params.Config.ReplicaHealthCheckSeconds > 0 {
+		log := params.Log
+		if log == nil {
+			log = zap.NewNop()
 		}
+
+		connection.health = newReplicaHealthChecker(
+			replicas,
+			time.Duration(params.Config.ReplicaHealthCheckSeconds)*time.Second,
+			time.Duration(params.Config.ReplicaMaxLagSeconds)*time.Second,
+			log.Named("database"),
+		)
+		connection.health.Start()
 	}
 
-	return &EntDB{
-		connection: connection,
-	}, nil
+	return connection, nil
+}
+
+// replicaConfigs returns the configured replicas, including the legacy replica
+func (c *Config) replicaConfigs() map[string]*ReplicaConfig {
+	replicas := make(map[string]*ReplicaConfig, len(c.Replicas)+1)
+
+	for name, replica := range c.Replicas {
+		if replica != nil {
+			replicas[name] = replica
+		}
+	}
+
+	if _, ok := replicas["default"]; c.Replica && !ok {
+		replicas["default"] = &ReplicaConfig{
+			Host:          c.ReplicaHost,
+			Port:          c.ReplicaPort,
+			Ssl:           c.ReplicaSsl,
+			SslRootCert:   c.ReplicaSslRootCert,
+			SslClientKey:  c.ReplicaSslClientKey,
+			SslClientCert: c.ReplicaSslClientCert,
+		}
+	}
+
+	return replicas
 }
 
 func (db *EntDB) DB() *sql.DB {
 	return db.connection.DB()
 }
 
+// Driver returns the driver for ent clients. Queries use the transaction
+// of WithTx if it is in their context.
 func (db *EntDB) Driver() dialect.Driver {
-	return db.connection.Driver()
+	return db.driver
+}
+
+// Stats returns the connection pool statistics of the primary and replicas
+func (db *EntDB) Stats() PoolStats {
+	stats := PoolStats{
+		Primary: db.connection.DB().Stats(),
+	}
+
+	if multi, ok := db.connection.(*multiConnection); ok {
+		stats.Replicas = make(map[string]sql.DBStats, len(multi.driver.replicas))
+
+		for _, r := range multi.driver.replicas {
+			stats.Replicas[r.name] = r.db.Stats()
+		}
+	}
+
+	return stats
 }
 
 func (db *EntDB) Close() error {
@@ -0,0 +1,230 @@
+package database
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"entgo.io/ent/dialect"
+	"github.com/go-sql-driver/mysql"
+
+	_ "modernc.org/sqlite"
+)
+
+// ErrUnsupportedDialect is returned by operations the dialect does not support
+var ErrUnsupportedDialect = errors.New("operation is not supported by the dialect")
+
+// Dialect is the SQL dialect of the database
+type Dialect string
+
+const (
+	// Postgres connects to PostgreSQL using pgx, the default dialect
+	Postgres Dialect = "postgres"
+
+	// MySQL connects to MySQL using go-sql-driver/mysql
+	MySQL Dialect = "mysql"
+
+	// SQLite opens a file or in-memory database using the pure Go
+	// modernc.org/sqlite driver, e.g. for tests and lightweight services
+	SQLite Dialect = "sqlite"
+)
+
+// sqliteMemoryPath is the path of an in-memory SQLite database
+const sqliteMemoryPath = ":memory:"
+
+// sqliteMemoryCount makes the name of each in-memory SQLite database unique
+var sqliteMemoryCount int64
+
+func (d Dialect) validate() error {
+	switch d {
+	case "", Postgres, MySQL, SQLite:
+		return nil
+	}
+
+	return fmt.Errorf("invalid dialect %q, expected %s, %s or %s", d, Postgres, MySQL, SQLite)
+}
+
+// orDefault returns the dialect, Postgres if it is not set
+func (d Dialect) orDefault() Dialect {
+	if d == "" {
+		return Postgres
+	}
+
+	return d
+}
+
+// entDialect is the name of the dialect in ent
+func (d Dialect) entDialect() string {
+	switch d.orDefault() {
+	case MySQL:
+		return dialect.MySQL
+	case SQLite:
+		return dialect.SQLite
+	default:
+		return dialect.Postgres
+	}
+}
+
+// driverName is the name of the database/sql driver of the dialect
+func (d Dialect) driverName() string {
+	switch d.orDefault() {
+	case MySQL:
+		return "mysql"
+	case SQLite:
+		return "sqlite"
+	default:
+		return "pgx"
+	}
+}
+
+// validateDialect checks that the features configured are supported by the dialect
+func (c *Config) validateDialect() error {
+	if err := c.Dialect.validate(); err != nil {
+		return err
+	}
+
+	d := c.Dialect.orDefault()
+	if d == Postgres {
+		return nil
+	}
+
+	if c.CloudSQL {
+		return fmt.Errorf("%w: cloud sql connector with %s", ErrUnsupportedDialect, d)
+	}
+
+	if c.ReplicaMaxLagSeconds > 0 {
+		return fmt.Errorf("%w: replication lag checks with %s", ErrUnsupportedDialect, d)
+	}
+
+	if d == SQLite && len(c.replicaConfigs()) > 0 {
+		return fmt.Errorf("%w: replicas with %s", ErrUnsupportedDialect, d)
+	}
+
+	return nil
+}
+
+// dsnForDialect returns the connection string for the dialect of the params
+func dsnForDialect(params ConnectionParams) (string, error) {
+	switch params.Dialect.orDefault() {
+	case MySQL:
+		return MySQLDsnForConnection(params)
+	case SQLite:
+		return SQLiteDsnForConnection(params), nil
+	default:
+		return DsnForConnection(params), nil
+	}
+}
+
+// MySQLDsnForConnection returns the go-sql-driver/mysql connection string.
+// Client certificates are registered as TLS config of the driver.
+func MySQLDsnForConnection(params ConnectionParams) (string, error) {
+	cfg := mysql.NewConfig()
+	cfg.User = params.Username
+	cfg.Passwd = params.Password
+	cfg.Net = "tcp"
+	cfg.Addr = net.JoinHostPort(params.Host, strconv.Itoa(params.Port))
+	cfg.DBName = params.Name
+	cfg.ParseTime = true
+
+	if params.ApplicationName != "" {
+		cfg.ConnectionAttributes = "program_name:" + params.ApplicationName
+	}
+
+	if params.Ssl {
+		cfg.TLSConfig = "true"
+
+		if params.SslRootCert != "" || params.SslClientCert != "" {
+			name, err := registerMySQLTLSConfig(params)
+			if err != nil {
+				return "", err
+			}
+			cfg.TLSConfig = name
+		}
+	}
+
+	return cfg.FormatDSN(), nil
+}
+
+func registerMySQLTLSConfig(params ConnectionParams) (string, error) {
+	tlsConfig := &tls.Config{
+		ServerName: params.Host,
+	}
+
+	if params.SslRootCert != "" {
+		pem, err := os.ReadFile(params.SslRootCert)
+		if err != nil {
+			return "", fmt.Errorf("failed reading ssl root cert: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return "", fmt.Errorf("no certificates found in ssl root cert %s", params.SslRootCert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if params.SslClientCert != "" {
+		cert, err := tls.LoadX509KeyPair(params.SslClientCert, params.SslClientKey)
+		if err != nil {
+			return "", fmt.Errorf("failed loading ssl client cert: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	name := "goji-" + net.JoinHostPort(params.Host, strconv.Itoa(params.Port))
+
+	if err := mysql.RegisterTLSConfig(name, tlsConfig); err != nil {
+		return "", fmt.Errorf("failed registering tls config: %w", err)
+	}
+
+	return name, nil
+}
+
+// SQLiteDsnForConnection returns the modernc.org/sqlite connection string
+// of the database file at the path of the params. Foreign keys, which ent
+// requires, are enabled. Each call for an empty path or `:memory:` returns
+// a new in-memory database, shared by the connections of a pool.
+func SQLiteDsnForConnection(params ConnectionParams) string {
+	query := url.Values{
+		"_pragma": {"foreign_keys(1)", "busy_timeout(5000)"},
+	}
+
+	path := params.Path
+
+	if path == "" || path == sqliteMemoryPath {
+		path = fmt.Sprintf("goji-%d", atomic.AddInt64(&sqliteMemoryCount, 1))
+		query.Set("mode", "memory")
+		query.Set("cache", "shared")
+	}
+
+	return "file:" + path + "?" + query.Encode()
+}
+
+var registerSQLite3Once sync.Once
+
+// registerSQLite3 registers the pure Go SQLite driver as `sqlite3`, which
+// Atlas uses for SQLite, unless another driver is registered already
+func registerSQLite3() {
+	registerSQLite3Once.Do(func() {
+		if slices.Contains(sql.Drivers(), "sqlite3") {
+			return
+		}
+
+		db, err := sql.Open(SQLite.driverName(), "")
+		if err != nil {
+			return
+		}
+		defer db.Close()
+
+		sql.Register("sqlite3", db.Driver())
+	})
+}
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/fruitsco/goji/component/health"
+)
+
+// pingRetryDelay is the delay between ping attempts on start
+const pingRetryDelay = time.Second
+
+var _ = health.Checker(&EntDB{})
+
+// HealthCheck pings the primary and each replica concurrently. Replicas
+// are optional, queries fall back to the primary if they are down.
+// Replicas ejected by the replica health check are reported as down.
+func (db *EntDB) HealthCheck(ctx context.Context) []health.Check {
+	var replicas []*replica
+	if multi, ok := db.connection.(*multiConnection); ok {
+		replicas = multi.driver.replicas
+	}
+
+	checks := make([]health.Check, 1+len(replicas))
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		checks[0] = health.Run(ctx, "database.primary", db.connection.DB().PingContext)
+	}()
+
+	for i, r := range replicas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			check := health.Run(ctx, "database.replica."+r.name, func(ctx context.Context) error {
+				if err := r.db.PingContext(ctx); err != nil {
+					return err
+				}
+
+				if !r.healthy.Load() {
+					return errors.New("ejected by replica health check")
+				}
+
+				return nil
+			})
+			check.Optional = true
+
+			checks[1+i] = check
+		}()
+	}
+
+	wg.Wait()
+
+	return checks
+}
+
+// ping pings the primary, retrying failed attempts
+func ping(ctx context.Context, db *sql.DB, attempts int, timeout time.Duration, log *zap.Logger) error {
+	attempts = max(attempts, 1)
+
+	var err error
+
+	for attempt := 1; attempt <= attempts; attempt++ {
+		pingCtx, cancel := ctx, context.CancelFunc(func() {})
+		if timeout > 0 {
+			pingCtx, cancel = context.WithTimeout(ctx, timeout)
+		}
+
+		err = db.PingContext(pingCtx)
+		cancel()
+
+		if err == nil {
+			return nil
+		}
+
+		log.Warn("database ping failed", zap.Int("attempt", attempt), zap.Error(err))
+
+		if attempt < attempts {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("failed to ping database: %w", ctx.Err())
+			case <-time.After(pingRetryDelay):
+			}
+		}
+	}
+
+	return fmt.Errorf("failed to ping database after %d attempts: %w", attempts, err)
+}
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/fruitsco/goji/component/health"
+)
+
+func TestEntDB_HealthCheck(t *testing.T) {
+	primary, err := sql.Open("pgx", "host=127.0.0.1 port=1 connect_timeout=1")
+	require.NoError(t, err)
+	defer primary.Close()
+
+	d, _, _ := newTestMultiDriver(RoundRobin, 0, 2)
+	d.replicas[0].name, d.replicas[0].db = "a", primary
+	d.replicas[1].name, d.replicas[1].db = "b", primary
+
+	db := newEntDB(&multiConnection{
+		w:      &singleConnection{db: primary},
+		driver: d,
+	})
+
+	checks := db.HealthCheck(context.Background())
+	require.Len(t, checks, 3)
+
+	assert.Equal(t, "database.primary", checks[0].Name)
+	assert.Equal(t, health.StatusDown, checks[0].Status)
+	assert.False(t, checks[0].Optional)
+
+	assert.Equal(t, "database.replica.a", checks[1].Name)
+	assert.Equal(t, "database.replica.b", checks[2].Name)
+	assert.True(t, checks[1].Optional)
+}
+
+func TestPing(t *testing.T) {
+	db, err := sql.Open("pgx", "host=127.0.0.1 port=1 connect_timeout=1")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = ping(context.Background(), db, 1, time.Second, zap.NewNop())
+	require.ErrorContains(t, err, "after 1 attempts")
+}
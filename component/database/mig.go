@@ -2,26 +2,52 @@ package database
 
 import (
 	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
 	"fmt"
+	"net"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
 
 	"go.uber.org/fx"
+	"go.uber.org/zap"
 
 	atlas "ariga.io/atlas/sql/migrate"
-	"entgo.io/ent/dialect"
+	"ariga.io/atlas/sql/mysql"
+	"ariga.io/atlas/sql/postgres"
+	sqlschema "ariga.io/atlas/sql/schema"
+	"ariga.io/atlas/sql/sqlclient"
+	"ariga.io/atlas/sql/sqlite"
 	"entgo.io/ent/dialect/sql/schema"
 
-	_ "ariga.io/atlas/sql/postgres"
 	_ "github.com/lib/pq"
 )
 
+// migrationLockName is the name of the advisory lock held while migrating,
+// shared with the Atlas CLI
+const migrationLockName = "atlas_migrate_execute"
+
 type MigratorParams struct {
 	fx.In
 
 	Config *Config
+
+	// DB is the database migrations are applied to, required by all
+	// operations except Diff and Lint
+	DB *EntDB `optional:"true"`
+
+	// Log is the logger for applied migrations
+	Log *zap.Logger `optional:"true"`
 }
 
 type Mig struct {
 	config *Config
+	db     *EntDB
+	log    *zap.Logger
 }
 
 func NewMig(params MigratorParams) (*Mig, error) {
@@ -29,14 +55,33 @@ func NewMig(params MigratorParams) (*Mig, error) {
 		return nil, fmt.Errorf("no db config provided")
 	}
 
+	log := params.Log
+	if log == nil {
+		log = zap.NewNop()
+	}
+
 	return &Mig{
 		config: params.Config,
+		db:     params.DB,
+		log:    log.Named("database"),
 	}, nil
 }
 
+// MigrationStatus is the migration state of the database
+type MigrationStatus struct {
+	// Current is the version of the latest revision, empty if no
+	// migration was applied yet
+	Current string
+
+	// Applied are the revisions applied to the database, oldest first
+	Applied []*atlas.Revision
+
+	// Pending are the migration files not applied yet, oldest first
+	Pending []atlas.File
+}
+
 type Differ = func(ctx context.Context, url, name string, opts ...schema.MigrateOption) error
 
-// TODO: move into migrator struct
 func (m *Mig) Diff(ctx context.Context, name string, differ Differ) error {
 	dir, err := atlas.NewLocalDir(m.config.MigrationPath)
 	if err != nil {
@@ -46,19 +91,522 @@ func (m *Mig) Diff(ctx context.Context, name string, differ Differ) error {
 	opts := []schema.MigrateOption{
 		schema.WithDir(dir),
 		schema.WithMigrationMode(schema.ModeReplay),
-		schema.WithDialect(dialect.Postgres),
+		schema.WithDialect(m.config.Dialect.entDialect()),
 		schema.WithFormatter(atlas.DefaultFormatter),
 	}
 
-	dsn := fmt.Sprintf(
-		"postgres://%s:%s@%s:%d/%s?search_path=%s&sslmode=disable",
-		m.config.Username, m.config.Password, m.config.Host, m.config.Port, m.config.MigrationDevDatabase, m.config.Schema,
-	)
+	// atlas opens SQLite databases using the `sqlite3` driver
+	if m.config.Dialect.orDefault() == SQLite {
+		registerSQLite3()
+	}
 
-	err = differ(ctx, dsn, name, opts...)
+	err = differ(ctx, m.devURL(), name, opts...)
 	if err != nil {
 		return fmt.Errorf("failed generating migration: %v", err)
 	}
 
 	return nil
 }
+
+// Apply applies the pending migrations to the database. Each migration runs
+// in its own transaction, unless the file has the `atlas:txmode none`
+// directive. An advisory lock ensures that only one instance migrates at a
+// time, others wait for it to finish and find no pending migrations. SQLite
+// has no advisory locks, a lock file is used for database files instead and
+// other instances fail while it is held.
+func (m *Mig) Apply(ctx context.Context) error {
+	db, dir, err := m.open()
+	if err != nil {
+		return err
+	}
+
+	drv, err := m.driver(db)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := m.lock(ctx, drv)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	revs := m.revisions(db)
+	if err := revs.init(ctx); err != nil {
+		return err
+	}
+
+	ex, err := m.executor(drv, dir, revs)
+	if err != nil {
+		return err
+	}
+
+	pending, err := ex.Pending(ctx)
+	if errors.Is(err, atlas.ErrNoPendingFiles) {
+		m.log.Info("database schema is up to date")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed reading pending migrations: %w", err)
+	}
+
+	for _, file := range pending {
+		if err := m.applyFile(ctx, db, dir, file); err != nil {
+			return err
+		}
+	}
+
+	m.log.Info("applied migrations", zap.Int("count", len(pending)))
+
+	return nil
+}
+
+// Status returns the applied and pending migrations of the database
+func (m *Mig) Status(ctx context.Context) (*MigrationStatus, error) {
+	db, dir, err := m.open()
+	if err != nil {
+		return nil, err
+	}
+
+	revs := m.revisions(db)
+
+	applied, err := revs.ReadRevisions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &MigrationStatus{
+		Applied: applied,
+	}
+
+	if len(applied) > 0 {
+		status.Current = applied[len(applied)-1].Version
+	}
+
+	// the executor writes the baseline revision when it is asked for pending
+	// files on an unmigrated database, so they are collected manually
+	if len(applied) == 0 {
+		status.Pending, err = m.initialFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		return status, nil
+	}
+
+	drv, err := m.driver(db)
+	if err != nil {
+		return nil, err
+	}
+
+	ex, err := m.executor(drv, dir, revs)
+	if err != nil {
+		return nil, err
+	}
+
+	status.Pending, err = ex.Pending(ctx)
+	if err != nil && !errors.Is(err, atlas.ErrNoPendingFiles) {
+		return nil, fmt.Errorf("failed reading pending migrations: %w", err)
+	}
+
+	return status, nil
+}
+
+// RollbackTo reverts the database schema to the given applied version. The
+// migration directory is replayed up to the version on the dev database, the
+// difference to the current schema is applied in a transaction and the
+// revisions of the reverted migrations are removed. Dropped tables and
+// columns lose their data, use with care.
+func (m *Mig) RollbackTo(ctx context.Context, version string) error {
+	db, dir, err := m.open()
+	if err != nil {
+		return err
+	}
+
+	drv, err := m.driver(db)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := m.lock(ctx, drv)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	applied, err := m.revisions(db).ReadRevisions(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !slices.ContainsFunc(applied, func(r *atlas.Revision) bool { return r.Version == version }) {
+		return fmt.Errorf("version %s is not applied", version)
+	}
+
+	var reverted []*atlas.Revision
+	for _, r := range applied {
+		if r.Version > version {
+			reverted = append(reverted, r)
+		}
+	}
+
+	if len(reverted) == 0 {
+		m.log.Info("database schema is already at version", zap.String("version", version))
+		return nil
+	}
+
+	desired, err := m.replay(ctx, dir, version)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	txDrv, err := m.driver(tx)
+	if err != nil {
+		return err
+	}
+
+	current, err := txDrv.InspectSchema(ctx, m.schema(), nil)
+	if err != nil {
+		return fmt.Errorf("failed inspecting schema: %w", err)
+	}
+
+	// SQLite keeps the revisions table next to the migrated tables
+	current.Tables = slices.DeleteFunc(current.Tables, m.isRevisionTable)
+
+	desired.Name = current.Name
+
+	changes, err := txDrv.SchemaDiff(current, desired)
+	if err != nil {
+		return fmt.Errorf("failed computing rollback changes: %w", err)
+	}
+
+	if err := txDrv.ApplyChanges(ctx, changes); err != nil {
+		return fmt.Errorf("failed applying rollback changes: %w", err)
+	}
+
+	txRevs := m.revisions(tx)
+	for _, r := range reverted {
+		if err := txRevs.DeleteRevision(ctx, r.Version); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed committing rollback: %w", err)
+	}
+
+	m.log.Info("rolled back migrations",
+		zap.String("version", version),
+		zap.Int("count", len(reverted)),
+	)
+
+	return nil
+}
+
+func (m *Mig) applyFile(ctx context.Context, db *sql.DB, dir atlas.Dir, file atlas.File) error {
+	if f, ok := file.(*atlas.LocalFile); ok && slices.Contains(f.Directive("txmode"), "none") {
+		drv, err := m.driver(db)
+		if err != nil {
+			return err
+		}
+
+		ex, err := m.executor(drv, dir, m.revisions(db))
+		if err != nil {
+			return err
+		}
+
+		if err := ex.Execute(ctx, file); err != nil {
+			return fmt.Errorf("failed applying migration %s: %w", file.Name(), err)
+		}
+
+		return nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	drv, err := m.driver(tx)
+	if err != nil {
+		return err
+	}
+
+	ex, err := m.executor(drv, dir, m.revisions(tx))
+	if err != nil {
+		return err
+	}
+
+	if err := ex.Execute(ctx, file); err != nil {
+		return fmt.Errorf("failed applying migration %s: %w", file.Name(), err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed committing migration %s: %w", file.Name(), err)
+	}
+
+	return nil
+}
+
+// replay returns the schema after applying the migrations up to the
+// given version on the dev database
+func (m *Mig) replay(ctx context.Context, dir atlas.Dir, version string) (*sqlschema.Schema, error) {
+	dev, err := m.openDev(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer dev.Close()
+
+	ex, err := atlas.NewExecutor(dev.Driver, dir, atlas.NopRevisionReadWriter{})
+	if err != nil {
+		return nil, err
+	}
+
+	realm, err := ex.Replay(ctx, atlas.SchemaConn(dev.Driver, "", nil), atlas.ReplayToVersion(version))
+	if err != nil {
+		return nil, fmt.Errorf("failed replaying migrations to version %s: %w", version, err)
+	}
+
+	if len(realm.Schemas) != 1 {
+		return nil, fmt.Errorf("replaying migrations resulted in %d schemas", len(realm.Schemas))
+	}
+
+	return realm.Schemas[0], nil
+}
+
+// initialFiles returns the files applied by the first migration run
+func (m *Mig) initialFiles(dir atlas.Dir) ([]atlas.File, error) {
+	if err := atlas.Validate(dir); err != nil {
+		return nil, fmt.Errorf("invalid migration directory: %w", err)
+	}
+
+	files, err := atlas.FilesFromLastCheckpoint(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading migration files: %w", err)
+	}
+
+	if baseline := m.config.MigrationBaselineVersion; baseline != "" {
+		idx := atlas.FilesLastIndex(files, func(f atlas.File) bool { return f.Version() == baseline })
+		if idx == -1 {
+			return nil, fmt.Errorf("baseline version %s not found", baseline)
+		}
+		files = files[idx+1:]
+	}
+
+	return files, nil
+}
+
+func (m *Mig) open() (*sql.DB, atlas.Dir, error) {
+	if m.db == nil {
+		return nil, nil, fmt.Errorf("no db connection provided")
+	}
+
+	dir, err := atlas.NewLocalDir(m.config.MigrationPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed creating atlas migration directory: %w", err)
+	}
+
+	// migrations are always applied to the primary
+	return m.db.DB(), dir, nil
+}
+
+// openDev connects to the dev database used to replay migrations
+func (m *Mig) openDev(ctx context.Context) (*sqlclient.Client, error) {
+	// atlas opens SQLite databases using the `sqlite3` driver
+	if m.config.Dialect.orDefault() == SQLite {
+		registerSQLite3()
+	}
+
+	dev, err := sqlclient.Open(ctx, m.devURL())
+	if err != nil {
+		return nil, fmt.Errorf("failed connecting to dev database: %w", err)
+	}
+
+	return dev, nil
+}
+
+func (m *Mig) driver(conn sqlschema.ExecQuerier) (atlas.Driver, error) {
+	var (
+		drv atlas.Driver
+		err error
+	)
+
+	switch m.config.Dialect.orDefault() {
+	case MySQL:
+		drv, err = mysql.Open(conn)
+	case SQLite:
+		drv, err = sqlite.Open(conn)
+	default:
+		drv, err = postgres.Open(conn)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed opening migration driver: %w", err)
+	}
+
+	return &schemaDriver{Driver: drv, schema: m.schema()}, nil
+}
+
+func (m *Mig) revisions(conn sqlschema.ExecQuerier) *revisions {
+	return newRevisions(conn, m.config.Dialect)
+}
+
+// schema is the name of the schema migrations are applied to
+func (m *Mig) schema() string {
+	switch m.config.Dialect.orDefault() {
+	case MySQL:
+		// schemas are databases in MySQL
+		return m.config.Name
+	case SQLite:
+		return "main"
+	}
+
+	return m.config.Schema
+}
+
+// isRevisionTable reports whether the table of the migrated schema is the
+// revisions table, which is only the case for SQLite
+func (m *Mig) isRevisionTable(t *sqlschema.Table) bool {
+	return m.config.Dialect.orDefault() == SQLite && t.Name == RevisionTable
+}
+
+func (m *Mig) executor(drv atlas.Driver, dir atlas.Dir, revs atlas.RevisionReadWriter) (*atlas.Executor, error) {
+	opts := []atlas.ExecutorOption{
+		atlas.WithLogger(&migLogger{log: m.log}),
+	}
+
+	if m.config.MigrationBaselineVersion != "" {
+		opts = append(opts, atlas.WithBaselineVersion(m.config.MigrationBaselineVersion))
+	}
+
+	ex, err := atlas.NewExecutor(drv, dir, revs, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating migration executor: %w", err)
+	}
+
+	return ex, nil
+}
+
+func (m *Mig) lock(ctx context.Context, drv atlas.Driver) (func(), error) {
+	timeout := time.Duration(m.config.MigrationLockTimeoutSeconds) * time.Second
+
+	name := migrationLockName
+
+	// the SQLite driver locks using a file in the temp directory, which is
+	// named after the database file. In-memory databases are private to
+	// the process and not locked.
+	if m.config.Dialect.orDefault() == SQLite {
+		if m.config.Path == "" || m.config.Path == sqliteMemoryPath {
+			return func() {}, nil
+		}
+
+		path, err := filepath.Abs(m.config.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed resolving database path: %w", err)
+		}
+
+		name = fmt.Sprintf("%s_%x", name, sha256.Sum256([]byte(path)))
+	}
+
+	unlock, err := drv.Lock(ctx, name, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed acquiring migration lock: %w", err)
+	}
+
+	return func() {
+		if err := unlock(); err != nil {
+			m.log.Warn("failed releasing migration lock", zap.Error(err))
+		}
+	}, nil
+}
+
+// devURL is the url of the dev database used to replay migrations
+func (m *Mig) devURL() string {
+	switch m.config.Dialect.orDefault() {
+	case SQLite:
+		// a new in-memory database per connection
+		return "sqlite://dev?mode=memory&_pragma=foreign_keys(1)"
+	case MySQL:
+		u := url.URL{
+			Scheme: "mysql",
+			User:   url.UserPassword(m.config.Username, m.config.Password),
+			Host:   net.JoinHostPort(m.config.Host, strconv.Itoa(m.config.Port)),
+			Path:   m.config.MigrationDevDatabase,
+		}
+
+		return u.String()
+	}
+
+	u := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(m.config.Username, m.config.Password),
+		Host:   net.JoinHostPort(m.config.Host, strconv.Itoa(m.config.Port)),
+		Path:   m.config.MigrationDevDatabase,
+		RawQuery: url.Values{
+			"search_path": {m.config.Schema},
+			"sslmode":     {"disable"},
+		}.Encode(),
+	}
+
+	return u.String()
+}
+
+// MARK: - Driver
+
+// schemaDriver limits the clean check of the first migration run to the
+// configured schema, the driver is bound to the whole database otherwise
+type schemaDriver struct {
+	atlas.Driver
+	schema string
+}
+
+func (d *schemaDriver) CheckClean(ctx context.Context, revT *atlas.TableIdent) error {
+	s, err := d.InspectSchema(ctx, d.schema, nil)
+	if sqlschema.IsNotExistError(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// the revisions table of SQLite is part of the schema
+	if revT != nil && revT.Schema == "" {
+		s.Tables = slices.DeleteFunc(s.Tables, func(t *sqlschema.Table) bool { return t.Name == revT.Name })
+	}
+
+	if len(s.Tables) > 0 {
+		return &atlas.NotCleanError{
+			State:  sqlschema.NewRealm(s),
+			Reason: fmt.Sprintf("found table %q in schema %q", s.Tables[0].Name, s.Name),
+		}
+	}
+
+	return nil
+}
+
+// MARK: - Logger
+
+// migLogger logs the progress of the migration executor
+type migLogger struct {
+	log *zap.Logger
+}
+
+func (l *migLogger) Log(entry atlas.LogEntry) {
+	switch e := entry.(type) {
+	case atlas.LogFile:
+		l.log.Info("applying migration",
+			zap.String("version", e.File.Version()),
+			zap.String("description", e.File.Desc()),
+		)
+	case atlas.LogStmt:
+		l.log.Debug("executing migration statement", zap.String("sql", e.SQL))
+	case atlas.LogError:
+		l.log.Error("migration failed", zap.String("sql", e.SQL), zap.Error(e.Error))
+	}
+}
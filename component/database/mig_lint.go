@@ -0,0 +1,191 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	atlas "ariga.io/atlas/sql/migrate"
+	sqlschema "ariga.io/atlas/sql/schema"
+	"ariga.io/atlas/sql/sqlcheck"
+	"ariga.io/atlas/sql/sqlclient"
+
+	_ "ariga.io/atlas/sql/mysql/mysqlcheck"
+	_ "ariga.io/atlas/sql/postgres/postgrescheck"
+	_ "ariga.io/atlas/sql/sqlite/sqlitecheck"
+)
+
+// LintReport is the result of linting migration files
+type LintReport struct {
+	// Files are the linted files with their reports
+	Files []LintFileReport
+}
+
+// LintFileReport are the findings of the analyzers for a migration file
+type LintFileReport struct {
+	// Name is the name of the migration file
+	Name string
+
+	// Reports are the reports of the analyzers, e.g. destructive changes
+	Reports []sqlcheck.Report
+}
+
+// HasDiagnostics returns true if any analyzer reported a finding
+func (r *LintReport) HasDiagnostics() bool {
+	for _, f := range r.Files {
+		for _, report := range f.Reports {
+			if len(report.Diagnostics) > 0 {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Lint verifies the checksum file of the migration directory and analyzes
+// the latest migration files for destructive, data dependent and backward
+// incompatible changes. All files are analyzed if latest is not positive.
+// The files are executed statement by statement on the dev database.
+func (m *Mig) Lint(ctx context.Context, latest int) (*LintReport, error) {
+	dir, err := atlas.NewLocalDir(m.config.MigrationPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating atlas migration directory: %w", err)
+	}
+
+	if err := atlas.Validate(dir); err != nil {
+		return nil, fmt.Errorf("invalid migration directory: %w", err)
+	}
+
+	files, err := atlas.FilesFromLastCheckpoint(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading migration files: %w", err)
+	}
+
+	base, linted := files, files
+	if latest > 0 && latest < len(files) {
+		base, linted = files[:len(files)-latest], files[len(files)-latest:]
+	} else {
+		base = nil
+	}
+
+	dev, err := m.openDev(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer dev.Close()
+
+	analyzers, err := sqlcheck.AnalyzerFor(dev.Name, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating analyzers: %w", err)
+	}
+
+	restore, err := dev.Snapshot(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed taking dev database snapshot: %w", err)
+	}
+	defer restore(ctx)
+
+	ex, err := atlas.NewExecutor(dev.Driver, dir, atlas.NopRevisionReadWriter{})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ex.ExecuteFiles(ctx, base); err != nil {
+		return nil, fmt.Errorf("failed replaying migrations: %w", err)
+	}
+
+	report := &LintReport{}
+
+	for _, file := range linted {
+		fileReport, err := m.lintFile(ctx, dev, analyzers, file)
+		if err != nil {
+			return nil, err
+		}
+
+		report.Files = append(report.Files, *fileReport)
+	}
+
+	return report, nil
+}
+
+// lintFile executes the file on the dev database, recording the
+// changes of each statement, and runs the analyzers on the changes
+func (m *Mig) lintFile(
+	ctx context.Context,
+	dev *sqlclient.Client,
+	analyzers []sqlcheck.Analyzer,
+	file atlas.File,
+) (*LintFileReport, error) {
+	stmts, err := file.StmtDecls()
+	if err != nil {
+		return nil, fmt.Errorf("failed reading statements of %s: %w", file.Name(), err)
+	}
+
+	from, err := m.inspectDev(ctx, dev)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &sqlcheck.File{
+		File: file,
+		From: sqlschema.NewRealm(from),
+	}
+
+	current := from
+
+	for _, stmt := range stmts {
+		if _, err := dev.ExecContext(ctx, stmt.Text); err != nil {
+			return nil, fmt.Errorf("failed executing statement of %s at position %d: %w", file.Name(), stmt.Pos, err)
+		}
+
+		next, err := m.inspectDev(ctx, dev)
+		if err != nil {
+			return nil, err
+		}
+
+		changes, err := dev.SchemaDiff(current, next)
+		if err != nil {
+			return nil, fmt.Errorf("failed computing changes of %s: %w", file.Name(), err)
+		}
+
+		f.Changes = append(f.Changes, &sqlcheck.Change{Changes: changes, Stmt: stmt})
+		current = next
+	}
+
+	f.To = sqlschema.NewRealm(current)
+
+	if f.Sum, err = dev.SchemaDiff(from, current); err != nil {
+		return nil, fmt.Errorf("failed computing changes of %s: %w", file.Name(), err)
+	}
+
+	fileReport := &LintFileReport{Name: file.Name()}
+
+	pass := &sqlcheck.Pass{
+		File: f,
+		Dev:  dev,
+		Reporter: sqlcheck.ReportWriterFunc(func(r sqlcheck.Report) {
+			fileReport.Reports = append(fileReport.Reports, r)
+		}),
+	}
+
+	for _, analyzer := range analyzers {
+		reported := len(fileReport.Reports)
+
+		// analyzers fail after reporting their findings, which are
+		// returned with the report instead
+		if err := analyzer.Analyze(ctx, pass); err != nil && len(fileReport.Reports) == reported {
+			return nil, fmt.Errorf("failed analyzing %s: %w", file.Name(), err)
+		}
+	}
+
+	return fileReport, nil
+}
+
+func (m *Mig) inspectDev(ctx context.Context, dev *sqlclient.Client) (*sqlschema.Schema, error) {
+	s, err := dev.InspectSchema(ctx, "", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed inspecting dev database: %w", err)
+	}
+
+	return s, nil
+}
@@ -0,0 +1,145 @@
+package database
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	atlas "ariga.io/atlas/sql/migrate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMig_DevURL(t *testing.T) {
+	mig, err := NewMig(MigratorParams{Config: &Config{
+		Host:                 "127.0.0.1",
+		Port:                 5432,
+		Username:             "fruits",
+		Password:             "p@ss/word",
+		Schema:               "app",
+		MigrationDevDatabase: "fruits_dev",
+	}})
+	require.NoError(t, err)
+
+	u, err := url.Parse(mig.devURL())
+	require.NoError(t, err)
+
+	password, _ := u.User.Password()
+	assert.Equal(t, "p@ss/word", password)
+	assert.Equal(t, "127.0.0.1:5432", u.Host)
+	assert.Equal(t, "/fruits_dev", u.Path)
+	assert.Equal(t, "app", u.Query().Get("search_path"))
+}
+
+func TestMig_InitialFiles(t *testing.T) {
+	dir, err := atlas.NewLocalDir(t.TempDir())
+	require.NoError(t, err)
+
+	for _, name := range []string{"1_init.sql", "2_users.sql", "3_orders.sql"} {
+		require.NoError(t, dir.WriteFile(name, []byte("SELECT 1;\n")))
+	}
+
+	sum, err := dir.Checksum()
+	require.NoError(t, err)
+	require.NoError(t, atlas.WriteSumFile(dir, sum))
+
+	versions := func(files []atlas.File) []string {
+		var v []string
+		for _, f := range files {
+			v = append(v, f.Version())
+		}
+		return v
+	}
+
+	mig := &Mig{config: &Config{}}
+
+	files, err := mig.initialFiles(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1", "2", "3"}, versions(files))
+
+	mig.config.MigrationBaselineVersion = "2"
+
+	files, err = mig.initialFiles(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"3"}, versions(files))
+
+	mig.config.MigrationBaselineVersion = "4"
+
+	_, err = mig.initialFiles(dir)
+	require.Error(t, err)
+
+	// files changed after generating the checksums are rejected
+	require.NoError(t, dir.WriteFile("2_users.sql", []byte("SELECT 2;\n")))
+
+	_, err = mig.initialFiles(dir)
+	require.Error(t, err)
+}
+
+func TestMig_SQLite(t *testing.T) {
+	ctx := context.Background()
+
+	path := t.TempDir()
+
+	dir, err := atlas.NewLocalDir(path)
+	require.NoError(t, err)
+
+	require.NoError(t, dir.WriteFile("1_users.sql", []byte("CREATE TABLE `users` (`id` integer NOT NULL PRIMARY KEY, `name` text NOT NULL);\n")))
+	require.NoError(t, dir.WriteFile("2_orders.sql", []byte("CREATE TABLE `orders` (`id` integer NOT NULL PRIMARY KEY, `user_id` integer NOT NULL);\n")))
+
+	sum, err := dir.Checksum()
+	require.NoError(t, err)
+	require.NoError(t, atlas.WriteSumFile(dir, sum))
+
+	config := &Config{
+		Dialect:       SQLite,
+		Path:          filepath.Join(t.TempDir(), "app.db"),
+		MigrationPath: path,
+	}
+
+	db, err := NewDB(EntDBParams{Config: config})
+	require.NoError(t, err)
+	defer db.Close()
+
+	mig, err := NewMig(MigratorParams{Config: config, DB: db})
+	require.NoError(t, err)
+
+	status, err := mig.Status(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, status.Current)
+	assert.Len(t, status.Pending, 2)
+
+	require.NoError(t, mig.Apply(ctx))
+
+	// applying again finds no pending migrations
+	require.NoError(t, mig.Apply(ctx))
+
+	status, err = mig.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "2", status.Current)
+	assert.Len(t, status.Applied, 2)
+	assert.Empty(t, status.Pending)
+
+	require.NoError(t, mig.RollbackTo(ctx, "1"))
+
+	status, err = mig.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "1", status.Current)
+	assert.Len(t, status.Pending, 1)
+
+	var tables []string
+	rows, err := db.DB().QueryContext(ctx, "SELECT name FROM sqlite_master WHERE type = 'table' ORDER BY name")
+	require.NoError(t, err)
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		require.NoError(t, rows.Scan(&name))
+		tables = append(tables, name)
+	}
+	require.NoError(t, rows.Err())
+
+	// the revisions table survives the rollback
+	assert.Equal(t, []string{RevisionTable, "users"}, tables)
+
+	require.NoError(t, mig.Apply(ctx))
+}
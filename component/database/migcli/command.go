@@ -0,0 +1,161 @@
+// Package migcli provides CLI commands to apply and inspect the database
+// migrations, to be added to the app using `CLIRoot.AddCommand`.
+package migcli
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v3"
+	"go.uber.org/fx"
+
+	"github.com/fruitsco/goji"
+	"github.com/fruitsco/goji/component/database"
+)
+
+// MigrateCommand creates the `migrate` command with the `apply`, `status`,
+// `rollback` and `lint` subcommands. The shell must provide the database
+// component, e.g. using the core module:
+//
+//	app migrate apply
+//	app migrate rollback 20240101120000
+func MigrateCommand[C any](shell *goji.Shell[C]) *cli.Command {
+	return &cli.Command{
+		Name:  "migrate",
+		Usage: "manage the database migrations",
+		Commands: []*cli.Command{
+			applyCommand(shell),
+			statusCommand(shell),
+			rollbackCommand(shell),
+			lintCommand(shell),
+		},
+	}
+}
+
+func applyCommand[C any](shell *goji.Shell[C]) *cli.Command {
+	return &cli.Command{
+		Name:  "apply",
+		Usage: "apply the pending migrations",
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			var mig *database.Mig
+
+			return shell.RunTask(ctx, func(ctx context.Context) error {
+				return mig.Apply(ctx)
+			}, fx.Populate(&mig))
+		},
+	}
+}
+
+func statusCommand[C any](shell *goji.Shell[C]) *cli.Command {
+	return &cli.Command{
+		Name:  "status",
+		Usage: "list the applied and pending migrations",
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			var mig *database.Mig
+
+			return shell.RunTask(ctx, func(ctx context.Context) error {
+				status, err := mig.Status(ctx)
+				if err != nil {
+					return err
+				}
+
+				w := tabwriter.NewWriter(cmd.Root().Writer, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "VERSION\tDESCRIPTION\tSTATE\tEXECUTED")
+
+				for _, r := range status.Applied {
+					state := "applied"
+					switch {
+					case r.Error != "":
+						state = "failed"
+					case r.Applied < r.Total:
+						state = "partial"
+					}
+
+					executed := "-"
+					if !r.ExecutedAt.IsZero() {
+						executed = r.ExecutedAt.Format(time.RFC3339)
+					}
+
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Version, r.Description, state, executed)
+				}
+
+				for _, f := range status.Pending {
+					// partially applied migrations are listed as applied already
+					if len(status.Applied) > 0 && f.Version() == status.Current {
+						continue
+					}
+
+					fmt.Fprintf(w, "%s\t%s\tpending\t-\n", f.Version(), f.Desc())
+				}
+
+				return w.Flush()
+			}, fx.Populate(&mig))
+		},
+	}
+}
+
+func rollbackCommand[C any](shell *goji.Shell[C]) *cli.Command {
+	return &cli.Command{
+		Name:      "rollback",
+		Usage:     "revert the schema to an applied version, dropped tables and columns lose their data",
+		ArgsUsage: "<version>",
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			version := cmd.Args().First()
+			if version == "" {
+				return fmt.Errorf("missing argument %s", cmd.ArgsUsage)
+			}
+
+			var mig *database.Mig
+
+			return shell.RunTask(ctx, func(ctx context.Context) error {
+				return mig.RollbackTo(ctx, version)
+			}, fx.Populate(&mig))
+		},
+	}
+}
+
+func lintCommand[C any](shell *goji.Shell[C]) *cli.Command {
+	return &cli.Command{
+		Name:  "lint",
+		Usage: "verify the migration directory and analyze the latest migrations on the dev database",
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:  "latest",
+				Usage: "number of latest migrations to analyze, all if zero",
+				Value: 1,
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			var mig *database.Mig
+
+			return shell.RunTask(ctx, func(ctx context.Context) error {
+				report, err := mig.Lint(ctx, cmd.Int("latest"))
+				if err != nil {
+					return err
+				}
+
+				out := cmd.Root().Writer
+
+				for _, f := range report.Files {
+					for _, r := range f.Reports {
+						fmt.Fprintf(out, "%s: %s\n", f.Name, r.Text)
+
+						for _, d := range r.Diagnostics {
+							fmt.Fprintf(out, "  %s at position %d: %s\n", d.Code, d.Pos, d.Text)
+						}
+					}
+				}
+
+				if report.HasDiagnostics() {
+					return fmt.Errorf("migration lint found issues")
+				}
+
+				fmt.Fprintf(out, "linted %d migrations\n", len(report.Files))
+
+				return nil
+			}, fx.Populate(&mig))
+		},
+	}
+}
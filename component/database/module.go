@@ -2,6 +2,8 @@ package database
 
 import (
 	"go.uber.org/fx"
+
+	"github.com/fruitsco/goji/component/health"
 )
 
 func Module(config *Config) fx.Option {
@@ -9,5 +11,16 @@ func Module(config *Config) fx.Option {
 		fx.Supply(config),
 		fx.Provide(NewLifecycleDB),
 		fx.Provide(NewMig),
+		health.AsChecker[*EntDB](),
 	)
 }
+
+// MigrateOnStart applies the pending migrations when the app starts, to be
+// added to the app options next to the module. Instances starting
+// concurrently wait for each other, the app start timeout must allow for
+// the migrations to finish.
+func MigrateOnStart() fx.Option {
+	return fx.Invoke(func(lc fx.Lifecycle, mig *Mig) {
+		lc.Append(fx.StartHook(mig.Apply))
+	})
+}
@@ -3,77 +3,131 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"sync/atomic"
 
 	"entgo.io/ent"
 	"entgo.io/ent/dialect"
 )
 
+// writeFractionBase is the number of queries the write fraction is applied to
+const writeFractionBase = 100
+
 type multiDriver struct {
-	r, w dialect.Driver
+	w        dialect.Driver
+	replicas []*replica
+
+	// selection is the strategy selecting a replica for a read query
+	selection ReplicaSelection
 
-	// queryWriteFraction is the fraction of total queries that should be sent
-	// to the write replica. 0 < queryWriteFraction < queryTotal.
+	// writeFraction is the fraction of read queries that should be sent to
+	// the primary. 0 <= writeFraction <= 1.
 	//
-	// Example: If queryWriteFraction = 1, queryTotal = 10. 1/10 queries will
-	// be sent to the write replica, 9/10 to the read replica.
-	queryWriteFraction int64
+	// Example: If writeFraction = 0.1, 1/10 queries will be sent to the
+	// primary, 9/10 to the replicas.
+	writeFraction float64
 
-	// queryTotal is the total number of queries that are sent to both the read
-	// and write replicas.
-	queryTotal int64
+	// queryCounter is the number of read queries sent to any connection.
+	queryCounter atomic.Uint64
 
-	// queryCounter is the number of queries sent to either replica.
-	queryCounter int64
+	// replicaCounter is the number of read queries sent to a replica.
+	replicaCounter atomic.Uint64
 }
 
 var _ dialect.Driver = (*multiDriver)(nil)
 
 func (d *multiDriver) Query(ctx context.Context, query string, args, v any) error {
-	e := d.r
-
-	n := atomic.AddInt64(&d.queryCounter, 1)
-
 	if ent.QueryFromContext(ctx) == nil {
 		// Mutation statements that use the RETURNING clause.
-		e = d.w
-	} else if (n % d.queryTotal) < d.queryWriteFraction {
-		// Round-robin between read and write based on writeFraction
-		e = d.w
+		markWritten(ctx)
+		return d.w.Query(ctx, query, args, v)
+	}
+
+	// Reads requiring the latest writes, see WithPrimary and WithStickyPrimary
+	if UsesPrimary(ctx) {
+		return d.w.Query(ctx, query, args, v)
+	}
+
+	n := d.queryCounter.Add(1)
+
+	// Round-robin between primary and replicas based on writeFraction
+	if float64(n%writeFractionBase) < d.writeFraction*writeFractionBase {
+		return d.w.Query(ctx, query, args, v)
+	}
+
+	r := d.selectReplica()
+	if r == nil {
+		// all replicas are unhealthy, fall back to the primary
+		return d.w.Query(ctx, query, args, v)
 	}
 
-	return e.Query(ctx, query, args, v)
+	r.inflight.Add(1)
+	defer r.inflight.Add(-1)
+
+	return r.driver.Query(ctx, query, args, v)
+}
+
+// selectReplica returns a healthy replica, or nil if there is none
+func (d *multiDriver) selectReplica() *replica {
+	if len(d.replicas) == 0 {
+		return nil
+	}
+
+	// start at a rotating offset, so that replicas are used in turn
+	// and ties between least connections are distributed evenly
+	offset := int(d.replicaCounter.Add(1) % uint64(len(d.replicas)))
+
+	var selected *replica
+
+	for i := range d.replicas {
+		r := d.replicas[(offset+i)%len(d.replicas)]
+
+		if !r.healthy.Load() {
+			continue
+		}
+
+		if d.selection != LeastConnections {
+			return r
+		}
+
+		if selected == nil || r.inflight.Load() < selected.inflight.Load() {
+			selected = r
+		}
+	}
+
+	return selected
 }
 
 func (d *multiDriver) Exec(ctx context.Context, query string, args, v any) error {
+	markWritten(ctx)
 	return d.w.Exec(ctx, query, args, v)
 }
 
 func (d *multiDriver) Tx(ctx context.Context) (dialect.Tx, error) {
+	markWritten(ctx)
 	return d.w.Tx(ctx)
 }
 
 func (d *multiDriver) BeginTx(ctx context.Context, opts *sql.TxOptions) (dialect.Tx, error) {
+	if opts == nil || !opts.ReadOnly {
+		markWritten(ctx)
+	}
+
 	return d.w.(interface {
 		BeginTx(context.Context, *sql.TxOptions) (dialect.Tx, error)
 	}).BeginTx(ctx, opts)
 }
 
 func (d *multiDriver) Close() error {
-	rerr := d.r.Close()
-	werr := d.w.Close()
-
-	if rerr != nil {
-		return rerr
-	}
+	errs := []error{d.w.Close()}
 
-	if werr != nil {
-		return werr
+	for _, r := range d.replicas {
+		errs = append(errs, r.driver.Close())
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
 
 func (d *multiDriver) Dialect() string {
-	return d.r.Dialect()
+	return d.w.Dialect()
 }
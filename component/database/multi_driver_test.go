@@ -0,0 +1,136 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"entgo.io/ent"
+	"entgo.io/ent/dialect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingDriver is a driver counting the queries it receives
+type countingDriver struct {
+	dialect.Driver
+	queries int
+}
+
+func (d *countingDriver) Query(context.Context, string, any, any) error {
+	d.queries++
+	return nil
+}
+
+func newTestMultiDriver(selection ReplicaSelection, writeFraction float64, replicas int) (*multiDriver, *countingDriver, []*countingDriver) {
+	w := &countingDriver{}
+	d := &multiDriver{w: w, selection: selection, writeFraction: writeFraction}
+
+	var drivers []*countingDriver
+	for range replicas {
+		r := &countingDriver{}
+		drivers = append(drivers, r)
+		d.replicas = append(d.replicas, &replica{driver: r})
+		d.replicas[len(d.replicas)-1].healthy.Store(true)
+	}
+
+	return d, w, drivers
+}
+
+func TestMultiDriver_Query(t *testing.T) {
+	ctx := ent.NewQueryContext(context.Background(), &ent.QueryContext{})
+
+	d, w, replicas := newTestMultiDriver(RoundRobin, 0.2, 2)
+
+	for range 100 {
+		require.NoError(t, d.Query(ctx, "SELECT 1", []any{}, nil))
+	}
+
+	assert.Equal(t, 20, w.queries)
+	assert.Equal(t, 40, replicas[0].queries)
+	assert.Equal(t, 40, replicas[1].queries)
+
+	// mutations returning rows always use the primary
+	require.NoError(t, d.Query(context.Background(), "INSERT ... RETURNING id", []any{}, nil))
+	assert.Equal(t, 21, w.queries)
+}
+
+func TestMultiDriver_EjectsUnhealthyReplicas(t *testing.T) {
+	ctx := ent.NewQueryContext(context.Background(), &ent.QueryContext{})
+
+	d, w, replicas := newTestMultiDriver(RoundRobin, 0, 2)
+	d.replicas[0].healthy.Store(false)
+
+	for range 10 {
+		require.NoError(t, d.Query(ctx, "SELECT 1", []any{}, nil))
+	}
+
+	assert.Equal(t, 0, replicas[0].queries)
+	assert.Equal(t, 10, replicas[1].queries)
+
+	// without healthy replicas, the primary serves reads
+	d.replicas[1].healthy.Store(false)
+
+	require.NoError(t, d.Query(ctx, "SELECT 1", []any{}, nil))
+	assert.Equal(t, 1, w.queries)
+}
+
+func TestMultiDriver_LeastConnections(t *testing.T) {
+	d, _, _ := newTestMultiDriver(LeastConnections, 0, 3)
+
+	d.replicas[0].inflight.Store(4)
+	d.replicas[1].inflight.Store(1)
+	d.replicas[2].inflight.Store(2)
+
+	for range 5 {
+		assert.Same(t, d.replicas[1], d.selectReplica())
+	}
+}
+
+func TestConfig_ReplicaConfigs(t *testing.T) {
+	cfg := &Config{
+		Replica:     true,
+		ReplicaHost: "legacy",
+		Replicas: map[string]*ReplicaConfig{
+			"eu": {Host: "eu-replica"},
+		},
+	}
+
+	replicas := cfg.replicaConfigs()
+	require.Len(t, replicas, 2)
+	assert.Equal(t, "legacy", replicas["default"].Host)
+	assert.Equal(t, "eu-replica", replicas["eu"].Host)
+}
+
+func TestMultiDriver_WithPrimary(t *testing.T) {
+	ctx := ent.NewQueryContext(context.Background(), &ent.QueryContext{})
+
+	d, w, replicas := newTestMultiDriver(RoundRobin, 0, 1)
+
+	require.NoError(t, d.Query(WithPrimary(ctx), "SELECT 1", []any{}, nil))
+	assert.Equal(t, 1, w.queries)
+	assert.Equal(t, 0, replicas[0].queries)
+}
+
+func TestMultiDriver_StickyPrimary(t *testing.T) {
+	ctx := WithStickyPrimary(context.Background())
+	queryCtx := ent.NewQueryContext(ctx, &ent.QueryContext{})
+
+	d, w, replicas := newTestMultiDriver(RoundRobin, 0, 1)
+
+	// reads before the first write use the replicas
+	require.NoError(t, d.Query(queryCtx, "SELECT 1", []any{}, nil))
+	assert.Equal(t, 1, replicas[0].queries)
+	assert.False(t, UsesPrimary(queryCtx))
+
+	// a write sticks derived contexts to the primary
+	require.NoError(t, d.Query(ctx, "INSERT ... RETURNING id", []any{}, nil))
+	assert.True(t, UsesPrimary(queryCtx))
+
+	require.NoError(t, d.Query(queryCtx, "SELECT 1", []any{}, nil))
+	assert.Equal(t, 2, w.queries)
+	assert.Equal(t, 1, replicas[0].queries)
+
+	// other contexts are not affected
+	require.NoError(t, d.Query(ent.NewQueryContext(context.Background(), &ent.QueryContext{}), "SELECT 1", []any{}, nil))
+	assert.Equal(t, 2, replicas[0].queries)
+}
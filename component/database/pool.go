@@ -0,0 +1,97 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// PoolParams are the connection pool settings of a connection
+type PoolParams struct {
+	// MaxOpenConnections limits the number of open connections, unlimited if zero
+	MaxOpenConnections int
+
+	// MaxIdleConnections limits the number of idle connections,
+	// the database/sql default is kept if zero
+	MaxIdleConnections int
+
+	// MaxLifetime closes connections older than the given duration
+	MaxLifetime time.Duration
+
+	// MaxIdleTime closes connections idle for longer than the given duration
+	MaxIdleTime time.Duration
+}
+
+// apply applies the pool settings to the db, zero values keep the defaults
+func (p PoolParams) apply(db *sql.DB) {
+	if p.MaxOpenConnections > 0 {
+		db.SetMaxOpenConns(p.MaxOpenConnections)
+	}
+
+	if p.MaxIdleConnections > 0 {
+		db.SetMaxIdleConns(p.MaxIdleConnections)
+	}
+
+	if p.MaxLifetime > 0 {
+		db.SetConnMaxLifetime(p.MaxLifetime)
+	}
+
+	if p.MaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(p.MaxIdleTime)
+	}
+}
+
+// PoolStats are the connection pool statistics of the primary and replicas
+type PoolStats struct {
+	// Primary are the statistics of the primary
+	Primary sql.DBStats
+
+	// Replicas are the statistics of the replicas by name
+	Replicas map[string]sql.DBStats
+}
+
+// poolParams returns the pool settings of the primary
+func (c *Config) poolParams() PoolParams {
+	return PoolParams{
+		MaxOpenConnections: c.MaxConnections,
+		MaxIdleConnections: c.MaxIdleConnections,
+		MaxLifetime:        time.Duration(c.MaxLifetimeSeconds) * time.Second,
+		MaxIdleTime:        time.Duration(c.MaxIdleSeconds) * time.Second,
+	}
+}
+
+// poolParams returns the pool settings of the replica,
+// inheriting the settings of the primary
+func (r *ReplicaConfig) poolParams(primary PoolParams) PoolParams {
+	p := primary
+
+	if r.MaxConnections > 0 {
+		p.MaxOpenConnections = r.MaxConnections
+	}
+
+	if r.MaxIdleConnections > 0 {
+		p.MaxIdleConnections = r.MaxIdleConnections
+	}
+
+	if r.MaxLifetimeSeconds > 0 {
+		p.MaxLifetime = time.Duration(r.MaxLifetimeSeconds) * time.Second
+	}
+
+	if r.MaxIdleSeconds > 0 {
+		p.MaxIdleTime = time.Duration(r.MaxIdleSeconds) * time.Second
+	}
+
+	return p
+}
+
+// statementCacheModes are the query execution modes supported by pgx
+var statementCacheModes = []string{"cache_statement", "cache_describe", "describe_exec", "exec", "simple_protocol"}
+
+func validateStatementCacheMode(mode string) error {
+	if mode == "" || slices.Contains(statementCacheModes, mode) {
+		return nil
+	}
+
+	return fmt.Errorf("invalid statement cache mode %q, must be one of %v", mode, statementCacheModes)
+}
@@ -0,0 +1,153 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"entgo.io/ent/dialect"
+	"go.uber.org/zap"
+)
+
+// replicationLagQuery returns the replication lag of a replica in seconds.
+// A replica which replayed everything it received has no lag, even if
+// the primary has not written anything for a while.
+const replicationLagQuery = `SELECT CASE
+	WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+END`
+
+// replica is a read replica of a multi connection
+type replica struct {
+	name   string
+	db     *sql.DB
+	driver dialect.Driver
+
+	// inflight is the number of queries currently running on the replica
+	inflight atomic.Int64
+
+	// healthy is false while the replica is ejected by the health checker
+	healthy atomic.Bool
+}
+
+func newReplica(name string, conn Connection) *replica {
+	r := &replica{
+		name:   name,
+		db:     conn.DB(),
+		driver: conn.Driver(),
+	}
+
+	// replicas receive queries until a health check fails
+	r.healthy.Store(true)
+
+	return r
+}
+
+// replicaHealthChecker periodically checks the replicas, ejecting
+// unreachable and lagging replicas until they recover
+type replicaHealthChecker struct {
+	replicas []*replica
+	interval time.Duration
+	maxLag   time.Duration
+	log      *zap.Logger
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func newReplicaHealthChecker(replicas []*replica, interval, maxLag time.Duration, log *zap.Logger) *replicaHealthChecker {
+	return &replicaHealthChecker{
+		replicas: replicas,
+		interval: interval,
+		maxLag:   maxLag,
+		log:      log,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+func (h *replicaHealthChecker) Start() {
+	go func() {
+		defer close(h.done)
+
+		ticker := time.NewTicker(h.interval)
+		defer ticker.Stop()
+
+		for {
+			h.checkAll()
+
+			select {
+			case <-h.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (h *replicaHealthChecker) Stop() {
+	h.stopOnce.Do(func() {
+		close(h.stop)
+		<-h.done
+	})
+}
+
+func (h *replicaHealthChecker) checkAll() {
+	var wg sync.WaitGroup
+
+	for _, r := range h.replicas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.check(r)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (h *replicaHealthChecker) check(r *replica) {
+	// a check must complete before the next one is due
+	ctx, cancel := context.WithTimeout(context.Background(), h.interval)
+	defer cancel()
+
+	err := checkReplica(ctx, r.db, h.maxLag)
+	healthy := err == nil
+
+	if r.healthy.Swap(healthy) == healthy {
+		return
+	}
+
+	if healthy {
+		h.log.Info("replica recovered", zap.String("replica", r.name))
+	} else {
+		h.log.Warn("replica ejected", zap.String("replica", r.name), zap.Error(err))
+	}
+}
+
+// checkReplica pings the replica and checks its replication lag
+func checkReplica(ctx context.Context, db *sql.DB, maxLag time.Duration) error {
+	if err := db.PingContext(ctx); err != nil {
+		return err
+	}
+
+	if maxLag <= 0 {
+		return nil
+	}
+
+	var seconds float64
+	if err := db.QueryRowContext(ctx, replicationLagQuery).Scan(&seconds); err != nil {
+		return fmt.Errorf("failed to query replication lag: %w", err)
+	}
+
+	lag := time.Duration(seconds * float64(time.Second))
+	if lag > maxLag {
+		return fmt.Errorf("replication lag of %s exceeds %s", lag.Round(time.Millisecond), maxLag)
+	}
+
+	return nil
+}
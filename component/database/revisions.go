@@ -0,0 +1,319 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	atlas "ariga.io/atlas/sql/migrate"
+	sqlschema "ariga.io/atlas/sql/schema"
+	entsql "entgo.io/ent/dialect/sql"
+)
+
+const (
+	// RevisionSchema is the schema of the revisions table, a database in
+	// MySQL. SQLite stores the table in the main database. Schema and table
+	// match the Atlas CLI, so both can be used on the same database.
+	RevisionSchema = "atlas_schema_revisions"
+
+	// RevisionTable is the table storing the applied migrations
+	RevisionTable = "atlas_schema_revisions"
+)
+
+var revisionColumns = []string{
+	"version", "description", "type", "applied", "total", "executed_at", "execution_time",
+	"error", "error_stmt", "hash", "partial_hashes", "operator_version",
+}
+
+// revisionTables are the statements creating the revisions table of each
+// dialect, formatted with the quoted name of the table
+var revisionTables = map[Dialect]string{
+	Postgres: `CREATE TABLE IF NOT EXISTS %s (
+		version character varying NOT NULL PRIMARY KEY,
+		description character varying NOT NULL,
+		type bigint NOT NULL DEFAULT 2,
+		applied bigint NOT NULL DEFAULT 0,
+		total bigint NOT NULL DEFAULT 0,
+		executed_at timestamptz NOT NULL,
+		execution_time bigint NOT NULL,
+		error text NULL,
+		error_stmt text NULL,
+		hash character varying NOT NULL,
+		partial_hashes jsonb NULL,
+		operator_version character varying NOT NULL
+	)`,
+	MySQL: `CREATE TABLE IF NOT EXISTS %s (
+		version varchar(255) NOT NULL PRIMARY KEY,
+		description varchar(255) NOT NULL,
+		type bigint unsigned NOT NULL DEFAULT 2,
+		applied bigint NOT NULL DEFAULT 0,
+		total bigint NOT NULL DEFAULT 0,
+		executed_at timestamp(6) NOT NULL,
+		execution_time bigint NOT NULL,
+		error longtext NULL,
+		error_stmt longtext NULL,
+		hash varchar(255) NOT NULL,
+		partial_hashes json NULL,
+		operator_version varchar(255) NOT NULL
+	)`,
+	SQLite: `CREATE TABLE IF NOT EXISTS %s (
+		version text NOT NULL PRIMARY KEY,
+		description text NOT NULL,
+		type integer NOT NULL DEFAULT 2,
+		applied integer NOT NULL DEFAULT 0,
+		total integer NOT NULL DEFAULT 0,
+		executed_at datetime NOT NULL,
+		execution_time integer NOT NULL,
+		error text NULL,
+		error_stmt text NULL,
+		hash text NOT NULL,
+		partial_hashes json NULL,
+		operator_version text NOT NULL
+	)`,
+}
+
+// revisions stores the migration revisions in the revisions table
+type revisions struct {
+	db      sqlschema.ExecQuerier
+	dialect Dialect
+}
+
+var _ = atlas.RevisionReadWriter(&revisions{})
+
+func newRevisions(db sqlschema.ExecQuerier, dialect Dialect) *revisions {
+	return &revisions{db: db, dialect: dialect.orDefault()}
+}
+
+// init creates the revisions table if it does not exist yet
+func (r *revisions) init(ctx context.Context) error {
+	var createSchema string
+
+	switch r.dialect {
+	case Postgres:
+		createSchema = fmt.Sprintf(`CREATE SCHEMA IF NOT EXISTS %s`, r.quote(RevisionSchema))
+	case MySQL:
+		createSchema = fmt.Sprintf(`CREATE DATABASE IF NOT EXISTS %s`, r.quote(RevisionSchema))
+	}
+
+	if createSchema != "" {
+		if _, err := r.db.ExecContext(ctx, createSchema); err != nil {
+			return fmt.Errorf("failed creating revision schema: %w", err)
+		}
+	}
+
+	if _, err := r.db.ExecContext(ctx, fmt.Sprintf(revisionTables[r.dialect], r.table())); err != nil {
+		return fmt.Errorf("failed creating revision table: %w", err)
+	}
+
+	return nil
+}
+
+// table returns the quoted name of the revisions table
+func (r *revisions) table() string {
+	if r.dialect == SQLite {
+		return r.quote(RevisionTable)
+	}
+
+	return r.quote(RevisionSchema) + "." + r.quote(RevisionTable)
+}
+
+func (r *revisions) quote(ident string) string {
+	var b entsql.Builder
+	b.SetDialect(r.dialect.entDialect())
+
+	return b.Quote(ident)
+}
+
+func (r *revisions) builder() *entsql.DialectBuilder {
+	return entsql.Dialect(r.dialect.entDialect())
+}
+
+func (r *revisions) selectTable() *entsql.SelectTable {
+	return r.builder().Table(RevisionTable).Schema(RevisionSchema)
+}
+
+func (r *revisions) Ident() *atlas.TableIdent {
+	if r.dialect == SQLite {
+		return &atlas.TableIdent{Name: RevisionTable}
+	}
+
+	return &atlas.TableIdent{Name: RevisionTable, Schema: RevisionSchema}
+}
+
+func (r *revisions) ReadRevisions(ctx context.Context) ([]*atlas.Revision, error) {
+	// the table does not exist before the first migration run
+	exists, err := r.exists(ctx)
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	query, args := r.builder().Select(revisionColumns...).
+		From(r.selectTable()).
+		OrderBy("version").
+		Query()
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading revisions: %w", err)
+	}
+	defer rows.Close()
+
+	var revs []*atlas.Revision
+
+	for rows.Next() {
+		rev, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revs = append(revs, rev)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading revisions: %w", err)
+	}
+
+	return revs, nil
+}
+
+func (r *revisions) ReadRevision(ctx context.Context, version string) (*atlas.Revision, error) {
+	exists, err := r.exists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, atlas.ErrRevisionNotExist
+	}
+
+	query, args := r.builder().Select(revisionColumns...).
+		From(r.selectTable()).
+		Where(entsql.EQ("version", version)).
+		Query()
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading revision %s: %w", version, err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed reading revision %s: %w", version, err)
+		}
+		return nil, atlas.ErrRevisionNotExist
+	}
+
+	return scanRevision(rows)
+}
+
+func (r *revisions) WriteRevision(ctx context.Context, rev *atlas.Revision) error {
+	partialHashes, err := json.Marshal(rev.PartialHashes)
+	if err != nil {
+		return fmt.Errorf("failed encoding partial hashes: %w", err)
+	}
+
+	query, args := r.builder().Insert(RevisionTable).
+		Schema(RevisionSchema).
+		Columns(revisionColumns...).
+		Values(
+			rev.Version, rev.Description, int64(rev.Type), rev.Applied, rev.Total,
+			rev.ExecutedAt, int64(rev.ExecutionTime),
+			nullString(rev.Error), nullString(rev.ErrorStmt),
+			rev.Hash, string(partialHashes), rev.OperatorVersion,
+		).
+		OnConflict(entsql.ConflictColumns("version"), entsql.ResolveWithNewValues()).
+		Query()
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed writing revision %s: %w", rev.Version, err)
+	}
+
+	return nil
+}
+
+func (r *revisions) DeleteRevision(ctx context.Context, version string) error {
+	query, args := r.builder().Delete(RevisionTable).
+		Schema(RevisionSchema).
+		Where(entsql.EQ("version", version)).
+		Query()
+
+	_, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed deleting revision %s: %w", version, err)
+	}
+
+	return nil
+}
+
+func (r *revisions) exists(ctx context.Context) (bool, error) {
+	var (
+		query string
+		args  []any
+	)
+
+	switch r.dialect {
+	case MySQL:
+		query = `SELECT COUNT(*) > 0 FROM information_schema.tables WHERE table_schema = ? AND table_name = ?`
+		args = []any{RevisionSchema, RevisionTable}
+	case SQLite:
+		query = `SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = ?`
+		args = []any{RevisionTable}
+	default:
+		query = `SELECT to_regclass($1::text) IS NOT NULL`
+		args = []any{r.table()}
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return false, fmt.Errorf("failed checking revision table: %w", err)
+	}
+	defer rows.Close()
+
+	var exists bool
+	if rows.Next() {
+		if err := rows.Scan(&exists); err != nil {
+			return false, fmt.Errorf("failed checking revision table: %w", err)
+		}
+	}
+
+	return exists, rows.Err()
+}
+
+func scanRevision(rows *sql.Rows) (*atlas.Revision, error) {
+	var (
+		rev           atlas.Revision
+		revType       int64
+		executionTime int64
+		errorText     sql.NullString
+		errorStmt     sql.NullString
+		partialHashes []byte
+	)
+
+	err := rows.Scan(
+		&rev.Version, &rev.Description, &revType, &rev.Applied, &rev.Total,
+		&rev.ExecutedAt, &executionTime, &errorText, &errorStmt,
+		&rev.Hash, &partialHashes, &rev.OperatorVersion,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed scanning revision: %w", err)
+	}
+
+	rev.Type = atlas.RevisionType(revType)
+	rev.ExecutionTime = time.Duration(executionTime)
+	rev.Error = errorText.String
+	rev.ErrorStmt = errorStmt.String
+
+	if len(partialHashes) > 0 {
+		if err := json.Unmarshal(partialHashes, &rev.PartialHashes); err != nil {
+			return nil, fmt.Errorf("failed decoding partial hashes of revision %s: %w", rev.Version, err)
+		}
+	}
+
+	return &rev, nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
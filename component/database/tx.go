@@ -0,0 +1,331 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+
+	"entgo.io/ent/dialect"
+	"github.com/go-sql-driver/mysql"
+	"modernc.org/sqlite"
+)
+
+const (
+	// DefaultTxMaxRetries is the default number of retries of a transaction
+	DefaultTxMaxRetries = 3
+
+	// DefaultTxMinBackoff is the default delay before the first retry
+	DefaultTxMinBackoff = 10 * time.Millisecond
+
+	// DefaultTxMaxBackoff is the default maximum delay between retries
+	DefaultTxMaxBackoff = time.Second
+)
+
+const (
+	// sqlStateSerializationFailure is raised by conflicting serializable transactions
+	sqlStateSerializationFailure = "40001"
+
+	// sqlStateDeadlockDetected is raised if transactions wait for each other
+	sqlStateDeadlockDetected = "40P01"
+
+	// mysqlErrLockDeadlock is the MySQL error number of a deadlock
+	mysqlErrLockDeadlock = 1213
+
+	// sqliteBusy is the primary SQLite result code of a locked database file
+	sqliteBusy = 5
+)
+
+// TxOptions configures the transaction of WithTx
+type TxOptions struct {
+	// Isolation is the isolation level, the database default is used if zero
+	Isolation sql.IsolationLevel
+
+	// ReadOnly starts a read only transaction
+	ReadOnly bool
+
+	// MaxRetries is the number of retries after serialization failures and
+	// deadlocks, DefaultTxMaxRetries if zero. Retries are disabled if negative.
+	MaxRetries int
+
+	// MinBackoff is the delay before the first retry, DefaultTxMinBackoff
+	// if zero. The delay doubles with each retry.
+	MinBackoff time.Duration
+
+	// MaxBackoff is the maximum delay between retries, DefaultTxMaxBackoff if zero
+	MaxBackoff time.Duration
+}
+
+func (o *TxOptions) withDefaults() TxOptions {
+	var opts TxOptions
+	if o != nil {
+		opts = *o
+	}
+
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = DefaultTxMaxRetries
+	}
+
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = DefaultTxMinBackoff
+	}
+
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = DefaultTxMaxBackoff
+	}
+
+	return opts
+}
+
+// backoff returns the jittered delay before the given retry
+func (o TxOptions) backoff(attempt int) time.Duration {
+	d := o.MaxBackoff
+	if attempt < 32 {
+		d = min(o.MinBackoff<<attempt, o.MaxBackoff)
+	}
+
+	return d/2 + rand.N(d/2+1)
+}
+
+// WithTx runs fn in a transaction, which is committed if fn returns nil and
+// rolled back otherwise. Queries of the driver returned by Driver, e.g. of
+// an ent client, use the transaction when they are passed the context
+// given to fn. The transaction always runs on the primary.
+//
+// Nested calls with the context run in a savepoint of the transaction and
+// ignore their options. The whole transaction is retried with backoff if it
+// fails with a serialization failure or deadlock, fn must thus be safe to
+// run multiple times.
+func (db *EntDB) WithTx(ctx context.Context, fn func(ctx context.Context) error, opts *TxOptions) error {
+	if state := db.txFromContext(ctx); state != nil {
+		return state.withSavepoint(ctx, fn)
+	}
+
+	o := opts.withDefaults()
+
+	for attempt := 0; ; attempt++ {
+		err := db.runTx(ctx, fn, o)
+		if err == nil || !IsRetryableError(err) || attempt >= o.MaxRetries {
+			return err
+		}
+
+		timer := time.NewTimer(o.backoff(attempt))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w: %w", ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
+
+// IsRetryableError reports whether err is a serialization failure or a
+// deadlock, after which the transaction can be retried. For SQLite a busy
+// database is retryable.
+func IsRetryableError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlErrLockDeadlock
+	}
+
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code()&0xff == sqliteBusy
+	}
+
+	var sqlErr interface{ SQLState() string }
+	if !errors.As(err, &sqlErr) {
+		return false
+	}
+
+	switch sqlErr.SQLState() {
+	case sqlStateSerializationFailure, sqlStateDeadlockDetected:
+		return true
+	}
+
+	return false
+}
+
+func (db *EntDB) runTx(ctx context.Context, fn func(ctx context.Context) error, opts TxOptions) error {
+	tx, err := db.beginTx(ctx, &sql.TxOptions{
+		Isolation: opts.Isolation,
+		ReadOnly:  opts.ReadOnly,
+	})
+	if err != nil {
+		return fmt.Errorf("failed starting transaction: %w", err)
+	}
+
+	state := &txState{
+		owner:  db,
+		tx:     tx,
+		parent: txStateFromContext(ctx),
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey, state)); err != nil {
+		if rerr := tx.Rollback(); rerr != nil {
+			return errors.Join(err, fmt.Errorf("failed rolling back transaction: %w", rerr))
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// beginTx starts a transaction on the primary
+func (db *EntDB) beginTx(ctx context.Context, opts *sql.TxOptions) (dialect.Tx, error) {
+	return beginTx(ctx, db.connection.Driver(), opts)
+}
+
+func beginTx(ctx context.Context, driver dialect.Driver, opts *sql.TxOptions) (dialect.Tx, error) {
+	if d, ok := driver.(interface {
+		BeginTx(context.Context, *sql.TxOptions) (dialect.Tx, error)
+	}); ok {
+		return d.BeginTx(ctx, opts)
+	}
+
+	return driver.Tx(ctx)
+}
+
+// txFromContext returns the transaction of the database in the context
+func (db *EntDB) txFromContext(ctx context.Context) *txState {
+	for state := txStateFromContext(ctx); state != nil; state = state.parent {
+		if state.owner == db {
+			return state
+		}
+	}
+
+	return nil
+}
+
+func txStateFromContext(ctx context.Context) *txState {
+	state, _ := ctx.Value(txKey).(*txState)
+	return state
+}
+
+// MARK: - Transaction State
+
+// txState is the transaction of WithTx, shared with nested calls
+type txState struct {
+	owner *EntDB
+	tx    dialect.Tx
+
+	// parent is the transaction of another database in the context
+	parent *txState
+
+	// savepoints is the number of savepoints created, for unique names
+	savepoints atomic.Int64
+}
+
+func (s *txState) withSavepoint(ctx context.Context, fn func(ctx context.Context) error) error {
+	sp, err := s.savepoint(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(ctx); err != nil {
+		if rerr := sp.Rollback(); rerr != nil {
+			return errors.Join(err, rerr)
+		}
+		return err
+	}
+
+	return sp.Commit()
+}
+
+func (s *txState) savepoint(ctx context.Context) (*savepointTx, error) {
+	sp := &savepointTx{
+		Tx:   s.tx,
+		ctx:  ctx,
+		name: fmt.Sprintf("goji_savepoint_%d", s.savepoints.Add(1)),
+	}
+
+	if err := s.tx.Exec(ctx, "SAVEPOINT "+sp.name, []any{}, nil); err != nil {
+		return nil, fmt.Errorf("failed creating savepoint: %w", err)
+	}
+
+	return sp, nil
+}
+
+// savepointTx is a nested transaction, committing releases the savepoint
+// and rolling back reverts the changes since the savepoint
+type savepointTx struct {
+	dialect.Tx
+	ctx  context.Context
+	name string
+}
+
+var _ = dialect.Tx(&savepointTx{})
+
+func (t *savepointTx) Commit() error {
+	if err := t.Exec(t.ctx, "RELEASE SAVEPOINT "+t.name, []any{}, nil); err != nil {
+		return fmt.Errorf("failed releasing savepoint: %w", err)
+	}
+
+	return nil
+}
+
+func (t *savepointTx) Rollback() error {
+	if err := t.Exec(t.ctx, "ROLLBACK TO SAVEPOINT "+t.name, []any{}, nil); err != nil {
+		return fmt.Errorf("failed rolling back to savepoint: %w", err)
+	}
+
+	return nil
+}
+
+// MARK: - Driver
+
+// txDriver runs the queries of a context with a transaction of WithTx in
+// the transaction, transactions started within become savepoints
+type txDriver struct {
+	dialect.Driver
+	db *EntDB
+}
+
+var _ = dialect.Driver(&txDriver{})
+
+func (d *txDriver) Exec(ctx context.Context, query string, args, v any) error {
+	if state := d.db.txFromContext(ctx); state != nil {
+		return state.tx.Exec(ctx, query, args, v)
+	}
+
+	return d.Driver.Exec(ctx, query, args, v)
+}
+
+func (d *txDriver) Query(ctx context.Context, query string, args, v any) error {
+	if state := d.db.txFromContext(ctx); state != nil {
+		return state.tx.Query(ctx, query, args, v)
+	}
+
+	return d.Driver.Query(ctx, query, args, v)
+}
+
+func (d *txDriver) Tx(ctx context.Context) (dialect.Tx, error) {
+	if state := d.db.txFromContext(ctx); state != nil {
+		return state.savepoint(ctx)
+	}
+
+	return d.Driver.Tx(ctx)
+}
+
+func (d *txDriver) BeginTx(ctx context.Context, opts *sql.TxOptions) (dialect.Tx, error) {
+	if state := d.db.txFromContext(ctx); state != nil {
+		return state.savepoint(ctx)
+	}
+
+	return beginTx(ctx, d.Driver, opts)
+}
@@ -0,0 +1,199 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"entgo.io/ent/dialect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// txTestDriver is a driver recording the statements it receives
+type txTestDriver struct {
+	dialect.Driver
+	stmts []string
+
+	// commitErrs are returned by the next commits
+	commitErrs []error
+}
+
+func (d *txTestDriver) Exec(_ context.Context, query string, _, _ any) error {
+	d.stmts = append(d.stmts, query)
+	return nil
+}
+
+func (d *txTestDriver) Query(_ context.Context, query string, _, _ any) error {
+	d.stmts = append(d.stmts, query)
+	return nil
+}
+
+func (d *txTestDriver) BeginTx(context.Context, *sql.TxOptions) (dialect.Tx, error) {
+	d.stmts = append(d.stmts, "BEGIN")
+	return &txTestTx{d: d}, nil
+}
+
+type txTestTx struct {
+	d *txTestDriver
+}
+
+func (t *txTestTx) Exec(_ context.Context, query string, _, _ any) error {
+	t.d.stmts = append(t.d.stmts, "tx: "+query)
+	return nil
+}
+
+func (t *txTestTx) Query(_ context.Context, query string, _, _ any) error {
+	t.d.stmts = append(t.d.stmts, "tx: "+query)
+	return nil
+}
+
+func (t *txTestTx) Commit() error {
+	t.d.stmts = append(t.d.stmts, "COMMIT")
+
+	if len(t.d.commitErrs) > 0 {
+		err := t.d.commitErrs[0]
+		t.d.commitErrs = t.d.commitErrs[1:]
+		return err
+	}
+
+	return nil
+}
+
+func (t *txTestTx) Rollback() error {
+	t.d.stmts = append(t.d.stmts, "ROLLBACK")
+	return nil
+}
+
+type txTestConnection struct {
+	driver *txTestDriver
+}
+
+func (c *txTestConnection) Driver() dialect.Driver { return c.driver }
+func (c *txTestConnection) Close() error           { return nil }
+func (c *txTestConnection) DB() *sql.DB            { return nil }
+
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return fmt.Sprintf("sqlstate %s", string(e)) }
+func (e sqlStateError) SQLState() string { return string(e) }
+
+func newTestTxDB() (*EntDB, *txTestDriver) {
+	d := &txTestDriver{}
+	return newEntDB(&txTestConnection{driver: d}), d
+}
+
+func TestEntDB_WithTx(t *testing.T) {
+	ctx := context.Background()
+	db, d := newTestTxDB()
+
+	err := db.WithTx(ctx, func(ctx context.Context) error {
+		return db.Driver().Exec(ctx, "INSERT", []any{}, nil)
+	}, nil)
+	require.NoError(t, err)
+
+	// queries outside of the transaction context do not use it
+	require.NoError(t, db.Driver().Query(ctx, "SELECT", []any{}, nil))
+
+	assert.Equal(t, []string{"BEGIN", "tx: INSERT", "COMMIT", "SELECT"}, d.stmts)
+}
+
+func TestEntDB_WithTx_Rollback(t *testing.T) {
+	db, d := newTestTxDB()
+	errFailed := errors.New("failed")
+
+	err := db.WithTx(context.Background(), func(ctx context.Context) error {
+		require.NoError(t, db.Driver().Exec(ctx, "INSERT", []any{}, nil))
+		return errFailed
+	}, nil)
+	require.ErrorIs(t, err, errFailed)
+
+	assert.Equal(t, []string{"BEGIN", "tx: INSERT", "ROLLBACK"}, d.stmts)
+
+	d.stmts = nil
+
+	assert.Panics(t, func() {
+		db.WithTx(context.Background(), func(ctx context.Context) error {
+			panic("boom")
+		}, nil)
+	})
+	assert.Equal(t, []string{"BEGIN", "ROLLBACK"}, d.stmts)
+}
+
+func TestEntDB_WithTx_Savepoints(t *testing.T) {
+	db, d := newTestTxDB()
+
+	err := db.WithTx(context.Background(), func(ctx context.Context) error {
+		err := db.WithTx(ctx, func(ctx context.Context) error {
+			require.NoError(t, db.Driver().Exec(ctx, "INSERT 1", []any{}, nil))
+			return errors.New("nested failed")
+		}, nil)
+		require.Error(t, err)
+
+		// transactions of ent clients are savepoints, too
+		tx, err := db.Driver().Tx(ctx)
+		require.NoError(t, err)
+		require.NoError(t, tx.Exec(ctx, "INSERT 2", []any{}, nil))
+		return tx.Commit()
+	}, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"BEGIN",
+		"tx: SAVEPOINT goji_savepoint_1",
+		"tx: INSERT 1",
+		"tx: ROLLBACK TO SAVEPOINT goji_savepoint_1",
+		"tx: SAVEPOINT goji_savepoint_2",
+		"tx: INSERT 2",
+		"tx: RELEASE SAVEPOINT goji_savepoint_2",
+		"COMMIT",
+	}, d.stmts)
+}
+
+func TestEntDB_WithTx_Retries(t *testing.T) {
+	opts := &TxOptions{MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	tests := []struct {
+		name     string
+		errs     []error
+		opts     *TxOptions
+		attempts int
+		err      bool
+	}{
+		{"serialization failure", []error{sqlStateError("40001")}, opts, 2, false},
+		{"deadlock", []error{sqlStateError("40P01"), sqlStateError("40P01")}, opts, 3, false},
+		{"other error", []error{sqlStateError("23505")}, opts, 1, true},
+		{"retries exhausted", []error{sqlStateError("40001"), sqlStateError("40001")}, &TxOptions{MaxRetries: 1, MinBackoff: time.Millisecond}, 2, true},
+		{"retries disabled", []error{sqlStateError("40001")}, &TxOptions{MaxRetries: -1}, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, d := newTestTxDB()
+			d.commitErrs = tt.errs
+
+			attempts := 0
+			err := db.WithTx(context.Background(), func(ctx context.Context) error {
+				attempts++
+				return nil
+			}, tt.opts)
+
+			assert.Equal(t, tt.attempts, attempts)
+			if tt.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	assert.True(t, IsRetryableError(fmt.Errorf("wrapped: %w", sqlStateError("40001"))))
+	assert.True(t, IsRetryableError(sqlStateError("40P01")))
+	assert.False(t, IsRetryableError(sqlStateError("23505")))
+	assert.False(t, IsRetryableError(errors.New("failed")))
+}
@@ -19,13 +19,15 @@ type SMTPConfig struct {
 }
 
 type MailgunConfig struct {
-	Domain  string `conf:"domain"`
-	APIKey  string `conf:"api_key"`
-	APIBase string `conf:"api_base"`
+	Domain            string `conf:"domain"`
+	APIKey            string `conf:"api_key"`
+	APIBase           string `conf:"api_base"`
+	WebhookSigningKey string `conf:"webhook_signing_key"`
 }
 
 type ResendConfig struct {
-	APIKey string `conf:"api_key"`
+	APIKey        string `conf:"api_key"`
+	WebhookSecret string `conf:"webhook_secret"`
 }
 
 type SenderConfig struct {
@@ -0,0 +1,80 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// EventType is the normalized type of a delivery event reported by a provider.
+type EventType string
+
+const (
+	// EventDelivered is emitted when the recipient server accepted the message.
+	EventDelivered EventType = "delivered"
+
+	// EventBounced is emitted when the message permanently failed to deliver.
+	EventBounced EventType = "bounced"
+
+	// EventComplained is emitted when the recipient marked the message as spam.
+	EventComplained EventType = "complained"
+
+	// EventOpened is emitted when the recipient opened the message.
+	EventOpened EventType = "opened"
+
+	// EventClicked is emitted when the recipient clicked a tracked link.
+	EventClicked EventType = "clicked"
+)
+
+var (
+	// ErrInvalidSignature is returned when a webhook signature does not match.
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+
+	// ErrUnsupportedEvent is returned for provider events that have no
+	// normalized counterpart. Webhook handlers acknowledge them silently.
+	ErrUnsupportedEvent = errors.New("unsupported webhook event")
+)
+
+// Event is a provider-independent delivery event.
+type Event struct {
+	// ID is the provider's unique identifier of the event.
+	ID string
+
+	// Type is the normalized event type.
+	Type EventType
+
+	// Driver is the provider that reported the event.
+	Driver MailDriver
+
+	// MessageID is the provider message id, as returned by `Driver.SendID`.
+	MessageID string
+
+	// Recipient is the address the event relates to.
+	Recipient string
+
+	// Timestamp is the time the event occurred at the provider.
+	Timestamp time.Time
+
+	// Reason is a human readable reason for bounces and complaints.
+	Reason string
+
+	// URL is the clicked link for click events.
+	URL string
+
+	// Raw is the unmodified event payload as received from the provider.
+	Raw []byte
+}
+
+// EventHandler handles normalized delivery events.
+type EventHandler interface {
+	HandleEvent(context.Context, *Event) error
+}
+
+// EventHandlerFunc is a function adapter for EventHandler.
+type EventHandlerFunc func(context.Context, *Event) error
+
+func (f EventHandlerFunc) HandleEvent(ctx context.Context, event *Event) error {
+	return f(ctx, event)
+}
+
+var _ = EventHandler(EventHandlerFunc(nil))
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/mailgun/mailgun-go/v5"
 	"go.uber.org/fx"
@@ -97,3 +98,15 @@ func (m *MailgunDriver) SendID(ctx context.Context, message email.Message) (stri
 
 	return res.ID, nil
 }
+
+// messageID returns the id of a Message-Id header in the form returned by
+// SendID. The API wraps the id in angle brackets, while webhooks report
+// the header without them.
+func messageID(header string) string {
+	id := strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(header), "<"), ">")
+	if id == "" {
+		return ""
+	}
+
+	return "<" + id + ">"
+}
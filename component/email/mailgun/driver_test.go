@@ -0,0 +1,47 @@
+package emailmailgun_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/fruitsco/goji/component/email"
+	emailmailgun "github.com/fruitsco/goji/component/email/mailgun"
+)
+
+func TestMailgunDriver_SendID(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v3/sandbox.mailgun.org/messages", r.URL.Path)
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"<20241019120000.1.A1B2C3D4E5F6@sandbox.mailgun.org>","message":"Queued. Thank you."}`))
+	}))
+	defer srv.Close()
+
+	d, err := emailmailgun.NewMailgunDriver(emailmailgun.MailgunDriverParams{
+		Config: &email.MailgunConfig{
+			Domain:  "sandbox.mailgun.org",
+			APIKey:  "key-test",
+			APIBase: srv.URL,
+		},
+		Log: zap.NewNop(),
+	})
+	require.NoError(t, err)
+
+	from, subject, text := "bob@sandbox.mailgun.org", "Test delivered webhook", "Hello"
+	msg := email.NewGenericMessage(&from, []string{"alice@example.com"}, &subject, &text, nil, nil, nil, nil)
+
+	id, err := d.SendID(context.Background(), msg)
+	require.NoError(t, err)
+	assert.Equal(t, "<20241019120000.1.A1B2C3D4E5F6@sandbox.mailgun.org>", id, "the id is returned as is")
+
+	// the id matches the message id of the webhook events
+	event, err := newParser(t).ParseEvent(nil, loadSignedFixture(t, "delivered.json", testSigningKey))
+	require.NoError(t, err)
+	assert.Equal(t, event.MessageID, id)
+}
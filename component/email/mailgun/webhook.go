@@ -0,0 +1,146 @@
+package emailmailgun
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mailgun/mailgun-go/v5/events"
+	"github.com/mailgun/mailgun-go/v5/mtypes"
+
+	"github.com/fruitsco/goji/component/email"
+)
+
+// webhookTolerance is the maximum age of a webhook signature. Older requests
+// are rejected to limit the window for replay attacks.
+const webhookTolerance = 5 * time.Minute
+
+// WebhookParser verifies and parses mailgun webhooks.
+// See https://documentation.mailgun.com/docs/mailgun/user-manual/tracking-messages/#securing-webhooks
+type WebhookParser struct {
+	signingKey []byte
+}
+
+var _ = email.EventParser(&WebhookParser{})
+
+// NewWebhookParser creates a new mailgun webhook parser
+func NewWebhookParser(config *email.MailgunConfig) (*WebhookParser, error) {
+	if config == nil {
+		return nil, errors.New("config is missing")
+	}
+
+	if config.WebhookSigningKey == "" {
+		return nil, errors.New("webhook signing key is empty")
+	}
+
+	return &WebhookParser{
+		signingKey: []byte(config.WebhookSigningKey),
+	}, nil
+}
+
+// NewWebhookHandler creates a http.Handler for mailgun webhooks
+func NewWebhookHandler(config *email.MailgunConfig, h email.EventHandler) (http.Handler, error) {
+	p, err := NewWebhookParser(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return email.WebhookHandler(p, h), nil
+}
+
+// ParseEvent verifies the payload signature and maps the event data
+// to a normalized email event.
+func (p *WebhookParser) ParseEvent(_ http.Header, body []byte) (*email.Event, error) {
+	var payload mtypes.WebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook payload: %w", err)
+	}
+
+	if err := p.verify(payload.Signature); err != nil {
+		return nil, err
+	}
+
+	raw, err := events.ParseEvent(payload.EventData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse event data: %w", err)
+	}
+
+	event := &email.Event{
+		ID:        raw.GetID(),
+		Driver:    email.Mailgun,
+		Timestamp: raw.GetTimestamp(),
+		Raw:       payload.EventData,
+	}
+
+	switch e := raw.(type) {
+	case *events.Delivered:
+		event.Type = email.EventDelivered
+		event.MessageID = messageID(e.Message.Headers.MessageID)
+		event.Recipient = e.Recipient
+	case *events.Failed:
+		// temporary failures are retried by mailgun,
+		// only permanent failures are considered bounces
+		if e.Severity != "permanent" {
+			return nil, fmt.Errorf("%w: %s (%s)", email.ErrUnsupportedEvent, e.GetName(), e.Severity)
+		}
+		event.Type = email.EventBounced
+		event.MessageID = messageID(e.Message.Headers.MessageID)
+		event.Recipient = e.Recipient
+		event.Reason = e.Reason
+		if e.DeliveryStatus.Message != "" {
+			event.Reason = e.DeliveryStatus.Message
+		} else if e.DeliveryStatus.Description != "" {
+			event.Reason = e.DeliveryStatus.Description
+		}
+	case *events.Complained:
+		event.Type = email.EventComplained
+		event.MessageID = messageID(e.Message.Headers.MessageID)
+		event.Recipient = e.Recipient
+	case *events.Opened:
+		event.Type = email.EventOpened
+		event.MessageID = messageID(e.Message.Headers.MessageID)
+		event.Recipient = e.Recipient
+	case *events.Clicked:
+		event.Type = email.EventClicked
+		event.MessageID = messageID(e.Message.Headers.MessageID)
+		event.Recipient = e.Recipient
+		event.URL = e.Url
+	default:
+		return nil, fmt.Errorf("%w: %s", email.ErrUnsupportedEvent, raw.GetName())
+	}
+
+	return event, nil
+}
+
+// verify checks the HMAC-SHA256 signature over timestamp and token
+func (p *WebhookParser) verify(sig mtypes.Signature) error {
+	ts, err := strconv.ParseInt(sig.TimeStamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid timestamp", email.ErrInvalidSignature)
+	}
+
+	if age := time.Since(time.Unix(ts, 0)); age > webhookTolerance || age < -webhookTolerance {
+		return fmt.Errorf("%w: timestamp outside of tolerance", email.ErrInvalidSignature)
+	}
+
+	signature, err := hex.DecodeString(sig.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: malformed signature", email.ErrInvalidSignature)
+	}
+
+	mac := hmac.New(sha256.New, p.signingKey)
+	mac.Write([]byte(sig.TimeStamp))
+	mac.Write([]byte(sig.Token))
+
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return email.ErrInvalidSignature
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package emailmailgun_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fruitsco/goji/component/email"
+	emailmailgun "github.com/fruitsco/goji/component/email/mailgun"
+)
+
+const testSigningKey = "key-test-signing"
+
+// loadSignedFixture loads a recorded webhook payload and re-signs it
+// with the test signing key and a current timestamp.
+func loadSignedFixture(t *testing.T, name string, key string) []byte {
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+
+	var payload map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(data, &payload))
+
+	var sig map[string]string
+	require.NoError(t, json.Unmarshal(payload["signature"], &sig))
+
+	sig["timestamp"] = strconv.FormatInt(time.Now().Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(sig["timestamp"] + sig["token"]))
+	sig["signature"] = hex.EncodeToString(mac.Sum(nil))
+
+	payload["signature"], err = json.Marshal(sig)
+	require.NoError(t, err)
+
+	body, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	return body
+}
+
+func newParser(t *testing.T) *emailmailgun.WebhookParser {
+	p, err := emailmailgun.NewWebhookParser(&email.MailgunConfig{
+		WebhookSigningKey: testSigningKey,
+	})
+	require.NoError(t, err)
+	return p
+}
+
+func TestWebhookParser_ParseEvent(t *testing.T) {
+	p := newParser(t)
+
+	tests := []struct {
+		fixture   string
+		eventType email.EventType
+		messageID string
+		reason    string
+		url       string
+	}{
+		{"delivered.json", email.EventDelivered, "<20241019120000.1.A1B2C3D4E5F6@sandbox.mailgun.org>", "", ""},
+		{"failed_permanent.json", email.EventBounced, "<20241019120000.2.B2C3D4E5F6A1@sandbox.mailgun.org>", "550 5.1.1 The email account that you tried to reach does not exist", ""},
+		{"complained.json", email.EventComplained, "<20241019120000.4.D4E5F6A1B2C3@sandbox.mailgun.org>", "", ""},
+		{"opened.json", email.EventOpened, "<20241019120000.5.E5F6A1B2C3D4@sandbox.mailgun.org>", "", ""},
+		{"clicked.json", email.EventClicked, "<20241019120000.6.F6A1B2C3D4E5@sandbox.mailgun.org>", "", "https://example.com/unsubscribe"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			event, err := p.ParseEvent(nil, loadSignedFixture(t, tt.fixture, testSigningKey))
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.eventType, event.Type)
+			assert.Equal(t, email.Mailgun, event.Driver)
+			assert.Equal(t, tt.messageID, event.MessageID)
+			assert.Equal(t, "alice@example.com", event.Recipient)
+			assert.Equal(t, tt.reason, event.Reason)
+			assert.Equal(t, tt.url, event.URL)
+			assert.Equal(t, int64(1729339200), event.Timestamp.Unix())
+			assert.NotEmpty(t, event.ID)
+			assert.NotEmpty(t, event.Raw)
+		})
+	}
+}
+
+func TestWebhookParser_ParseEvent_IgnoresTemporaryFailures(t *testing.T) {
+	p := newParser(t)
+
+	_, err := p.ParseEvent(nil, loadSignedFixture(t, "failed_temporary.json", testSigningKey))
+	require.ErrorIs(t, err, email.ErrUnsupportedEvent)
+}
+
+func TestWebhookParser_ParseEvent_FailsForInvalidSignature(t *testing.T) {
+	p := newParser(t)
+
+	_, err := p.ParseEvent(nil, loadSignedFixture(t, "delivered.json", "key-other"))
+	require.ErrorIs(t, err, email.ErrInvalidSignature)
+
+	// the recorded fixture carries an outdated timestamp
+	data, err := os.ReadFile(filepath.Join("testdata", "delivered.json"))
+	require.NoError(t, err)
+
+	_, err = p.ParseEvent(nil, data)
+	require.ErrorIs(t, err, email.ErrInvalidSignature)
+}
+
+func TestWebhookHandler(t *testing.T) {
+	p := newParser(t)
+
+	var received []*email.Event
+	h := email.WebhookHandler(p, email.EventHandlerFunc(func(_ context.Context, e *email.Event) error {
+		received = append(received, e)
+		return nil
+	}))
+
+	tests := []struct {
+		body   []byte
+		status int
+	}{
+		{loadSignedFixture(t, "delivered.json", testSigningKey), http.StatusOK},
+		{loadSignedFixture(t, "failed_temporary.json", testSigningKey), http.StatusOK},
+		{loadSignedFixture(t, "delivered.json", "key-other"), http.StatusUnauthorized},
+		{[]byte("not json"), http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/mailgun", bytes.NewReader(tt.body))
+
+		h.ServeHTTP(rec, req)
+
+		assert.Equal(t, tt.status, rec.Code)
+	}
+
+	require.Len(t, received, 1)
+	assert.Equal(t, email.EventDelivered, received[0].Type)
+}
@@ -0,0 +1,177 @@
+package emailresend
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fruitsco/goji/component/email"
+)
+
+// webhookTolerance is the maximum age of a webhook signature. Older requests
+// are rejected to limit the window for replay attacks.
+const webhookTolerance = 5 * time.Minute
+
+// webhookSecretPrefix is the prefix of svix signing secrets
+const webhookSecretPrefix = "whsec_"
+
+// resendEvent is the webhook payload sent by resend.
+// See https://resend.com/docs/dashboard/webhooks/event-types
+type resendEvent struct {
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      struct {
+		EmailID string   `json:"email_id"`
+		To      []string `json:"to"`
+		Bounce  *struct {
+			Message string `json:"message"`
+			Type    string `json:"type"`
+		} `json:"bounce"`
+		Click *struct {
+			Link string `json:"link"`
+		} `json:"click"`
+	} `json:"data"`
+}
+
+var resendEventTypes = map[string]email.EventType{
+	"email.delivered":  email.EventDelivered,
+	"email.bounced":    email.EventBounced,
+	"email.complained": email.EventComplained,
+	"email.opened":     email.EventOpened,
+	"email.clicked":    email.EventClicked,
+}
+
+// WebhookParser verifies and parses resend webhooks, which are
+// delivered and signed by svix.
+// See https://docs.svix.com/receiving/verifying-payloads/how-manual
+type WebhookParser struct {
+	secret []byte
+}
+
+var _ = email.EventParser(&WebhookParser{})
+
+// NewWebhookParser creates a new resend webhook parser
+func NewWebhookParser(config *email.ResendConfig) (*WebhookParser, error) {
+	if config == nil {
+		return nil, errors.New("config is missing")
+	}
+
+	if config.WebhookSecret == "" {
+		return nil, errors.New("webhook secret is empty")
+	}
+
+	secret, err := base64.StdEncoding.DecodeString(
+		strings.TrimPrefix(config.WebhookSecret, webhookSecretPrefix),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook secret: %w", err)
+	}
+
+	return &WebhookParser{secret}, nil
+}
+
+// NewWebhookHandler creates a http.Handler for resend webhooks
+func NewWebhookHandler(config *email.ResendConfig, h email.EventHandler) (http.Handler, error) {
+	p, err := NewWebhookParser(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return email.WebhookHandler(p, h), nil
+}
+
+// ParseEvent verifies the svix signature headers and maps the payload
+// to a normalized email event.
+func (p *WebhookParser) ParseEvent(header http.Header, body []byte) (*email.Event, error) {
+	id := header.Get("svix-id")
+
+	if err := p.verify(id, header.Get("svix-timestamp"), header.Get("svix-signature"), body); err != nil {
+		return nil, err
+	}
+
+	var payload resendEvent
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook payload: %w", err)
+	}
+
+	eventType, ok := resendEventTypes[payload.Type]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", email.ErrUnsupportedEvent, payload.Type)
+	}
+
+	event := &email.Event{
+		ID:        id,
+		Type:      eventType,
+		Driver:    email.Resend,
+		MessageID: payload.Data.EmailID,
+		Timestamp: payload.CreatedAt,
+		Raw:       body,
+	}
+
+	if len(payload.Data.To) > 0 {
+		event.Recipient = payload.Data.To[0]
+	}
+
+	if payload.Data.Bounce != nil {
+		// resend reports soft bounces as bounced events too,
+		// which are not considered bounces by us.
+		if payload.Data.Bounce.Type != "" && payload.Data.Bounce.Type != "Permanent" {
+			return nil, fmt.Errorf("%w: %s (%s)", email.ErrUnsupportedEvent, payload.Type, payload.Data.Bounce.Type)
+		}
+		event.Reason = payload.Data.Bounce.Message
+	}
+
+	if payload.Data.Click != nil {
+		event.URL = payload.Data.Click.Link
+	}
+
+	return event, nil
+}
+
+// verify checks the svix HMAC-SHA256 signature over id, timestamp and body
+func (p *WebhookParser) verify(id, timestamp, signatures string, body []byte) error {
+	if id == "" || timestamp == "" || signatures == "" {
+		return fmt.Errorf("%w: missing svix headers", email.ErrInvalidSignature)
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid timestamp", email.ErrInvalidSignature)
+	}
+
+	if age := time.Since(time.Unix(ts, 0)); age > webhookTolerance || age < -webhookTolerance {
+		return fmt.Errorf("%w: timestamp outside of tolerance", email.ErrInvalidSignature)
+	}
+
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte(id + "." + timestamp + "."))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+
+	// the header may contain multiple space separated signatures,
+	// e.g. during secret rotation. each one is prefixed w/ the version.
+	for _, versioned := range strings.Fields(signatures) {
+		version, sig, ok := strings.Cut(versioned, ",")
+		if !ok || version != "v1" {
+			continue
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(sig)
+		if err != nil {
+			continue
+		}
+
+		if hmac.Equal(decoded, expected) {
+			return nil
+		}
+	}
+
+	return email.ErrInvalidSignature
+}
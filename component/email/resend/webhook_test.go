@@ -0,0 +1,157 @@
+package emailresend_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fruitsco/goji/component/email"
+	emailresend "github.com/fruitsco/goji/component/email/resend"
+)
+
+var testSecret = []byte("resend-test-webhook-secret")
+
+func loadFixture(t *testing.T, name string) []byte {
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	return data
+}
+
+// signHeader creates the svix headers for the given body
+func signHeader(id string, ts time.Time, secret []byte, body []byte) http.Header {
+	timestamp := strconv.FormatInt(ts.Unix(), 10)
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(id + "." + timestamp + "."))
+	mac.Write(body)
+
+	header := http.Header{}
+	header.Set("svix-id", id)
+	header.Set("svix-timestamp", timestamp)
+	header.Set("svix-signature", "v1,invalid v1,"+base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	return header
+}
+
+func newParser(t *testing.T) *emailresend.WebhookParser {
+	p, err := emailresend.NewWebhookParser(&email.ResendConfig{
+		WebhookSecret: "whsec_" + base64.StdEncoding.EncodeToString(testSecret),
+	})
+	require.NoError(t, err)
+	return p
+}
+
+func TestWebhookParser_ParseEvent(t *testing.T) {
+	p := newParser(t)
+
+	tests := []struct {
+		fixture   string
+		eventType email.EventType
+		recipient string
+		reason    string
+		url       string
+	}{
+		{"delivered.json", email.EventDelivered, "delivered@resend.dev", "", ""},
+		{"bounced.json", email.EventBounced, "bounced@resend.dev", "The recipient's email address is on the suppression list because it has a recent history of producing hard bounces.", ""},
+		{"complained.json", email.EventComplained, "complained@resend.dev", "", ""},
+		{"opened.json", email.EventOpened, "delivered@resend.dev", "", ""},
+		{"clicked.json", email.EventClicked, "delivered@resend.dev", "", "https://resend.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			body := loadFixture(t, tt.fixture)
+
+			event, err := p.ParseEvent(signHeader("msg_test", time.Now(), testSecret, body), body)
+			require.NoError(t, err)
+
+			assert.Equal(t, "msg_test", event.ID)
+			assert.Equal(t, tt.eventType, event.Type)
+			assert.Equal(t, email.Resend, event.Driver)
+			assert.Equal(t, "56761188-7520-42d8-8898-ff6fc54ce618", event.MessageID)
+			assert.Equal(t, tt.recipient, event.Recipient)
+			assert.Equal(t, tt.reason, event.Reason)
+			assert.Equal(t, tt.url, event.URL)
+			assert.Equal(t, int64(1729339200), event.Timestamp.Unix())
+		})
+	}
+}
+
+func TestWebhookParser_ParseEvent_IgnoresUnsupportedEvents(t *testing.T) {
+	p := newParser(t)
+
+	for _, fixture := range []string{"sent.json", "bounced_transient.json"} {
+		body := loadFixture(t, fixture)
+
+		_, err := p.ParseEvent(signHeader("msg_test", time.Now(), testSecret, body), body)
+		require.ErrorIs(t, err, email.ErrUnsupportedEvent, fixture)
+	}
+}
+
+func TestWebhookParser_ParseEvent_FailsForInvalidSignature(t *testing.T) {
+	p := newParser(t)
+
+	body := loadFixture(t, "delivered.json")
+
+	tests := map[string]http.Header{
+		"wrong secret":   signHeader("msg_test", time.Now(), []byte("other"), body),
+		"stale":          signHeader("msg_test", time.Now().Add(-time.Hour), testSecret, body),
+		"missing header": {},
+	}
+
+	for name, header := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := p.ParseEvent(header, body)
+			require.ErrorIs(t, err, email.ErrInvalidSignature)
+		})
+	}
+
+	t.Run("tampered body", func(t *testing.T) {
+		header := signHeader("msg_test", time.Now(), testSecret, body)
+		_, err := p.ParseEvent(header, loadFixture(t, "bounced.json"))
+		require.ErrorIs(t, err, email.ErrInvalidSignature)
+	})
+}
+
+func TestWebhookHandler(t *testing.T) {
+	p := newParser(t)
+
+	h := email.WebhookHandler(p, email.EventHandlerFunc(func(_ context.Context, e *email.Event) error {
+		if e.Type == email.EventBounced {
+			return assert.AnError
+		}
+		return nil
+	}))
+
+	tests := []struct {
+		fixture string
+		status  int
+	}{
+		{"delivered.json", http.StatusOK},
+		{"sent.json", http.StatusOK},
+		{"bounced.json", http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		body := loadFixture(t, tt.fixture)
+
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/resend", bytes.NewReader(body))
+		req.Header = signHeader("msg_test", time.Now(), testSecret, body)
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		assert.Equal(t, tt.status, rec.Code, tt.fixture)
+	}
+}
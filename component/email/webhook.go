@@ -0,0 +1,88 @@
+package email
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/fruitsco/goji"
+)
+
+// EventParser verifies and parses a provider webhook request.
+type EventParser interface {
+	// ParseEvent verifies the signature of the request and returns the
+	// normalized event. It returns ErrInvalidSignature if the request could
+	// not be authenticated and ErrUnsupportedEvent for events that have no
+	// normalized counterpart.
+	ParseEvent(header http.Header, body []byte) (*Event, error)
+}
+
+const maxWebhookPayloadBytes = int64(1 << 20)
+
+// WebhookHandler returns a http.Handler that verifies and parses incoming
+// provider webhooks using the given parser and dispatches them to the handler.
+func WebhookHandler(p EventParser, h EventHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// prevent flooding the server with large payloads
+		r.Body = http.MaxBytesReader(w, r.Body, maxWebhookPayloadBytes)
+
+		ctx := r.Context()
+
+		log, err := goji.LoggerFromContext(ctx)
+		if err != nil {
+			log = zap.NewNop()
+		}
+
+		log = log.Named("email_webhook_handler").With(
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		)
+
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			log.Warn("error reading request body", zap.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		event, err := p.ParseEvent(r.Header, body)
+		if errors.Is(err, ErrUnsupportedEvent) {
+			// acknowledge events we do not care about, otherwise
+			// the provider would keep retrying them.
+			log.Debug("ignoring unsupported email event", zap.Error(err))
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		if errors.Is(err, ErrInvalidSignature) {
+			log.Warn("invalid email webhook signature", zap.Error(err))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			log.Warn("error parsing email event", zap.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		log = log.With(
+			zap.String("event_id", event.ID),
+			zap.String("event_type", string(event.Type)),
+			zap.String("message_id", event.MessageID),
+		)
+
+		if err := h.HandleEvent(ctx, event); err != nil {
+			log.Warn("error handling email event", zap.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	})
+}
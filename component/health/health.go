@@ -0,0 +1,204 @@
+// Package health aggregates the health checks of components, e.g. for a
+// readiness endpoint. Components provide a Checker to the registry using
+// AsChecker, which runs the checks of all components concurrently.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/fx"
+)
+
+// DefaultTimeout is the default timeout of a health check run
+const DefaultTimeout = 5 * time.Second
+
+// Status is the health status of a dependency or of the whole app
+type Status string
+
+const (
+	// StatusUp means all checks passed
+	StatusUp Status = "up"
+
+	// StatusDegraded means only optional checks failed
+	StatusDegraded Status = "degraded"
+
+	// StatusDown means a required check failed
+	StatusDown Status = "down"
+)
+
+// Check is the result of checking a single dependency
+type Check struct {
+	// Name of the dependency, e.g. `database.primary`
+	Name string `json:"name"`
+
+	// Status is either StatusUp or StatusDown
+	Status Status `json:"status"`
+
+	// Latency is the duration of the check
+	Latency time.Duration `json:"latency"`
+
+	// Error is the reason of a failed check
+	Error string `json:"error,omitempty"`
+
+	// Optional checks degrade the app if they fail, but do not
+	// fail its readiness, e.g. replicas with a fallback
+	Optional bool `json:"optional,omitempty"`
+}
+
+// MarshalJSON encodes the latency as duration string, e.g. `1.5ms`
+func (c Check) MarshalJSON() ([]byte, error) {
+	type check Check
+
+	return json.Marshal(struct {
+		check
+		Latency string `json:"latency"`
+	}{check(c), c.Latency.String()})
+}
+
+// Checker checks the health of the dependencies of a component
+type Checker interface {
+	HealthCheck(ctx context.Context) []Check
+}
+
+// CheckerFunc is a function implementing Checker
+type CheckerFunc func(ctx context.Context) []Check
+
+func (f CheckerFunc) HealthCheck(ctx context.Context) []Check {
+	return f(ctx)
+}
+
+// Run runs the check function, measuring its latency
+func Run(ctx context.Context, name string, check func(ctx context.Context) error) Check {
+	start := time.Now()
+	err := check(ctx)
+
+	c := Check{
+		Name:    name,
+		Status:  StatusUp,
+		Latency: time.Since(start),
+	}
+
+	if err != nil {
+		c.Status = StatusDown
+		c.Error = err.Error()
+	}
+
+	return c
+}
+
+// AsChecker provides T, which must be provided to the app, as a Checker
+// of the registry. T is only created if the registry is used.
+func AsChecker[T Checker]() fx.Option {
+	return fx.Provide(fx.Annotate(
+		func(c T) Checker { return c },
+		fx.ResultTags(`group:"health"`),
+	))
+}
+
+// Report is the result of running all health checks
+type Report struct {
+	Status Status  `json:"status"`
+	Checks []Check `json:"checks"`
+}
+
+// Registry runs the health checks of the registered components
+type Registry struct {
+	mu       sync.RWMutex
+	checkers []Checker
+
+	// Timeout limits the duration of a check run
+	Timeout time.Duration
+}
+
+type RegistryParams struct {
+	fx.In
+
+	// Checkers are the checkers provided using AsChecker
+	Checkers []Checker `group:"health"`
+}
+
+func NewRegistry(params RegistryParams) *Registry {
+	return &Registry{
+		checkers: params.Checkers,
+		Timeout:  DefaultTimeout,
+	}
+}
+
+// Register adds a checker to the registry
+func (r *Registry) Register(checker Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checkers = append(r.checkers, checker)
+}
+
+// Check runs the checks of all components concurrently
+func (r *Registry) Check(ctx context.Context) Report {
+	r.mu.RLock()
+	checkers := r.checkers
+	r.mu.RUnlock()
+
+	if r.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
+	}
+
+	results := make([][]Check, len(checkers))
+
+	var wg sync.WaitGroup
+	for i, checker := range checkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = checker.HealthCheck(ctx)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{
+		Status: StatusUp,
+		Checks: []Check{},
+	}
+
+	for _, checks := range results {
+		for _, c := range checks {
+			report.Checks = append(report.Checks, c)
+
+			switch {
+			case c.Status == StatusUp:
+			case c.Optional:
+				if report.Status == StatusUp {
+					report.Status = StatusDegraded
+				}
+			default:
+				report.Status = StatusDown
+			}
+		}
+	}
+
+	return report
+}
+
+// Handler is an HTTP readiness endpoint responding with the report as JSON.
+// The status code is 503 if the app is down and 200 otherwise.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		report := r.Check(req.Context())
+
+		status := http.StatusOK
+		if report.Status == StatusDown {
+			status = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+
+		json.NewEncoder(w).Encode(report)
+	})
+}
@@ -0,0 +1,70 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fruitsco/goji/component/health"
+)
+
+func checker(checks ...health.Check) health.Checker {
+	return health.CheckerFunc(func(context.Context) []health.Check {
+		return checks
+	})
+}
+
+func TestRegistry_Check(t *testing.T) {
+	up := health.Check{Name: "primary", Status: health.StatusUp}
+	down := health.Check{Name: "primary", Status: health.StatusDown, Error: "refused"}
+	optionalDown := health.Check{Name: "replica", Status: health.StatusDown, Optional: true}
+
+	tests := []struct {
+		name     string
+		checkers []health.Checker
+		status   health.Status
+		code     int
+	}{
+		{"no checks", nil, health.StatusUp, http.StatusOK},
+		{"up", []health.Checker{checker(up)}, health.StatusUp, http.StatusOK},
+		{"degraded", []health.Checker{checker(up, optionalDown)}, health.StatusDegraded, http.StatusOK},
+		{"down", []health.Checker{checker(up), checker(down, optionalDown)}, health.StatusDown, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := health.NewRegistry(health.RegistryParams{})
+			for _, c := range tt.checkers {
+				r.Register(c)
+			}
+
+			assert.Equal(t, tt.status, r.Check(context.Background()).Status)
+
+			rec := httptest.NewRecorder()
+			r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			assert.Equal(t, tt.code, rec.Code)
+
+			var body map[string]any
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, string(tt.status), body["status"])
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	c := health.Run(context.Background(), "db", func(context.Context) error {
+		return errors.New("refused")
+	})
+	assert.Equal(t, health.StatusDown, c.Status)
+	assert.Equal(t, "refused", c.Error)
+
+	c = health.Run(context.Background(), "db", func(context.Context) error { return nil })
+	assert.Equal(t, health.StatusUp, c.Status)
+	assert.Positive(t, c.Latency)
+}
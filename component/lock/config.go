@@ -0,0 +1,48 @@
+package lock
+
+import (
+	"github.com/fruitsco/goji/component/redis"
+	"github.com/fruitsco/goji/conf"
+)
+
+type DriverName string
+
+const (
+	// Redis is a driver for Redis, safe with a single master, e.g. with
+	// Sentinel, but not across independent Redis servers
+	Redis DriverName = "redis"
+
+	// Memory is an in-memory driver for development and tests
+	Memory DriverName = "memory"
+)
+
+type Config struct {
+	// Driver is the driver to use for locks
+	Driver DriverName `conf:"driver"`
+
+	// TTLSeconds is the default lease of a lock in seconds, which is
+	// extended automatically while the lock is held
+	TTLSeconds int `conf:"ttl_seconds"`
+
+	// Redis is the configuration for Redis
+	Redis *RedisConfig `conf:"redis"`
+}
+
+// DefaultConfig is the default configuration for locks
+var DefaultConfig = conf.DefaultConfig{
+	"lock.driver":                "redis",
+	"lock.ttl_seconds":           "30",
+	"lock.redis.connection_name": "default",
+	"lock.redis.key_prefix":      "lock:",
+}
+
+// MARK: - Redis
+
+// RedisConfig is the configuration for the Redis driver
+type RedisConfig struct {
+	// ConnectionName is the name of the Redis connection to use
+	ConnectionName redis.ConnectionName `conf:"connection_name"`
+
+	// KeyPrefix is prepended to the keys of the locks
+	KeyPrefix string `conf:"key_prefix"`
+}
@@ -0,0 +1,130 @@
+package lock_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fruitsco/goji/component/lock"
+	lockmemory "github.com/fruitsco/goji/component/lock/memory"
+	lockredis "github.com/fruitsco/goji/component/lock/redis"
+	"github.com/fruitsco/goji/component/redis/redistest"
+)
+
+// ttl is the lease of the locks acquired by the driver tests
+const ttl = 100 * time.Millisecond
+
+// driverTest tests a driver, advance moves the clock of its backend
+type driverTest func(t *testing.T, ctx context.Context, d lock.Driver, advance func(time.Duration))
+
+// testDrivers runs the test against the memory driver with a fake clock
+// and the redis driver with an in-memory server
+func testDrivers(t *testing.T, test driverTest) {
+	t.Run("memory", func(t *testing.T) {
+		now := time.Now()
+
+		d := lockmemory.NewMemoryDriver(lockmemory.MemoryDriverParams{
+			Now: func() time.Time { return now },
+		})
+
+		test(t, context.Background(), d, func(d time.Duration) { now = now.Add(d) })
+	})
+
+	t.Run("redis", func(t *testing.T) {
+		r, srv := redistest.New(t)
+
+		d, err := lockredis.NewRedisDriver(lockredis.RedisDriverParams{
+			Config: &lock.RedisConfig{KeyPrefix: "lock:"},
+			Redis:  r,
+		})
+		require.NoError(t, err)
+
+		test(t, context.Background(), d, srv.FastForward)
+	})
+}
+
+func TestDriver_Acquire(t *testing.T) {
+	testDrivers(t, func(t *testing.T, ctx context.Context, d lock.Driver, _ func(time.Duration)) {
+		token, err := d.Acquire(ctx, "jobs", "a", ttl)
+		require.NoError(t, err)
+		assert.Positive(t, token)
+
+		_, err = d.Acquire(ctx, "jobs", "b", ttl)
+		assert.ErrorIs(t, err, lock.ErrNotAcquired)
+
+		// the lock is not reentrant
+		_, err = d.Acquire(ctx, "jobs", "a", ttl)
+		assert.ErrorIs(t, err, lock.ErrNotAcquired)
+
+		// other keys are independent
+		_, err = d.Acquire(ctx, "reports", "b", ttl)
+		assert.NoError(t, err)
+	})
+}
+
+func TestDriver_Release(t *testing.T) {
+	testDrivers(t, func(t *testing.T, ctx context.Context, d lock.Driver, _ func(time.Duration)) {
+		_, err := d.Acquire(ctx, "jobs", "a", ttl)
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, d.Release(ctx, "jobs", "b"), lock.ErrLockLost, "only the owner releases the lock")
+		require.NoError(t, d.Release(ctx, "jobs", "a"))
+		assert.ErrorIs(t, d.Release(ctx, "jobs", "a"), lock.ErrLockLost)
+
+		_, err = d.Acquire(ctx, "jobs", "b", ttl)
+		assert.NoError(t, err)
+	})
+}
+
+func TestDriver_Extend(t *testing.T) {
+	testDrivers(t, func(t *testing.T, ctx context.Context, d lock.Driver, advance func(time.Duration)) {
+		_, err := d.Acquire(ctx, "jobs", "a", ttl)
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, d.Extend(ctx, "jobs", "b", ttl), lock.ErrLockLost, "only the owner extends the lock")
+
+		advance(ttl / 2)
+		require.NoError(t, d.Extend(ctx, "jobs", "a", ttl))
+		advance(ttl * 3 / 4)
+
+		_, err = d.Acquire(ctx, "jobs", "b", ttl)
+		assert.ErrorIs(t, err, lock.ErrNotAcquired, "the extended lock is still held")
+	})
+}
+
+func TestDriver_Expiry(t *testing.T) {
+	testDrivers(t, func(t *testing.T, ctx context.Context, d lock.Driver, advance func(time.Duration)) {
+		_, err := d.Acquire(ctx, "jobs", "a", ttl)
+		require.NoError(t, err)
+
+		advance(ttl * 2)
+
+		assert.ErrorIs(t, d.Extend(ctx, "jobs", "a", ttl), lock.ErrLockLost)
+
+		_, err = d.Acquire(ctx, "jobs", "b", ttl)
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, d.Release(ctx, "jobs", "a"), lock.ErrLockLost, "an expired owner cannot release the lock of another")
+	})
+}
+
+func TestDriver_Token(t *testing.T) {
+	testDrivers(t, func(t *testing.T, ctx context.Context, d lock.Driver, advance func(time.Duration)) {
+		first, err := d.Acquire(ctx, "jobs", "a", ttl)
+		require.NoError(t, err)
+		require.NoError(t, d.Release(ctx, "jobs", "a"))
+
+		second, err := d.Acquire(ctx, "jobs", "b", ttl)
+		require.NoError(t, err)
+		assert.Greater(t, second, first)
+
+		advance(ttl * 2)
+
+		third, err := d.Acquire(ctx, "jobs", "c", ttl)
+		require.NoError(t, err)
+		assert.Greater(t, third, second, "tokens increase after expiry")
+	})
+}
@@ -0,0 +1,16 @@
+package lock
+
+import "errors"
+
+var (
+	// ErrNotAcquired is returned if a lock is held by another owner
+	ErrNotAcquired = errors.New("lock not acquired")
+
+	// ErrLockLost is returned by drivers if the owner no longer holds the
+	// lock, because it expired. It is the cause of the canceled context
+	// of a lost lock.
+	ErrLockLost = errors.New("lock lost")
+
+	// ErrReleased is the cause of the canceled context of a released lock
+	ErrReleased = errors.New("lock released")
+)
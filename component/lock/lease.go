@@ -0,0 +1,143 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Lock is an acquired lock. Its lease is extended in the background
+// until it is released or lost.
+type Lock struct {
+	driver Driver
+	log    *zap.Logger
+
+	key   string
+	owner string
+	token int64
+	ttl   time.Duration
+
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+
+	// done is closed when the lease is no longer extended
+	done chan struct{}
+
+	release sync.Once
+}
+
+func newLock(
+	ctx context.Context,
+	driver Driver,
+	key, owner string,
+	token int64,
+	opts Options,
+	log *zap.Logger,
+) *Lock {
+	// the lock outlives the context it was acquired with, e.g. a request
+	lockCtx, cancel := context.WithCancelCause(context.WithoutCancel(ctx))
+
+	l := &Lock{
+		driver: driver,
+		log:    log.With(zap.String("key", key)),
+		key:    key,
+		owner:  owner,
+		token:  token,
+		ttl:    opts.TTL,
+		ctx:    lockCtx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	if opts.NoExtend {
+		expire := time.AfterFunc(opts.TTL, func() { cancel(ErrLockLost) })
+		context.AfterFunc(lockCtx, func() { expire.Stop() })
+		close(l.done)
+	} else {
+		go l.keepAlive()
+	}
+
+	return l
+}
+
+// Key returns the key of the lock
+func (l *Lock) Key() string {
+	return l.key
+}
+
+// Token returns the fencing token, which is greater than the tokens of
+// all previous acquisitions of the key
+func (l *Lock) Token() int64 {
+	return l.token
+}
+
+// Context returns a context, which is canceled when the lock is released
+// or lost. Its cause is ErrReleased or ErrLockLost.
+func (l *Lock) Context() context.Context {
+	return l.ctx
+}
+
+// Release stops the extension of the lease and releases the lock.
+// ErrLockLost is returned if the lock expired before. Subsequent
+// calls return nil.
+func (l *Lock) Release(ctx context.Context) error {
+	var err error
+
+	l.release.Do(func() {
+		l.cancel(ErrReleased)
+		<-l.done
+
+		err = l.driver.Release(ctx, l.key, l.owner)
+	})
+
+	return err
+}
+
+// keepAlive extends the lease every third of the TTL. The lock is lost if
+// the owner no longer holds it or if no extension succeeded within the TTL.
+func (l *Lock) keepAlive() {
+	defer close(l.done)
+
+	interval := l.ttl / 3
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	extended := time.Now()
+
+	for {
+		select {
+		case <-l.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(l.ctx, interval)
+		err := l.driver.Extend(ctx, l.key, l.owner, l.ttl)
+		cancel()
+
+		switch {
+		case err == nil:
+			extended = time.Now()
+
+		case l.ctx.Err() != nil:
+			return
+
+		case errors.Is(err, ErrLockLost):
+			l.log.Warn("lock lost")
+			l.cancel(ErrLockLost)
+			return
+
+		default:
+			l.log.Warn("failed extending lock", zap.Error(err))
+
+			if time.Since(extended) >= l.ttl {
+				l.cancel(ErrLockLost)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,234 @@
+// Package lock provides distributed locks with a lease, which is extended
+// automatically while a lock is held, and fencing tokens.
+//
+// A lock whose lease cannot be extended, e.g. after a network partition,
+// expires and may be acquired by another owner. Its context is canceled,
+// but work in flight may still reach shared resources. Resources should
+// thus reject writes with a fencing token older than the latest one seen.
+package lock
+
+import (
+	"context"
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"github.com/fruitsco/goji/x/driver"
+)
+
+const (
+	// DefaultTTL is the lease of a lock if neither the options nor the
+	// config set one
+	DefaultTTL = 30 * time.Second
+
+	// DefaultMinBackoff is the default delay before the first retry of Acquire
+	DefaultMinBackoff = 10 * time.Millisecond
+
+	// DefaultMaxBackoff is the default maximum delay between retries of Acquire
+	DefaultMaxBackoff = time.Second
+)
+
+// Driver stores the locks. Each acquisition is identified by a random owner,
+// only the owner can extend or release the lock.
+type Driver interface {
+	// Acquire acquires the lock for the owner with the given lease and
+	// returns the fencing token, which increases with each acquisition of
+	// the key. ErrNotAcquired is returned if the lock is held.
+	Acquire(ctx context.Context, key, owner string, ttl time.Duration) (int64, error)
+
+	// Extend resets the lease of the lock held by the owner. ErrLockLost
+	// is returned if the owner no longer holds the lock.
+	Extend(ctx context.Context, key, owner string, ttl time.Duration) error
+
+	// Release releases the lock held by the owner. ErrLockLost is
+	// returned if the owner no longer holds the lock.
+	Release(ctx context.Context, key, owner string) error
+}
+
+// Options configures the acquisition of a lock
+type Options struct {
+	// TTL is the lease of the lock, the configured lease if zero
+	TTL time.Duration
+
+	// NoExtend disables the extension of the lease, the lock expires
+	// after the TTL unless it is released before
+	NoExtend bool
+
+	// MinBackoff is the delay before the first retry of Acquire,
+	// DefaultMinBackoff if zero. The delay doubles with each retry.
+	MinBackoff time.Duration
+
+	// MaxBackoff is the maximum delay between retries of Acquire,
+	// DefaultMaxBackoff if zero
+	MaxBackoff time.Duration
+}
+
+func (o *Options) withDefaults(ttl time.Duration) Options {
+	var opts Options
+	if o != nil {
+		opts = *o
+	}
+
+	if opts.TTL <= 0 {
+		opts.TTL = ttl
+	}
+
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = DefaultMinBackoff
+	}
+
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = DefaultMaxBackoff
+	}
+
+	return opts
+}
+
+// backoff returns the jittered delay before the given retry
+func (o Options) backoff(attempt int) time.Duration {
+	d := o.MaxBackoff
+	if attempt < 32 {
+		d = min(o.MinBackoff<<attempt, o.MaxBackoff)
+	}
+
+	return d/2 + rand.N(d/2+1)
+}
+
+type Locker interface {
+	// Acquire waits until the lock is acquired or the context is done
+	Acquire(ctx context.Context, key string, opts *Options) (*Lock, error)
+
+	// TryAcquire acquires the lock, ErrNotAcquired is returned if it is held
+	TryAcquire(ctx context.Context, key string, opts *Options) (*Lock, error)
+
+	// WithLock runs fn while holding the lock. The context of fn is
+	// canceled if the lock is lost.
+	WithLock(ctx context.Context, key string, opts *Options, fn func(ctx context.Context) error) error
+
+	Driver(name DriverName) (Driver, error)
+}
+
+type LockParams struct {
+	fx.In
+
+	Drivers []*driver.Factory[DriverName, Driver] `group:"drivers"`
+	Config  *Config
+
+	// Log is the logger for failed lease extensions
+	Log *zap.Logger `optional:"true"`
+}
+
+type Manager struct {
+	drivers *driver.Pool[DriverName, Driver]
+	config  *Config
+	log     *zap.Logger
+}
+
+var _ = Locker(&Manager{})
+
+func New(params LockParams) Locker {
+	log := params.Log
+	if log == nil {
+		log = zap.NewNop()
+	}
+
+	return &Manager{
+		drivers: driver.NewPool(params.Drivers),
+		config:  params.Config,
+		log:     log,
+	}
+}
+
+func (m *Manager) resolveDriver() (Driver, error) {
+	return m.drivers.Resolve(m.config.Driver)
+}
+
+func (m *Manager) ttl() time.Duration {
+	if m.config.TTLSeconds > 0 {
+		return time.Duration(m.config.TTLSeconds) * time.Second
+	}
+
+	return DefaultTTL
+}
+
+func (m *Manager) Acquire(ctx context.Context, key string, opts *Options) (*Lock, error) {
+	o := opts.withDefaults(m.ttl())
+
+	for attempt := 0; ; attempt++ {
+		l, err := m.TryAcquire(ctx, key, &o)
+		if !errors.Is(err, ErrNotAcquired) {
+			return l, err
+		}
+
+		timer := time.NewTimer(o.backoff(attempt))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("%w: %w", ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
+
+func (m *Manager) TryAcquire(ctx context.Context, key string, opts *Options) (*Lock, error) {
+	d, err := m.resolveDriver()
+	if err != nil {
+		return nil, err
+	}
+
+	o := opts.withDefaults(m.ttl())
+	owner := newOwner()
+
+	token, err := d.Acquire(ctx, key, owner, o.TTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return newLock(ctx, d, key, owner, token, o, m.log), nil
+}
+
+func (m *Manager) WithLock(
+	ctx context.Context,
+	key string,
+	opts *Options,
+	fn func(ctx context.Context) error,
+) error {
+	l, err := m.Acquire(ctx, key, opts)
+	if err != nil {
+		return err
+	}
+
+	fnCtx, cancel := context.WithCancelCause(ctx)
+	stop := context.AfterFunc(l.Context(), func() {
+		cancel(context.Cause(l.Context()))
+	})
+
+	err = fn(fnCtx)
+
+	stop()
+	cancel(nil)
+
+	if rerr := l.Release(context.WithoutCancel(ctx)); rerr != nil {
+		return errors.Join(err, fmt.Errorf("failed releasing lock: %w", rerr))
+	}
+
+	return err
+}
+
+func (m *Manager) Driver(name DriverName) (Driver, error) {
+	return m.drivers.Resolve(name)
+}
+
+// newOwner returns a random owner of an acquisition
+func newOwner() string {
+	b := make([]byte, 16)
+	_, _ = cryptorand.Read(b)
+	return hex.EncodeToString(b)
+}
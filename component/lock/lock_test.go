@@ -0,0 +1,136 @@
+package lock_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fruitsco/goji/component/lock"
+	lockmemory "github.com/fruitsco/goji/component/lock/memory"
+	"github.com/fruitsco/goji/x/driver"
+)
+
+func newLocker(d lock.Driver) lock.Locker {
+	return lock.New(lock.LockParams{
+		Drivers: []*driver.Factory[lock.DriverName, lock.Driver]{
+			driver.NewFactory(lock.Memory, func() (lock.Driver, error) {
+				return d, nil
+			}).Factory,
+		},
+		Config: &lock.Config{Driver: lock.Memory},
+	})
+}
+
+// lostDriver loses all locks when they are extended
+type lostDriver struct {
+	lock.Driver
+}
+
+func (d *lostDriver) Extend(context.Context, string, string, time.Duration) error {
+	return lock.ErrLockLost
+}
+
+func TestManager_TryAcquire(t *testing.T) {
+	ctx := context.Background()
+	locker := newLocker(lockmemory.NewMemoryDriver(lockmemory.MemoryDriverParams{}))
+
+	l, err := locker.TryAcquire(ctx, "jobs", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "jobs", l.Key())
+
+	_, err = locker.TryAcquire(ctx, "jobs", nil)
+	assert.ErrorIs(t, err, lock.ErrNotAcquired)
+
+	require.NoError(t, l.Release(ctx))
+	assert.NoError(t, l.Release(ctx), "releasing twice is a no-op")
+	assert.ErrorIs(t, context.Cause(l.Context()), lock.ErrReleased)
+
+	next, err := locker.TryAcquire(ctx, "jobs", nil)
+	require.NoError(t, err)
+	defer next.Release(ctx)
+
+	assert.Greater(t, next.Token(), l.Token())
+}
+
+func TestManager_Acquire(t *testing.T) {
+	ctx := context.Background()
+	locker := newLocker(lockmemory.NewMemoryDriver(lockmemory.MemoryDriverParams{}))
+
+	held, err := locker.TryAcquire(ctx, "jobs", nil)
+	require.NoError(t, err)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+
+	_, err = locker.Acquire(timeoutCtx, "jobs", nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorIs(t, err, lock.ErrNotAcquired)
+
+	time.AfterFunc(50*time.Millisecond, func() { held.Release(ctx) })
+
+	l, err := locker.Acquire(ctx, "jobs", &lock.Options{MaxBackoff: 20 * time.Millisecond})
+	require.NoError(t, err)
+	assert.NoError(t, l.Release(ctx))
+}
+
+func TestManager_Extend(t *testing.T) {
+	ctx := context.Background()
+	locker := newLocker(lockmemory.NewMemoryDriver(lockmemory.MemoryDriverParams{}))
+
+	l, err := locker.TryAcquire(ctx, "jobs", &lock.Options{TTL: 60 * time.Millisecond})
+	require.NoError(t, err)
+
+	time.Sleep(200 * time.Millisecond)
+
+	_, err = locker.TryAcquire(ctx, "jobs", nil)
+	assert.ErrorIs(t, err, lock.ErrNotAcquired, "the lease is extended while held")
+	assert.NoError(t, l.Context().Err())
+	assert.NoError(t, l.Release(ctx))
+}
+
+func TestManager_NoExtend(t *testing.T) {
+	ctx := context.Background()
+	locker := newLocker(lockmemory.NewMemoryDriver(lockmemory.MemoryDriverParams{}))
+
+	l, err := locker.TryAcquire(ctx, "jobs", &lock.Options{TTL: 50 * time.Millisecond, NoExtend: true})
+	require.NoError(t, err)
+
+	select {
+	case <-l.Context().Done():
+	case <-time.After(time.Second):
+		t.Fatal("lock did not expire")
+	}
+
+	assert.ErrorIs(t, context.Cause(l.Context()), lock.ErrLockLost)
+	assert.ErrorIs(t, l.Release(ctx), lock.ErrLockLost)
+}
+
+func TestManager_WithLock(t *testing.T) {
+	ctx := context.Background()
+
+	locker := newLocker(lockmemory.NewMemoryDriver(lockmemory.MemoryDriverParams{}))
+
+	err := locker.WithLock(ctx, "jobs", nil, func(ctx context.Context) error {
+		_, err := locker.TryAcquire(ctx, "jobs", nil)
+		assert.ErrorIs(t, err, lock.ErrNotAcquired)
+		return errors.New("failed")
+	})
+	assert.EqualError(t, err, "failed")
+
+	l, err := locker.TryAcquire(ctx, "jobs", nil)
+	require.NoError(t, err, "the lock is released after fn returned")
+	require.NoError(t, l.Release(ctx))
+
+	// the context of fn is canceled if the lock is lost
+	locker = newLocker(&lostDriver{lockmemory.NewMemoryDriver(lockmemory.MemoryDriverParams{})})
+
+	err = locker.WithLock(ctx, "jobs", &lock.Options{TTL: 30 * time.Millisecond}, func(ctx context.Context) error {
+		<-ctx.Done()
+		return context.Cause(ctx)
+	})
+	assert.ErrorIs(t, err, lock.ErrLockLost)
+}
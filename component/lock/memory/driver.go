@@ -0,0 +1,110 @@
+package lockmemory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/fx"
+
+	"github.com/fruitsco/goji/component/lock"
+	"github.com/fruitsco/goji/x/driver"
+)
+
+// memoryLock is a lock held by an owner until it expires
+type memoryLock struct {
+	owner   string
+	expires time.Time
+}
+
+// MemoryDriver is an in-memory lock driver. Locks are only shared within
+// the process, which makes it suitable for development and tests only.
+type MemoryDriver struct {
+	now func() time.Time
+
+	mu     sync.Mutex
+	locks  map[string]memoryLock
+	tokens map[string]int64
+}
+
+// MemoryDriverParams is the parameters for the memory driver
+type MemoryDriverParams struct {
+	fx.In
+
+	// Now returns the current time, optional, defaults to time.Now
+	Now func() time.Time `optional:"true"`
+}
+
+// NewMemoryDriverFactory creates a new memory driver factory
+func NewMemoryDriverFactory(params MemoryDriverParams) driver.FactoryResult[lock.DriverName, lock.Driver] {
+	return driver.NewFactory(lock.Memory, func() (lock.Driver, error) {
+		return NewMemoryDriver(params), nil
+	})
+}
+
+// NewMemoryDriver creates a new memory driver
+func NewMemoryDriver(params MemoryDriverParams) *MemoryDriver {
+	d := &MemoryDriver{
+		now:    time.Now,
+		locks:  make(map[string]memoryLock),
+		tokens: make(map[string]int64),
+	}
+
+	if params.Now != nil {
+		d.now = params.Now
+	}
+
+	return d
+}
+
+var _ = lock.Driver(&MemoryDriver{})
+
+// Acquire acquires the lock if it is not held or expired
+func (d *MemoryDriver) Acquire(_ context.Context, key, owner string, ttl time.Duration) (int64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+
+	if l, ok := d.locks[key]; ok && now.Before(l.expires) {
+		return 0, lock.ErrNotAcquired
+	}
+
+	d.locks[key] = memoryLock{owner: owner, expires: now.Add(ttl)}
+	d.tokens[key]++
+
+	return d.tokens[key], nil
+}
+
+// Extend resets the lease of the lock if the owner holds it
+func (d *MemoryDriver) Extend(_ context.Context, key, owner string, ttl time.Duration) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.holds(key, owner) {
+		return lock.ErrLockLost
+	}
+
+	d.locks[key] = memoryLock{owner: owner, expires: d.now().Add(ttl)}
+
+	return nil
+}
+
+// Release releases the lock if the owner holds it
+func (d *MemoryDriver) Release(_ context.Context, key, owner string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.holds(key, owner) {
+		return lock.ErrLockLost
+	}
+
+	delete(d.locks, key)
+
+	return nil
+}
+
+func (d *MemoryDriver) holds(key, owner string) bool {
+	l, ok := d.locks[key]
+	return ok && l.owner == owner && d.now().Before(l.expires)
+}
@@ -0,0 +1,11 @@
+package lockmemory
+
+import (
+	"go.uber.org/fx"
+)
+
+func Module() fx.Option {
+	return fx.Options(
+		fx.Provide(NewMemoryDriverFactory),
+	)
+}
@@ -0,0 +1,16 @@
+package lock
+
+import (
+	"go.uber.org/fx"
+
+	"github.com/fruitsco/goji/x/logging"
+)
+
+func Module(cfg *Config) fx.Option {
+	return fx.Module("lock",
+		fx.Decorate(logging.NamedLogger("lock")),
+
+		fx.Supply(cfg),
+		fx.Provide(New),
+	)
+}
@@ -0,0 +1,145 @@
+package lockredis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"go.uber.org/fx"
+
+	"github.com/fruitsco/goji/component/lock"
+	"github.com/fruitsco/goji/component/redis"
+	"github.com/fruitsco/goji/x/driver"
+)
+
+// acquireScript sets the owner if the lock is not held and increments the
+// fencing token, which is kept after the lock is released
+var acquireScript = goredis.NewScript(`
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return redis.call("INCR", KEYS[2])
+end
+return 0
+`)
+
+// extendScript resets the expiry if the owner still holds the lock
+var extendScript = goredis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseScript deletes the lock if the owner still holds it
+var releaseScript = goredis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// RedisDriver is the lock driver for Redis
+type RedisDriver struct {
+	config *lock.RedisConfig
+	redis  redis.Client
+}
+
+// RedisDriverParams is the parameters for the Redis driver
+type RedisDriverParams struct {
+	fx.In
+
+	// Config is the configuration for the Redis driver
+	Config *lock.RedisConfig
+
+	// Redis is the Redis connection
+	Redis *redis.Redis
+}
+
+// NewRedisDriverFactory creates a new Redis driver factory
+func NewRedisDriverFactory(params RedisDriverParams) driver.FactoryResult[lock.DriverName, lock.Driver] {
+	return driver.NewFactory(lock.Redis, func() (lock.Driver, error) {
+		return NewRedisDriver(params)
+	})
+}
+
+// NewRedisDriver creates a new Redis driver
+func NewRedisDriver(params RedisDriverParams) (*RedisDriver, error) {
+	if params.Config == nil {
+		return nil, fmt.Errorf("config is required for Redis driver")
+	}
+
+	if params.Config.ConnectionName == "" {
+		params.Config.ConnectionName = redis.DefaultConnectionName
+	}
+
+	connection, err := params.Redis.Connection(params.Config.ConnectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RedisDriver{
+		config: params.Config,
+		redis:  connection,
+	}, nil
+}
+
+var _ = lock.Driver(&RedisDriver{})
+
+// Acquire acquires the lock using SET NX with the lease as expiry
+func (d *RedisDriver) Acquire(ctx context.Context, key, owner string, ttl time.Duration) (int64, error) {
+	token, err := acquireScript.Run(
+		ctx,
+		d.redis,
+		[]string{d.getKeyName(key), d.getTokenKeyName(key)},
+		owner,
+		ttl.Milliseconds(),
+	).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+
+	if token == 0 {
+		return 0, lock.ErrNotAcquired
+	}
+
+	return token, nil
+}
+
+// Extend resets the expiry of the lock if the owner holds it
+func (d *RedisDriver) Extend(ctx context.Context, key, owner string, ttl time.Duration) error {
+	res, err := extendScript.Run(ctx, d.redis, []string{d.getKeyName(key)}, owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		return fmt.Errorf("failed to extend lock: %w", err)
+	}
+
+	if res == 0 {
+		return lock.ErrLockLost
+	}
+
+	return nil
+}
+
+// Release deletes the lock if the owner holds it
+func (d *RedisDriver) Release(ctx context.Context, key, owner string) error {
+	res, err := releaseScript.Run(ctx, d.redis, []string{d.getKeyName(key)}, owner).Int()
+	if err != nil {
+		return fmt.Errorf("failed to release lock: %w", err)
+	}
+
+	if res == 0 {
+		return lock.ErrLockLost
+	}
+
+	return nil
+}
+
+// getKeyName returns the key of the lock. The hash tag keeps the lock and
+// its fencing token in the same slot of a Redis Cluster.
+func (d *RedisDriver) getKeyName(key string) string {
+	return d.config.KeyPrefix + "{" + key + "}"
+}
+
+// getTokenKeyName returns the key of the fencing token counter
+func (d *RedisDriver) getTokenKeyName(key string) string {
+	return d.getKeyName(key) + ":token"
+}
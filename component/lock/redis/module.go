@@ -0,0 +1,16 @@
+package lockredis
+
+import (
+	"go.uber.org/fx"
+
+	"github.com/fruitsco/goji/component/lock"
+)
+
+func Module() fx.Option {
+	return fx.Options(
+		fx.Provide(func(cfg *lock.Config) *lock.RedisConfig {
+			return cfg.Redis
+		}),
+		fx.Provide(NewRedisDriverFactory),
+	)
+}
@@ -0,0 +1,42 @@
+package ratelimit
+
+import (
+	"github.com/fruitsco/goji/component/redis"
+	"github.com/fruitsco/goji/conf"
+)
+
+type DriverName string
+
+const (
+	// Redis is a driver for Redis, sharing limits across all instances
+	Redis DriverName = "redis"
+
+	// Memory is an in-memory driver, limiting each instance on its own
+	Memory DriverName = "memory"
+)
+
+type Config struct {
+	// Driver is the driver to use for rate limits
+	Driver DriverName `conf:"driver"`
+
+	// Redis is the configuration for Redis
+	Redis *RedisConfig `conf:"redis"`
+}
+
+// DefaultConfig is the default configuration for rate limits
+var DefaultConfig = conf.DefaultConfig{
+	"ratelimit.driver":                "redis",
+	"ratelimit.redis.connection_name": "default",
+	"ratelimit.redis.key_prefix":      "ratelimit:",
+}
+
+// MARK: - Redis
+
+// RedisConfig is the configuration for the Redis driver
+type RedisConfig struct {
+	// ConnectionName is the name of the Redis connection to use
+	ConnectionName redis.ConnectionName `conf:"connection_name"`
+
+	// KeyPrefix is prepended to the keys of the limits
+	KeyPrefix string `conf:"key_prefix"`
+}
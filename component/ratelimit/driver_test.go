@@ -0,0 +1,172 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fruitsco/goji/component/ratelimit"
+	ratelimitmemory "github.com/fruitsco/goji/component/ratelimit/memory"
+	ratelimitredis "github.com/fruitsco/goji/component/ratelimit/redis"
+	"github.com/fruitsco/goji/component/redis/redistest"
+)
+
+// driverTest tests a driver, advance moves the clock the driver limits by
+type driverTest func(t *testing.T, ctx context.Context, d ratelimit.Driver, advance func(time.Duration))
+
+// testDrivers runs the test against each driver, both start with a clock
+// frozen to the current time
+func testDrivers(t *testing.T, test driverTest) {
+	t.Run("memory", func(t *testing.T) {
+		now := time.Now()
+
+		d := ratelimitmemory.NewMemoryDriver(ratelimitmemory.MemoryDriverParams{
+			Now: func() time.Time { return now },
+		})
+
+		test(t, context.Background(), d, func(d time.Duration) { now = now.Add(d) })
+	})
+
+	t.Run("redis", func(t *testing.T) {
+		r, srv := redistest.New(t)
+
+		// the scripts use the time of the server
+		now := time.Now()
+		srv.SetTime(now)
+
+		advance := func(d time.Duration) {
+			now = now.Add(d)
+			srv.SetTime(now)
+			srv.FastForward(d)
+		}
+
+		d, err := ratelimitredis.NewRedisDriver(ratelimitredis.RedisDriverParams{
+			Config: &ratelimit.RedisConfig{KeyPrefix: "ratelimit:"},
+			Redis:  r,
+		})
+		require.NoError(t, err)
+
+		test(t, context.Background(), d, advance)
+	})
+}
+
+func take(t *testing.T, ctx context.Context, d ratelimit.Driver, key string, limit ratelimit.Limit, reserve bool) ratelimit.Result {
+	t.Helper()
+
+	res, err := d.Take(ctx, key, limit.WithDefaults(), 1, reserve)
+	require.NoError(t, err)
+
+	return res
+}
+
+func TestDriver_SlidingWindow(t *testing.T) {
+	testDrivers(t, func(t *testing.T, ctx context.Context, d ratelimit.Driver, _ func(time.Duration)) {
+		limit := ratelimit.PerHour(3)
+
+		for remaining := 2; remaining >= 0; remaining-- {
+			res := take(t, ctx, d, "api", limit, false)
+			assert.True(t, res.Allowed)
+			assert.Equal(t, 3, res.Limit)
+			assert.Equal(t, remaining, res.Remaining)
+			assert.Zero(t, res.RetryAfter)
+			assert.Positive(t, res.ResetAfter)
+		}
+
+		res := take(t, ctx, d, "api", limit, false)
+		assert.False(t, res.Allowed)
+		assert.Zero(t, res.Remaining)
+		assert.Positive(t, res.RetryAfter)
+		assert.LessOrEqual(t, res.RetryAfter, 2*time.Hour)
+		assert.GreaterOrEqual(t, res.ResetAfter, res.RetryAfter)
+	})
+}
+
+func TestDriver_SlidingWindowReset(t *testing.T) {
+	testDrivers(t, func(t *testing.T, ctx context.Context, d ratelimit.Driver, advance func(time.Duration)) {
+		limit := ratelimit.Limit{Rate: 2, Period: 200 * time.Millisecond}
+
+		take(t, ctx, d, "api", limit, false)
+		take(t, ctx, d, "api", limit, false)
+
+		res := take(t, ctx, d, "api", limit, false)
+		require.False(t, res.Allowed)
+
+		// requests of the previous window expire with the next one
+		advance(450 * time.Millisecond)
+
+		res = take(t, ctx, d, "api", limit, false)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, 1, res.Remaining)
+	})
+}
+
+func TestDriver_TokenBucket(t *testing.T) {
+	testDrivers(t, func(t *testing.T, ctx context.Context, d ratelimit.Driver, _ func(time.Duration)) {
+		limit := ratelimit.Limit{Algorithm: ratelimit.TokenBucket, Rate: 1, Period: time.Hour, Burst: 3}
+
+		for remaining := 2; remaining >= 0; remaining-- {
+			res := take(t, ctx, d, "api", limit, false)
+			assert.True(t, res.Allowed)
+			assert.Equal(t, 3, res.Limit)
+			assert.Equal(t, remaining, res.Remaining)
+		}
+
+		res := take(t, ctx, d, "api", limit, false)
+		assert.False(t, res.Allowed)
+		assert.Zero(t, res.Remaining)
+		assert.InDelta(t, time.Hour, res.RetryAfter, float64(time.Minute))
+		assert.InDelta(t, 3*time.Hour, res.ResetAfter, float64(time.Minute))
+	})
+}
+
+func TestDriver_TokenBucketRefill(t *testing.T) {
+	testDrivers(t, func(t *testing.T, ctx context.Context, d ratelimit.Driver, advance func(time.Duration)) {
+		limit := ratelimit.Limit{Algorithm: ratelimit.TokenBucket, Rate: 10, Period: time.Second, Burst: 1}
+
+		require.True(t, take(t, ctx, d, "api", limit, false).Allowed)
+		require.False(t, take(t, ctx, d, "api", limit, false).Allowed)
+
+		advance(150 * time.Millisecond)
+
+		assert.True(t, take(t, ctx, d, "api", limit, false).Allowed)
+	})
+}
+
+func TestDriver_Reserve(t *testing.T) {
+	testDrivers(t, func(t *testing.T, ctx context.Context, d ratelimit.Driver, _ func(time.Duration)) {
+		for _, algorithm := range []ratelimit.Algorithm{ratelimit.SlidingWindow, ratelimit.TokenBucket} {
+			key := "api-" + string(algorithm)
+			limit := ratelimit.Limit{Algorithm: algorithm, Rate: 1, Period: time.Hour}
+
+			require.True(t, take(t, ctx, d, key, limit, true).Allowed)
+
+			first := take(t, ctx, d, key, limit, true)
+			assert.False(t, first.Allowed, algorithm)
+			assert.Positive(t, first.RetryAfter, algorithm)
+
+			// reserved requests count against the limit, the token bucket
+			// spaces them by its interval
+			second := take(t, ctx, d, key, limit, true)
+			assert.False(t, second.Allowed, algorithm)
+			if algorithm == ratelimit.TokenBucket {
+				assert.Greater(t, second.RetryAfter, first.RetryAfter, algorithm)
+			} else {
+				// the time passed between the requests shortens the delay
+				assert.GreaterOrEqual(t, second.RetryAfter+time.Second, first.RetryAfter, algorithm)
+			}
+		}
+	})
+}
+
+func TestDriver_Keys(t *testing.T) {
+	testDrivers(t, func(t *testing.T, ctx context.Context, d ratelimit.Driver, _ func(time.Duration)) {
+		limit := ratelimit.PerHour(1)
+
+		assert.True(t, take(t, ctx, d, "api-a", limit, false).Allowed)
+		assert.False(t, take(t, ctx, d, "api-a", limit, false).Allowed)
+		assert.True(t, take(t, ctx, d, "api-b", limit, false).Allowed)
+	})
+}
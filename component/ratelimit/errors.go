@@ -0,0 +1,13 @@
+package ratelimit
+
+import "errors"
+
+var (
+	// ErrLimited is returned by Wait if the limit does not allow the
+	// request before the deadline of the context
+	ErrLimited = errors.New("rate limit exceeded")
+
+	// ErrInvalidLimit is returned for limits without rate or period and for
+	// requests exceeding the capacity of the limit, which are never allowed
+	ErrInvalidLimit = errors.New("invalid rate limit")
+)
@@ -0,0 +1,208 @@
+package ratelimitmemory
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"go.uber.org/fx"
+
+	"github.com/fruitsco/goji/component/ratelimit"
+	"github.com/fruitsco/goji/x/driver"
+)
+
+// sweepInterval is the interval in which state of idle keys is removed
+const sweepInterval = time.Minute
+
+// memoryState is the state of a limit for a key, times are in nanoseconds
+type memoryState struct {
+	// tat is the theoretical arrival time of the token bucket
+	tat float64
+
+	// window is the index of the current window, prev and cur are the
+	// number of requests of the previous and current window
+	window, prev, cur float64
+
+	// expires is the time the limit is fully available again
+	expires float64
+}
+
+// MemoryDriver is an in-memory rate limit driver, which limits each
+// instance of the app on its own
+type MemoryDriver struct {
+	now func() time.Time
+
+	mu     sync.Mutex
+	states map[string]*memoryState
+	swept  time.Time
+}
+
+// MemoryDriverParams is the parameters for the memory driver
+type MemoryDriverParams struct {
+	fx.In
+
+	// Now returns the current time, optional, defaults to time.Now
+	Now func() time.Time `optional:"true"`
+}
+
+// NewMemoryDriverFactory creates a new memory driver factory
+func NewMemoryDriverFactory(params MemoryDriverParams) driver.FactoryResult[ratelimit.DriverName, ratelimit.Driver] {
+	return driver.NewFactory(ratelimit.Memory, func() (ratelimit.Driver, error) {
+		return NewMemoryDriver(params), nil
+	})
+}
+
+// NewMemoryDriver creates a new memory driver
+func NewMemoryDriver(params MemoryDriverParams) *MemoryDriver {
+	d := &MemoryDriver{
+		now:    time.Now,
+		states: make(map[string]*memoryState),
+	}
+
+	if params.Now != nil {
+		d.now = params.Now
+	}
+
+	d.swept = d.now()
+
+	return d
+}
+
+var _ = ratelimit.Driver(&MemoryDriver{})
+
+// Take takes the requests using the algorithm of the limit
+func (d *MemoryDriver) Take(
+	_ context.Context,
+	key string,
+	limit ratelimit.Limit,
+	n int,
+	reserve bool,
+) (ratelimit.Result, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	d.sweep(now)
+
+	s, ok := d.states[key]
+	if !ok {
+		s = &memoryState{}
+		d.states[key] = s
+	}
+
+	ns := float64(now.UnixNano())
+
+	var res ratelimit.Result
+	if limit.Algorithm == ratelimit.TokenBucket {
+		res = s.takeToken(ns, limit, float64(n), reserve)
+	} else {
+		res = s.takeWindow(ns, limit, float64(n), reserve)
+	}
+
+	s.expires = ns + float64(res.ResetAfter)
+
+	return res, nil
+}
+
+// sweep removes the state of keys whose limits are fully available
+func (d *MemoryDriver) sweep(now time.Time) {
+	if now.Sub(d.swept) < sweepInterval {
+		return
+	}
+
+	ns := float64(now.UnixNano())
+	for key, s := range d.states {
+		if s.expires <= ns {
+			delete(d.states, key)
+		}
+	}
+
+	d.swept = now
+}
+
+// takeToken implements the token bucket as generic cell rate algorithm
+func (s *memoryState) takeToken(now float64, limit ratelimit.Limit, n float64, reserve bool) ratelimit.Result {
+	interval := float64(limit.Period) / float64(limit.Rate)
+	burst := float64(limit.Burst) * interval
+
+	tat := max(s.tat, now)
+	next := tat + n*interval
+	allowAt := next - burst
+
+	res := ratelimit.Result{
+		Allowed: now >= allowAt,
+		Limit:   limit.Burst,
+	}
+
+	if !res.Allowed {
+		res.RetryAfter = duration(allowAt - now)
+	}
+
+	if res.Allowed || reserve {
+		s.tat = next
+	} else {
+		next = tat
+	}
+
+	res.Remaining = max(0, int(math.Floor((now-next+burst)/interval)))
+	res.ResetAfter = duration(next - now)
+
+	return res
+}
+
+// takeWindow implements the sliding window, weighting the requests of the
+// previous window by its overlap with the period ending now
+func (s *memoryState) takeWindow(now float64, limit ratelimit.Limit, n float64, reserve bool) ratelimit.Result {
+	period := float64(limit.Period)
+	rate := float64(limit.Rate)
+
+	window := math.Floor(now / period)
+	elapsed := (now - window*period) / period
+
+	if s.window != window {
+		if s.window == window-1 {
+			s.prev = s.cur
+		} else {
+			s.prev = 0
+		}
+		s.cur = 0
+		s.window = window
+	}
+
+	weighted := s.prev*(1-elapsed) + s.cur
+
+	res := ratelimit.Result{
+		Allowed: weighted+n <= rate,
+		Limit:   limit.Rate,
+	}
+
+	if !res.Allowed {
+		if s.cur+n <= rate {
+			// allowed once enough requests of the previous window left
+			res.RetryAfter = duration((1 - (rate-n-s.cur)/s.prev - elapsed) * period)
+		} else {
+			// allowed once enough requests of this window left in the next
+			res.RetryAfter = duration((1 - elapsed + 1 - (rate-n)/s.cur) * period)
+		}
+	}
+
+	if res.Allowed || reserve {
+		s.cur += n
+	}
+
+	res.Remaining = max(0, int(math.Floor(rate-s.prev*(1-elapsed)-s.cur)))
+
+	switch {
+	case s.cur > 0:
+		res.ResetAfter = duration((2 - elapsed) * period)
+	case s.prev > 0:
+		res.ResetAfter = duration((1 - elapsed) * period)
+	}
+
+	return res
+}
+
+func duration(ns float64) time.Duration {
+	return time.Duration(math.Ceil(ns))
+}
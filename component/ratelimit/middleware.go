@@ -0,0 +1,98 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// KeyFunc returns the key the requests are limited by. Requests with an
+// empty key are not limited.
+type KeyFunc func(r *http.Request) string
+
+// RemoteAddrKey limits requests by the IP address of the client. It does
+// not look at forwarding headers, these must be handled by a proxy or
+// another KeyFunc.
+func RemoteAddrKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// MiddlewareOptions configures the HTTP middleware
+type MiddlewareOptions struct {
+	// Limit is the limit of the requests of a key
+	Limit Limit
+
+	// Key returns the key of a request, RemoteAddrKey if nil
+	Key KeyFunc
+
+	// OnError is called if the limiter fails, the request is not limited
+	OnError func(r *http.Request, err error)
+}
+
+// Middleware limits the requests of a handler. The state of the limit is
+// reported by the RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and
+// RateLimit-Policy headers. Limited requests are answered with status 429
+// and a Retry-After header.
+//
+// Middleware panics if the limit is invalid, e.g. if its rate or period is
+// zero, as every request would fail to be limited otherwise.
+func Middleware(limiter Limiter, opts MiddlewareOptions) func(http.Handler) http.Handler {
+	key := opts.Key
+	if key == nil {
+		key = RemoteAddrKey
+	}
+
+	limit := opts.Limit.WithDefaults()
+	if err := limit.validate(1); err != nil {
+		panic(fmt.Errorf("invalid middleware limit: %w", err))
+	}
+
+	policy := fmt.Sprintf("%d;w=%d", limit.Capacity(), seconds(limit.Period))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			k := key(r)
+			if k == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			res, err := limiter.Allow(r.Context(), k, limit)
+			if err != nil {
+				if opts.OnError != nil {
+					opts.OnError(r, err)
+				}
+
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("RateLimit-Reset", strconv.FormatInt(seconds(res.ResetAfter), 10))
+			h.Set("RateLimit-Policy", policy)
+
+			if !res.Allowed {
+				h.Set("Retry-After", strconv.FormatInt(seconds(res.RetryAfter), 10))
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// seconds rounds the duration up to whole seconds
+func seconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
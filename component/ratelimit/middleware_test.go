@@ -0,0 +1,99 @@
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fruitsco/goji/component/ratelimit"
+)
+
+// failingLimiter fails all requests
+type failingLimiter struct {
+	ratelimit.Limiter
+}
+
+func (failingLimiter) Allow(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("unavailable")
+}
+
+var ok = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+})
+
+func serve(h http.Handler, remoteAddr string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = remoteAddr
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	return w
+}
+
+func TestMiddleware(t *testing.T) {
+	h := ratelimit.Middleware(newLimiter(), ratelimit.MiddlewareOptions{
+		Limit: ratelimit.PerMinute(2),
+	})(ok)
+
+	w := serve(h, "10.0.0.1:1234")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2;w=60", w.Header().Get("RateLimit-Policy"))
+	assert.NotEmpty(t, w.Header().Get("RateLimit-Reset"))
+
+	serve(h, "10.0.0.1:1235")
+
+	w = serve(h, "10.0.0.1:1236")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	// clients are limited by their address
+	w = serve(h, "10.0.0.2:1234")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestMiddleware_Key(t *testing.T) {
+	h := ratelimit.Middleware(newLimiter(), ratelimit.MiddlewareOptions{
+		Limit: ratelimit.PerMinute(1),
+		Key: func(r *http.Request) string {
+			return r.Header.Get("X-API-Key")
+		},
+	})(ok)
+
+	for range 3 {
+		w := serve(h, "10.0.0.1:1234")
+		assert.Equal(t, http.StatusNoContent, w.Code, "requests without key are not limited")
+		assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+	}
+}
+
+func TestMiddleware_Error(t *testing.T) {
+	var errs []error
+
+	h := ratelimit.Middleware(failingLimiter{}, ratelimit.MiddlewareOptions{
+		Limit: ratelimit.PerMinute(1),
+		OnError: func(r *http.Request, err error) {
+			errs = append(errs, err)
+		},
+	})(ok)
+
+	w := serve(h, "10.0.0.1:1234")
+	assert.Equal(t, http.StatusNoContent, w.Code, "requests are not limited if the limiter fails")
+	assert.Len(t, errs, 1)
+}
+
+func TestMiddleware_InvalidLimit(t *testing.T) {
+	for _, limit := range []ratelimit.Limit{{}, {Rate: 10}, {Period: time.Minute}} {
+		assert.Panics(t, func() {
+			ratelimit.Middleware(newLimiter(), ratelimit.MiddlewareOptions{Limit: limit})
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package ratelimit
+
+import (
+	"go.uber.org/fx"
+)
+
+func Module(cfg *Config) fx.Option {
+	return fx.Module("ratelimit",
+		fx.Supply(cfg),
+		fx.Provide(New),
+	)
+}
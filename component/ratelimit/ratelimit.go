@@ -0,0 +1,243 @@
+// Package ratelimit limits the rate of requests per key, e.g. per user or
+// API key, using a sliding window or a token bucket. Limits are shared by
+// all instances of the app with the Redis driver.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/fx"
+
+	"github.com/fruitsco/goji/x/driver"
+)
+
+// Algorithm is the algorithm of a limit
+type Algorithm string
+
+const (
+	// SlidingWindow allows Rate requests within any Period, weighting the
+	// requests of the previous window by its overlap with the period
+	SlidingWindow Algorithm = "sliding_window"
+
+	// TokenBucket allows bursts of up to Burst requests, the bucket is
+	// refilled at Rate requests per Period
+	TokenBucket Algorithm = "token_bucket"
+)
+
+// Limit is the rate requests of a key are limited to
+type Limit struct {
+	// Name keeps the requests of limits with the same keys apart, e.g. `api`
+	Name string
+
+	// Algorithm is the algorithm of the limit, SlidingWindow if empty
+	Algorithm Algorithm
+
+	// Rate is the number of requests per Period
+	Rate int
+
+	// Period is the duration of the window or the refill of the bucket
+	Period time.Duration
+
+	// Burst is the capacity of the token bucket, Rate if zero
+	Burst int
+}
+
+// PerSecond returns a sliding window limit of rate requests per second
+func PerSecond(rate int) Limit {
+	return Limit{Rate: rate, Period: time.Second}
+}
+
+// PerMinute returns a sliding window limit of rate requests per minute
+func PerMinute(rate int) Limit {
+	return Limit{Rate: rate, Period: time.Minute}
+}
+
+// PerHour returns a sliding window limit of rate requests per hour
+func PerHour(rate int) Limit {
+	return Limit{Rate: rate, Period: time.Hour}
+}
+
+// WithDefaults returns the limit with its algorithm and burst set
+func (l Limit) WithDefaults() Limit {
+	if l.Algorithm == "" {
+		l.Algorithm = SlidingWindow
+	}
+
+	if l.Burst <= 0 {
+		l.Burst = l.Rate
+	}
+
+	return l
+}
+
+// Capacity is the maximum number of requests allowed at once
+func (l Limit) Capacity() int {
+	if l.Algorithm == TokenBucket {
+		return l.Burst
+	}
+
+	return l.Rate
+}
+
+// validate checks the limit, which must have its defaults set
+func (l Limit) validate(n int) error {
+	if l.Rate <= 0 || l.Period <= 0 {
+		return fmt.Errorf("%w: rate and period must be positive", ErrInvalidLimit)
+	}
+
+	if l.Algorithm != SlidingWindow && l.Algorithm != TokenBucket {
+		return fmt.Errorf("%w: unknown algorithm %q", ErrInvalidLimit, l.Algorithm)
+	}
+
+	if n <= 0 || n > l.Capacity() {
+		return fmt.Errorf("%w: %d requests exceed the capacity of %d", ErrInvalidLimit, n, l.Capacity())
+	}
+
+	return nil
+}
+
+// Result is the state of a limit after requests were taken
+type Result struct {
+	// Allowed reports whether the requests were allowed
+	Allowed bool
+
+	// Limit is the capacity of the limit
+	Limit int
+
+	// Remaining is the number of requests allowed right away
+	Remaining int
+
+	// RetryAfter is the time until the requests are allowed, zero if allowed
+	RetryAfter time.Duration
+
+	// ResetAfter is the time until the limit is fully available again
+	ResetAfter time.Duration
+}
+
+// Reservation is a request taken in advance, which may proceed after Delay
+type Reservation struct {
+	Result
+
+	// Delay is the time to wait before acting on the reservation
+	Delay time.Duration
+}
+
+// Driver stores the state of the limits. Drivers take requests atomically.
+type Driver interface {
+	// Take takes n requests of the limit for the key if they are allowed.
+	// Reserved requests are taken even if they are not allowed yet, the
+	// RetryAfter of the result is the delay until they are allowed.
+	Take(ctx context.Context, key string, limit Limit, n int, reserve bool) (Result, error)
+}
+
+type Limiter interface {
+	// Allow takes a request if it is allowed
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+
+	// AllowN takes n requests if they are allowed
+	AllowN(ctx context.Context, key string, limit Limit, n int) (Result, error)
+
+	// Wait waits until a request is allowed and takes it. ErrLimited is
+	// returned if it would not be allowed before the deadline of the context.
+	Wait(ctx context.Context, key string, limit Limit) (Result, error)
+
+	// Reserve takes n requests, which may proceed after the delay of the
+	// reservation. The requests count against the limit even if the
+	// caller does not wait for them. The token bucket spaces reservations
+	// evenly, while the sliding window delays all reservations beyond its
+	// rate until their window left the period.
+	Reserve(ctx context.Context, key string, limit Limit, n int) (Reservation, error)
+
+	Driver(name DriverName) (Driver, error)
+}
+
+type LimiterParams struct {
+	fx.In
+
+	Drivers []*driver.Factory[DriverName, Driver] `group:"drivers"`
+	Config  *Config
+}
+
+type Manager struct {
+	drivers *driver.Pool[DriverName, Driver]
+	config  *Config
+}
+
+var _ = Limiter(&Manager{})
+
+func New(params LimiterParams) Limiter {
+	return &Manager{
+		drivers: driver.NewPool(params.Drivers),
+		config:  params.Config,
+	}
+}
+
+func (m *Manager) resolveDriver() (Driver, error) {
+	return m.drivers.Resolve(m.config.Driver)
+}
+
+func (m *Manager) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	return m.AllowN(ctx, key, limit, 1)
+}
+
+func (m *Manager) AllowN(ctx context.Context, key string, limit Limit, n int) (Result, error) {
+	return m.take(ctx, key, limit, n, false)
+}
+
+func (m *Manager) Wait(ctx context.Context, key string, limit Limit) (Result, error) {
+	for {
+		res, err := m.take(ctx, key, limit, 1, false)
+		if err != nil || res.Allowed {
+			return res, err
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < res.RetryAfter {
+			return res, ErrLimited
+		}
+
+		timer := time.NewTimer(res.RetryAfter)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return res, fmt.Errorf("%w: %w", ctx.Err(), ErrLimited)
+		case <-timer.C:
+		}
+	}
+}
+
+func (m *Manager) Reserve(ctx context.Context, key string, limit Limit, n int) (Reservation, error) {
+	res, err := m.take(ctx, key, limit, n, true)
+	if err != nil {
+		return Reservation{}, err
+	}
+
+	return Reservation{Result: res, Delay: res.RetryAfter}, nil
+}
+
+func (m *Manager) Driver(name DriverName) (Driver, error) {
+	return m.drivers.Resolve(name)
+}
+
+func (m *Manager) take(ctx context.Context, key string, limit Limit, n int, reserve bool) (Result, error) {
+	limit = limit.WithDefaults()
+	if err := limit.validate(n); err != nil {
+		return Result{}, err
+	}
+
+	d, err := m.resolveDriver()
+	if err != nil {
+		return Result{}, err
+	}
+
+	return d.Take(ctx, stateKey(limit, key), limit, n, reserve)
+}
+
+// stateKey is the key of the state of the limit for the key. Limits with
+// different algorithms keep their state apart, and the name is prefixed
+// with its length, as both the name and the key may contain colons.
+func stateKey(limit Limit, key string) string {
+	return fmt.Sprintf("%s:%d:%s:%s", limit.Algorithm, len(limit.Name), limit.Name, key)
+}
@@ -0,0 +1,115 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fruitsco/goji/component/ratelimit"
+	ratelimitmemory "github.com/fruitsco/goji/component/ratelimit/memory"
+	"github.com/fruitsco/goji/x/driver"
+)
+
+func newLimiter() ratelimit.Limiter {
+	d := ratelimitmemory.NewMemoryDriver(ratelimitmemory.MemoryDriverParams{})
+
+	return ratelimit.New(ratelimit.LimiterParams{
+		Drivers: []*driver.Factory[ratelimit.DriverName, ratelimit.Driver]{
+			driver.NewFactory(ratelimit.Memory, func() (ratelimit.Driver, error) {
+				return d, nil
+			}).Factory,
+		},
+		Config: &ratelimit.Config{Driver: ratelimit.Memory},
+	})
+}
+
+func TestManager_Allow(t *testing.T) {
+	ctx := context.Background()
+	limiter := newLimiter()
+
+	res, err := limiter.AllowN(ctx, "user:1", ratelimit.PerMinute(3), 2)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 1, res.Remaining)
+
+	res, err = limiter.AllowN(ctx, "user:1", ratelimit.PerMinute(3), 2)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+
+	res, err = limiter.Allow(ctx, "user:1", ratelimit.PerMinute(3))
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+
+	// limits with different names keep their requests apart
+	res, err = limiter.Allow(ctx, "user:1", ratelimit.Limit{Name: "uploads", Rate: 1, Period: time.Minute})
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+
+	// names and keys containing colons do not collide
+	res, err = limiter.Allow(ctx, "c", ratelimit.Limit{Name: "a:b", Rate: 1, Period: time.Minute})
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+
+	res, err = limiter.Allow(ctx, "b:c", ratelimit.Limit{Name: "a", Rate: 1, Period: time.Minute})
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+}
+
+func TestManager_InvalidLimit(t *testing.T) {
+	ctx := context.Background()
+	limiter := newLimiter()
+
+	_, err := limiter.Allow(ctx, "user:1", ratelimit.Limit{Rate: 1})
+	assert.ErrorIs(t, err, ratelimit.ErrInvalidLimit)
+
+	_, err = limiter.Allow(ctx, "user:1", ratelimit.Limit{Algorithm: "fixed", Rate: 1, Period: time.Second})
+	assert.ErrorIs(t, err, ratelimit.ErrInvalidLimit)
+
+	_, err = limiter.AllowN(ctx, "user:1", ratelimit.PerMinute(3), 4)
+	assert.ErrorIs(t, err, ratelimit.ErrInvalidLimit)
+}
+
+func TestManager_Wait(t *testing.T) {
+	ctx := context.Background()
+	limiter := newLimiter()
+	limit := ratelimit.Limit{Algorithm: ratelimit.TokenBucket, Rate: 20, Period: time.Second, Burst: 1}
+
+	_, err := limiter.Wait(ctx, "jobs", limit)
+	require.NoError(t, err)
+
+	start := time.Now()
+	res, err := limiter.Wait(ctx, "jobs", limit)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+
+	_, err = limiter.Wait(ctx, "jobs", ratelimit.PerHour(1))
+	require.NoError(t, err)
+
+	_, err = limiter.Wait(ctx, "jobs", ratelimit.PerHour(1))
+	assert.ErrorIs(t, err, ratelimit.ErrLimited)
+}
+
+func TestManager_Reserve(t *testing.T) {
+	ctx := context.Background()
+	limiter := newLimiter()
+	limit := ratelimit.Limit{Algorithm: ratelimit.TokenBucket, Rate: 1, Period: time.Minute}
+
+	r, err := limiter.Reserve(ctx, "jobs", limit, 1)
+	require.NoError(t, err)
+	assert.Zero(t, r.Delay)
+
+	r, err = limiter.Reserve(ctx, "jobs", limit, 1)
+	require.NoError(t, err)
+	assert.InDelta(t, time.Minute, r.Delay, float64(time.Second))
+
+	r, err = limiter.Reserve(ctx, "jobs", limit, 1)
+	require.NoError(t, err)
+	assert.InDelta(t, 2*time.Minute, r.Delay, float64(time.Second))
+}
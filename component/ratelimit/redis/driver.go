@@ -0,0 +1,203 @@
+package ratelimitredis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"go.uber.org/fx"
+
+	"github.com/fruitsco/goji/component/ratelimit"
+	"github.com/fruitsco/goji/component/redis"
+	"github.com/fruitsco/goji/x/driver"
+)
+
+// tokenBucketScript implements the token bucket as generic cell rate
+// algorithm, storing the theoretical arrival time in microseconds. The
+// time of the server is used, so that the clocks of the app instances
+// do not matter.
+var tokenBucketScript = goredis.NewScript(`
+local rate = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local burst = tonumber(ARGV[3])
+local n = tonumber(ARGV[4])
+local reserve = ARGV[5] == "1"
+
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+
+local interval = period / rate
+local tolerance = burst * interval
+
+local tat = tonumber(redis.call("GET", KEYS[1])) or now
+if tat < now then
+	tat = now
+end
+
+local new_tat = tat + n * interval
+local allow_at = new_tat - tolerance
+local allowed = now >= allow_at
+
+local retry_after = 0
+if not allowed then
+	retry_after = allow_at - now
+end
+
+if allowed or reserve then
+	redis.call("SET", KEYS[1], string.format("%.0f", new_tat), "PX", math.ceil((new_tat - now) / 1000))
+else
+	new_tat = tat
+end
+
+local remaining = math.floor((now - new_tat + tolerance) / interval)
+if remaining < 0 then
+	remaining = 0
+end
+
+return {allowed and 1 or 0, remaining, math.ceil(retry_after), math.ceil(new_tat - now)}
+`)
+
+// slidingWindowScript implements the sliding window, storing the index of
+// the current window and the requests of the current and previous window
+var slidingWindowScript = goredis.NewScript(`
+local rate = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local n = tonumber(ARGV[3])
+local reserve = ARGV[4] == "1"
+
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+
+local window = math.floor(now / period)
+local elapsed = (now - window * period) / period
+
+local state = redis.call("HMGET", KEYS[1], "window", "prev", "cur")
+local stored = tonumber(state[1])
+
+local prev, cur = 0, 0
+if stored == window then
+	prev = tonumber(state[2]) or 0
+	cur = tonumber(state[3]) or 0
+elseif stored == window - 1 then
+	prev = tonumber(state[3]) or 0
+end
+
+local allowed = prev * (1 - elapsed) + cur + n <= rate
+
+local retry_after = 0
+if not allowed then
+	if cur + n <= rate then
+		retry_after = (1 - (rate - n - cur) / prev - elapsed) * period
+	else
+		retry_after = (2 - elapsed - (rate - n) / cur) * period
+	end
+end
+
+if allowed or reserve then
+	cur = cur + n
+	redis.call("HSET", KEYS[1], "window", string.format("%.0f", window), "prev", prev, "cur", cur)
+	redis.call("PEXPIRE", KEYS[1], math.ceil(2 * period / 1000))
+end
+
+local remaining = math.floor(rate - prev * (1 - elapsed) - cur)
+if remaining < 0 then
+	remaining = 0
+end
+
+local reset_after = 0
+if cur > 0 then
+	reset_after = (2 - elapsed) * period
+elseif prev > 0 then
+	reset_after = (1 - elapsed) * period
+end
+
+return {allowed and 1 or 0, remaining, math.ceil(retry_after), math.ceil(reset_after)}
+`)
+
+// RedisDriver is the rate limit driver for Redis. Requests are taken
+// atomically by Lua scripts, each limit of a key is a single Redis key.
+type RedisDriver struct {
+	config *ratelimit.RedisConfig
+	redis  redis.Client
+}
+
+// RedisDriverParams is the parameters for the Redis driver
+type RedisDriverParams struct {
+	fx.In
+
+	// Config is the configuration for the Redis driver
+	Config *ratelimit.RedisConfig
+
+	// Redis is the Redis connection
+	Redis *redis.Redis
+}
+
+// NewRedisDriverFactory creates a new Redis driver factory
+func NewRedisDriverFactory(params RedisDriverParams) driver.FactoryResult[ratelimit.DriverName, ratelimit.Driver] {
+	return driver.NewFactory(ratelimit.Redis, func() (ratelimit.Driver, error) {
+		return NewRedisDriver(params)
+	})
+}
+
+// NewRedisDriver creates a new Redis driver
+func NewRedisDriver(params RedisDriverParams) (*RedisDriver, error) {
+	if params.Config == nil {
+		return nil, fmt.Errorf("config is required for Redis driver")
+	}
+
+	if params.Config.ConnectionName == "" {
+		params.Config.ConnectionName = redis.DefaultConnectionName
+	}
+
+	connection, err := params.Redis.Connection(params.Config.ConnectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RedisDriver{
+		config: params.Config,
+		redis:  connection,
+	}, nil
+}
+
+var _ = ratelimit.Driver(&RedisDriver{})
+
+// Take takes the requests using the script of the algorithm of the limit
+func (d *RedisDriver) Take(
+	ctx context.Context,
+	key string,
+	limit ratelimit.Limit,
+	n int,
+	reserve bool,
+) (ratelimit.Result, error) {
+	keys := []string{d.config.KeyPrefix + key}
+	period := limit.Period.Microseconds()
+
+	var cmd *goredis.Cmd
+	capacity := limit.Rate
+
+	if limit.Algorithm == ratelimit.TokenBucket {
+		cmd = tokenBucketScript.Run(ctx, d.redis, keys, limit.Rate, period, limit.Burst, n, reserve)
+		capacity = limit.Burst
+	} else {
+		cmd = slidingWindowScript.Run(ctx, d.redis, keys, limit.Rate, period, n, reserve)
+	}
+
+	values, err := cmd.Int64Slice()
+	if err != nil {
+		return ratelimit.Result{}, fmt.Errorf("failed to take rate limit: %w", err)
+	}
+
+	if len(values) != 4 {
+		return ratelimit.Result{}, fmt.Errorf("unexpected rate limit script result %v", values)
+	}
+
+	return ratelimit.Result{
+		Allowed:    values[0] == 1,
+		Limit:      capacity,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Microsecond,
+		ResetAfter: time.Duration(values[3]) * time.Microsecond,
+	}, nil
+}
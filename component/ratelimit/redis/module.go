@@ -0,0 +1,16 @@
+package ratelimitredis
+
+import (
+	"go.uber.org/fx"
+
+	"github.com/fruitsco/goji/component/ratelimit"
+)
+
+func Module() fx.Option {
+	return fx.Options(
+		fx.Provide(func(cfg *ratelimit.Config) *ratelimit.RedisConfig {
+			return cfg.Redis
+		}),
+		fx.Provide(NewRedisDriverFactory),
+	)
+}
@@ -8,23 +8,113 @@ const (
 	DefaultConnectionName ConnectionName = "default"
 )
 
+// Mode is the topology of a Redis deployment
+type Mode string
+
+const (
+	// ModeSingle connects to a single Redis server, the default mode
+	ModeSingle Mode = "single"
+
+	// ModeSentinel connects to the master monitored by Redis Sentinel
+	ModeSentinel Mode = "sentinel"
+
+	// ModeCluster connects to a Redis Cluster
+	ModeCluster Mode = "cluster"
+)
+
 type ConnectionConfig struct {
-	Name     string `conf:"name"`
-	Host     string `conf:"host"`
-	Port     int    `conf:"port"`
+	Name string `conf:"name"`
+
+	// Mode is the topology, one of `single`, `sentinel` and `cluster`
+	Mode Mode `conf:"mode"`
+
+	Host string `conf:"host"`
+	Port int    `conf:"port"`
+
+	// Addrs are the `host:port` addresses of the sentinels or the cluster
+	// nodes, host and port are used if empty
+	Addrs []string `conf:"addrs"`
+
+	// MasterName is the name of the master monitored by the sentinels
+	MasterName string `conf:"master_name"`
+
+	// SentinelUsername and SentinelPassword authenticate with the sentinels
+	SentinelUsername string `conf:"sentinel_username"`
+	SentinelPassword string `conf:"sentinel_password"`
+
+	// Username is the ACL user, the `default` user is used if empty
+	Username string `conf:"username"`
 	Password string `conf:"password"`
-	DB       int    `conf:"db"`
+
+	// DB is the database index, which must be zero in cluster mode
+	DB int `conf:"db"`
+
+	// TLS enables TLS, which is implied by the other tls_* keys
+	TLS           bool   `conf:"tls"`
+	TLSCACert     string `conf:"tls_ca_cert"`
+	TLSClientCert string `conf:"tls_client_cert"`
+	TLSClientKey  string `conf:"tls_client_key"`
+
+	// TLSSkipVerify disables the verification of the server certificate
+	TLSSkipVerify bool `conf:"tls_skip_verify"`
+
+	// DialTimeoutSeconds, ReadTimeoutSeconds and WriteTimeoutSeconds are
+	// the timeouts of the connections, the go-redis defaults are used if zero
+	DialTimeoutSeconds  int `conf:"dial_timeout_seconds"`
+	ReadTimeoutSeconds  int `conf:"read_timeout_seconds"`
+	WriteTimeoutSeconds int `conf:"write_timeout_seconds"`
+
+	// MaxRetries is the number of retries of a failed command, the go-redis
+	// default is used if zero and retries are disabled if negative
+	MaxRetries int `conf:"max_retries"`
+
+	// PoolSize is the maximum number of connections per node, the go-redis
+	// default of 10 per CPU is used if zero
+	PoolSize int `conf:"pool_size"`
+
+	// MinIdleConnections and MaxIdleConnections bound the number of idle
+	// connections kept open per node
+	MinIdleConnections int `conf:"min_idle_connections"`
+	MaxIdleConnections int `conf:"max_idle_connections"`
+
+	// PoolTimeoutSeconds is how long a command waits for a connection if all
+	// are busy, the read timeout plus one second if zero
+	PoolTimeoutSeconds int `conf:"pool_timeout_seconds"`
+
+	// MaxIdleSeconds closes connections idle for longer than the given seconds
+	MaxIdleSeconds int `conf:"max_idle_seconds"`
+
+	// MaxLifetimeSeconds closes connections older than the given seconds
+	MaxLifetimeSeconds int `conf:"max_lifetime_seconds"`
+
+	// ReadOnly sends read commands to replicas in cluster mode and connects
+	// to a replica instead of the master in sentinel mode
+	ReadOnly bool `conf:"read_only"`
+
+	// RouteByLatency and RouteRandomly send read commands to the closest or
+	// a random node in cluster and sentinel mode, they imply ReadOnly
+	RouteByLatency bool `conf:"route_by_latency"`
+	RouteRandomly  bool `conf:"route_randomly"`
 }
 
 type Config struct {
 	DefaultConnection ConnectionName `conf:"connection"`
 
 	Connections map[ConnectionName]*ConnectionConfig `conf:"connections"`
+
+	// PingOnStart pings the default connection when the app starts, failing
+	// the start if it is unreachable
+	PingOnStart bool `conf:"ping_on_start"`
+
+	// PingTimeoutSeconds is the timeout of the ping on start, unlimited if zero
+	PingTimeoutSeconds int `conf:"ping_timeout_seconds"`
 }
 
 var DefaultConfig = conf.DefaultConfig{
 	"redis.connection":                   "default",
+	"redis.ping_timeout_seconds":         "3",
 	"redis.connections.default.name":     "default",
+	"redis.connections.default.mode":     "single",
 	"redis.connections.default.host":     "localhost",
 	"redis.connections.default.port":     "6379",
 	"redis.connections.default.password": "",
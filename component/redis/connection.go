@@ -0,0 +1,129 @@
+package redis
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// NewConnection creates the client for the mode of the config. Clients
+// connect lazily, the config is only validated.
+func NewConnection(config *ConnectionConfig) (Client, error) {
+	if config == nil {
+		return nil, ErrConnectionNotConfigured
+	}
+
+	opts, err := universalOptions(config)
+	if err != nil {
+		return nil, err
+	}
+
+	switch config.Mode {
+	case "", ModeSingle:
+		return redis.NewClient(opts.Simple()), nil
+
+	case ModeSentinel:
+		if config.MasterName == "" {
+			return nil, fmt.Errorf("master name is required in sentinel mode")
+		}
+
+		// the failover client connects to the master or a single replica,
+		// routing reads to any replica requires the cluster client
+		if config.RouteByLatency || config.RouteRandomly {
+			return redis.NewFailoverClusterClient(opts.Failover()), nil
+		}
+
+		return redis.NewFailoverClient(opts.Failover()), nil
+
+	case ModeCluster:
+		if config.DB != 0 {
+			return nil, fmt.Errorf("cluster mode only supports db 0, got %d", config.DB)
+		}
+
+		return redis.NewClusterClient(opts.Cluster()), nil
+	}
+
+	return nil, fmt.Errorf("invalid mode %q, expected %s, %s or %s", config.Mode, ModeSingle, ModeSentinel, ModeCluster)
+}
+
+func universalOptions(config *ConnectionConfig) (*redis.UniversalOptions, error) {
+	tlsConfig, err := newTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs := config.Addrs
+	if len(addrs) == 0 || config.Mode == "" || config.Mode == ModeSingle {
+		addrs = []string{net.JoinHostPort(config.Host, strconv.Itoa(config.Port))}
+	}
+
+	return &redis.UniversalOptions{
+		Addrs:            addrs,
+		MasterName:       config.MasterName,
+		SentinelUsername: config.SentinelUsername,
+		SentinelPassword: config.SentinelPassword,
+		Username:         config.Username,
+		Password:         config.Password,
+		DB:               config.DB,
+		TLSConfig:        tlsConfig,
+		DialTimeout:      seconds(config.DialTimeoutSeconds),
+		ReadTimeout:      seconds(config.ReadTimeoutSeconds),
+		WriteTimeout:     seconds(config.WriteTimeoutSeconds),
+		MaxRetries:       config.MaxRetries,
+		PoolSize:         config.PoolSize,
+		MinIdleConns:     config.MinIdleConnections,
+		MaxIdleConns:     config.MaxIdleConnections,
+		PoolTimeout:      seconds(config.PoolTimeoutSeconds),
+		ConnMaxIdleTime:  seconds(config.MaxIdleSeconds),
+		ConnMaxLifetime:  seconds(config.MaxLifetimeSeconds),
+		ReadOnly:         config.ReadOnly,
+		RouteByLatency:   config.RouteByLatency,
+		RouteRandomly:    config.RouteRandomly,
+	}, nil
+}
+
+// newTLSConfig returns the TLS config of the connection, nil if TLS is disabled
+func newTLSConfig(config *ConnectionConfig) (*tls.Config, error) {
+	if !config.TLS && config.TLSCACert == "" && config.TLSClientCert == "" && !config.TLSSkipVerify {
+		return nil, nil
+	}
+
+	// the server name is derived from the address of each node
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: config.TLSSkipVerify,
+	}
+
+	if config.TLSCACert != "" {
+		pem, err := os.ReadFile(config.TLSCACert)
+		if err != nil {
+			return nil, fmt.Errorf("failed reading tls ca cert: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in tls ca cert %s", config.TLSCACert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.TLSClientCert != "" {
+		cert, err := tls.LoadX509KeyPair(config.TLSClientCert, config.TLSClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed loading tls client cert: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+func seconds(s int) time.Duration {
+	return time.Duration(s) * time.Second
+}
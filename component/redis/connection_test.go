@@ -0,0 +1,90 @@
+package redis_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fruitsco/goji/component/redis"
+)
+
+func TestNewConnection_Single(t *testing.T) {
+	srv := miniredis.RunT(t)
+	srv.RequireUserAuth("fruits", "secret")
+
+	client, err := redis.NewConnection(&redis.ConnectionConfig{
+		Host:     srv.Host(),
+		Port:     srv.Server().Addr().Port,
+		Username: "fruits",
+		Password: "secret",
+	})
+	require.NoError(t, err)
+	defer client.Close()
+
+	assert.IsType(t, &goredis.Client{}, client)
+	assert.NoError(t, client.Ping(context.Background()).Err())
+}
+
+func TestNewConnection_Modes(t *testing.T) {
+	tests := []struct {
+		name   string
+		config redis.ConnectionConfig
+		client redis.Client
+		err    bool
+	}{
+		{
+			name:   "sentinel",
+			config: redis.ConnectionConfig{Mode: redis.ModeSentinel, Addrs: []string{"sentinel:26379"}, MasterName: "main"},
+			client: &goredis.Client{},
+		},
+		{
+			name:   "sentinel routing",
+			config: redis.ConnectionConfig{Mode: redis.ModeSentinel, Addrs: []string{"sentinel:26379"}, MasterName: "main", RouteRandomly: true},
+			client: &goredis.ClusterClient{},
+		},
+		{
+			name:   "sentinel without master",
+			config: redis.ConnectionConfig{Mode: redis.ModeSentinel, Addrs: []string{"sentinel:26379"}},
+			err:    true,
+		},
+		{
+			name:   "cluster",
+			config: redis.ConnectionConfig{Mode: redis.ModeCluster, Addrs: []string{"node-1:6379", "node-2:6379"}},
+			client: &goredis.ClusterClient{},
+		},
+		{
+			name:   "cluster db",
+			config: redis.ConnectionConfig{Mode: redis.ModeCluster, Host: "node-1", Port: 6379, DB: 1},
+			err:    true,
+		},
+		{
+			name:   "invalid mode",
+			config: redis.ConnectionConfig{Mode: "ring"},
+			err:    true,
+		},
+		{
+			name:   "missing tls ca cert",
+			config: redis.ConnectionConfig{Host: "localhost", Port: 6379, TLSCACert: "/nonexistent/ca.pem"},
+			err:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := redis.NewConnection(&tt.config)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			defer client.Close()
+
+			assert.IsType(t, tt.client, client)
+		})
+	}
+}
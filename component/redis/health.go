@@ -0,0 +1,63 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fruitsco/goji/component/health"
+)
+
+var _ = health.Checker(&Redis{})
+
+// HealthCheck pings each connection used so far concurrently, configured
+// connections which are never used are not checked
+func (r *Redis) HealthCheck(ctx context.Context) []health.Check {
+	connections := r.resolvedConnections()
+
+	names := make([]ConnectionName, 0, len(connections))
+	for name := range connections {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	checks := make([]health.Check, len(names))
+
+	var wg sync.WaitGroup
+
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checks[i] = health.Run(ctx, "redis."+string(name), func(ctx context.Context) error {
+				return connections[name].Ping(ctx).Err()
+			})
+		}()
+	}
+
+	wg.Wait()
+
+	return checks
+}
+
+// ping pings the default connection
+func (r *Redis) ping(ctx context.Context) error {
+	conn, err := r.Default()
+	if err != nil {
+		return err
+	}
+
+	if timeout := time.Duration(r.config.PingTimeoutSeconds) * time.Second; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if err := conn.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to ping redis: %w", err)
+	}
+
+	return nil
+}
@@ -2,15 +2,19 @@ package redis
 
 import (
 	"go.uber.org/fx"
+
+	"github.com/fruitsco/goji/component/health"
 )
 
 func Module(config *Config) fx.Option {
 	return fx.Module("redis",
 		fx.Supply(config),
-		fx.Provide(New),
+		fx.Provide(NewLifecycleRedis),
 
-		fx.Provide(func(redis Redis) (*Client, error) {
+		fx.Provide(func(redis *Redis) (Client, error) {
 			return redis.Default()
 		}),
+
+		health.AsChecker[*Redis](),
 	)
 }
@@ -1,30 +1,28 @@
 package redis
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/fx"
 )
 
-type Client = redis.Client
+// Client is a single node, sentinel or cluster client, depending on the
+// mode of the connection
+type Client = redis.UniversalClient
 
 var (
 	ErrConnectionNotConfigured = errors.New("connection not configured")
 )
 
-func NewConnection(config *ConnectionConfig) *Client {
-	return redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%d", config.Host, config.Port),
-		Password: config.Password,
-		DB:       config.DB,
-	})
-}
-
 type Redis struct {
-	config      *Config
-	connections map[ConnectionName]*Client
+	config *Config
+
+	mu          sync.Mutex
+	connections map[ConnectionName]Client
 }
 
 type RedisParams struct {
@@ -33,25 +31,55 @@ type RedisParams struct {
 	Config *Config
 }
 
-func New(params RedisParams) *Redis {
-	// init default connections
-	connections := map[ConnectionName]*Client{
-		(DefaultConnectionName): NewConnection(params.Config.Connections[params.Config.DefaultConnection]),
+// New creates the connection manager, connections are created on first use
+func New(params RedisParams) (*Redis, error) {
+	if params.Config == nil {
+		return nil, fmt.Errorf("no redis config provided")
 	}
 
 	return &Redis{
 		config:      params.Config,
-		connections: connections,
+		connections: make(map[ConnectionName]Client),
+	}, nil
+}
+
+// NewLifecycleRedis creates the connection manager, pings the default
+// connection on start if configured and closes all connections on stop
+func NewLifecycleRedis(lc fx.Lifecycle, params RedisParams) (*Redis, error) {
+	r, err := New(params)
+	if err != nil {
+		return nil, err
 	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			if !params.Config.PingOnStart {
+				return nil
+			}
+
+			return r.ping(ctx)
+		},
+		OnStop: func(_ context.Context) error {
+			return r.Close()
+		},
+	})
+
+	return r, nil
 }
 
-func (r *Redis) resolveConnection(name ConnectionName) (*Client, error) {
+func (r *Redis) resolveConnection(name ConnectionName) (Client, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	if conn, ok := r.connections[name]; ok {
 		return conn, nil
 	}
 
 	if config, ok := r.config.Connections[name]; ok {
-		conn := NewConnection(config)
+		conn, err := NewConnection(config)
+		if err != nil {
+			return nil, err
+		}
 		r.connections[name] = conn
 		return conn, nil
 	}
@@ -59,10 +87,41 @@ func (r *Redis) resolveConnection(name ConnectionName) (*Client, error) {
 	return nil, ErrConnectionNotConfigured
 }
 
-func (r *Redis) Default() (*Client, error) {
+func (r *Redis) Default() (Client, error) {
 	return r.resolveConnection(r.config.DefaultConnection)
 }
 
-func (r *Redis) Connection(name ConnectionName) (*Client, error) {
+func (r *Redis) Connection(name ConnectionName) (Client, error) {
 	return r.resolveConnection(name)
 }
+
+// Close closes all connections, which are recreated if used again
+func (r *Redis) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var errs []error
+
+	for name, conn := range r.connections {
+		if err := conn.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed closing redis connection %s: %w", name, err))
+		}
+	}
+
+	clear(r.connections)
+
+	return errors.Join(errs...)
+}
+
+// resolvedConnections returns a copy of the connections used so far
+func (r *Redis) resolvedConnections() map[ConnectionName]Client {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	connections := make(map[ConnectionName]Client, len(r.connections))
+	for name, conn := range r.connections {
+		connections[name] = conn
+	}
+
+	return connections
+}
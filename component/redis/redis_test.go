@@ -0,0 +1,116 @@
+package redis_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx/fxtest"
+
+	"github.com/fruitsco/goji/component/health"
+	"github.com/fruitsco/goji/component/redis"
+)
+
+func newConfig(srv *miniredis.Miniredis) *redis.Config {
+	return &redis.Config{
+		DefaultConnection: redis.DefaultConnectionName,
+		Connections: map[redis.ConnectionName]*redis.ConnectionConfig{
+			redis.DefaultConnectionName: {
+				Host: srv.Host(),
+				Port: srv.Server().Addr().Port,
+			},
+			"cache": {
+				Host: srv.Host(),
+				Port: srv.Server().Addr().Port,
+				DB:   1,
+			},
+		},
+	}
+}
+
+func TestRedis_Connection(t *testing.T) {
+	srv := miniredis.RunT(t)
+
+	r, err := redis.New(redis.RedisParams{Config: newConfig(srv)})
+	require.NoError(t, err)
+	defer r.Close()
+
+	clients := make([]redis.Client, 10)
+
+	var wg sync.WaitGroup
+	for i := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			clients[i], _ = r.Connection("cache")
+		}()
+	}
+	wg.Wait()
+
+	for _, client := range clients {
+		assert.Same(t, clients[0], client)
+	}
+
+	_, err = r.Connection("sessions")
+	assert.ErrorIs(t, err, redis.ErrConnectionNotConfigured)
+}
+
+func TestRedis_HealthCheck(t *testing.T) {
+	srv := miniredis.RunT(t)
+
+	r, err := redis.New(redis.RedisParams{Config: newConfig(srv)})
+	require.NoError(t, err)
+	defer r.Close()
+
+	ctx := context.Background()
+
+	assert.Empty(t, r.HealthCheck(ctx), "unused connections are not checked")
+
+	_, err = r.Default()
+	require.NoError(t, err)
+	_, err = r.Connection("cache")
+	require.NoError(t, err)
+
+	checks := r.HealthCheck(ctx)
+	require.Len(t, checks, 2)
+	assert.Equal(t, "redis.cache", checks[0].Name)
+	assert.Equal(t, health.StatusUp, checks[0].Status)
+	assert.Equal(t, "redis.default", checks[1].Name)
+	assert.Equal(t, health.StatusUp, checks[1].Status)
+
+	srv.Close()
+
+	for _, check := range r.HealthCheck(ctx) {
+		assert.Equal(t, health.StatusDown, check.Status)
+	}
+}
+
+func TestNewLifecycleRedis(t *testing.T) {
+	srv := miniredis.RunT(t)
+
+	config := newConfig(srv)
+	config.PingOnStart = true
+
+	lc := fxtest.NewLifecycle(t)
+
+	r, err := redis.NewLifecycleRedis(lc, redis.RedisParams{Config: config})
+	require.NoError(t, err)
+
+	require.NoError(t, lc.Start(context.Background()))
+	assert.Len(t, r.HealthCheck(context.Background()), 1, "the default connection is pinged")
+
+	require.NoError(t, lc.Stop(context.Background()))
+	assert.Empty(t, r.HealthCheck(context.Background()), "connections are closed on stop")
+
+	srv.Close()
+
+	lc = fxtest.NewLifecycle(t)
+
+	_, err = redis.NewLifecycleRedis(lc, redis.RedisParams{Config: config})
+	require.NoError(t, err)
+
+	assert.Error(t, lc.Start(context.Background()))
+}
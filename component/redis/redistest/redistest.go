@@ -0,0 +1,46 @@
+// Package redistest provides redis connections to in-memory servers for
+// the tests of components backed by redis:
+//
+//	func TestDriver(t *testing.T) {
+//		r, srv := redistest.New(t)
+//		...
+//	}
+package redistest
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fruitsco/goji/component/redis"
+)
+
+// New starts an in-memory server, which is stopped at the end of the test,
+// and connects to it
+func New(t testing.TB) (*redis.Redis, *miniredis.Miniredis) {
+	srv := miniredis.RunT(t)
+
+	return Connect(t, srv), srv
+}
+
+// Connect connects the default connection to the server, the connection
+// is closed at the end of the test
+func Connect(t testing.TB, srv *miniredis.Miniredis) *redis.Redis {
+	r, err := redis.New(redis.RedisParams{
+		Config: &redis.Config{
+			DefaultConnection: redis.DefaultConnectionName,
+			Connections: map[redis.ConnectionName]*redis.ConnectionConfig{
+				redis.DefaultConnectionName: {
+					Host: srv.Host(),
+					Port: srv.Server().Addr().Port,
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	t.Cleanup(func() { r.Close() })
+
+	return r
+}
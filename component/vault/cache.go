@@ -0,0 +1,436 @@
+package vault
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// latestVersionKey is the cache key used for "latest" lookups
+const latestVersionKey = "latest"
+
+// CacheOptions configures a CachedDriver
+type CacheOptions struct {
+	// LatestTTL is the duration a "latest" lookup is cached.
+	// "latest" lookups are not cached if zero.
+	LatestTTL time.Duration
+
+	// NegativeTTL is the duration a lookup of a missing secret or version
+	// is cached. Missing secrets are not cached if zero. Other errors,
+	// e.g. network failures, are never cached.
+	NegativeTTL time.Duration
+
+	// Encrypt keeps cached payloads encrypted in memory using
+	// a random key generated when the driver is created.
+	Encrypt bool
+}
+
+// cacheEntry is a single cached lookup result
+type cacheEntry struct {
+	secret    Secret
+	err       error
+	expiresAt time.Time
+}
+
+func (e *cacheEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
+
+// CachedDriver is a Driver decorator that caches secrets in memory.
+//
+// Specific versions are immutable and cached until they are explicitly
+// invalidated, while "latest" lookups are cached for a configurable TTL.
+// Concurrent lookups of the same version are deduplicated, so that only
+// a single request hits the underlying driver.
+type CachedDriver struct {
+	driver Driver
+	opts   CacheOptions
+	aead   cipher.AEAD
+	now    func() time.Time
+
+	mu      sync.RWMutex
+	entries map[string]map[string]*cacheEntry
+
+	// generations are incremented when a secret is invalidated, so that
+	// lookups started before do not store stale results
+	generations map[string]uint64
+	epoch       uint64
+
+	group singleflight.Group
+}
+
+// generation identifies the state of the cache of a secret
+type generation struct {
+	epoch, name uint64
+}
+
+var _ = Driver(&CachedDriver{})
+
+var _ = Closer(&CachedDriver{})
+
+// NewCachedDriver wraps the given driver in a caching driver
+func NewCachedDriver(driver Driver, opts CacheOptions) (*CachedDriver, error) {
+	c := &CachedDriver{
+		driver:      driver,
+		opts:        opts,
+		now:         time.Now,
+		entries:     make(map[string]map[string]*cacheEntry),
+		generations: make(map[string]uint64),
+	}
+
+	if opts.Encrypt {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate cache key: %w", err)
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+		}
+
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create GCM cipher: %w", err)
+		}
+
+		c.aead = aead
+	}
+
+	return c, nil
+}
+
+// NewCacheOptions creates cache options from the cache configuration
+func NewCacheOptions(cfg *CacheConfig) CacheOptions {
+	return CacheOptions{
+		LatestTTL:   time.Duration(cfg.LatestTTLSeconds) * time.Second,
+		NegativeTTL: time.Duration(cfg.NegativeTTLSeconds) * time.Second,
+		Encrypt:     cfg.Encrypt,
+	}
+}
+
+// CreateSecret creates the secret and caches the first version
+func (c *CachedDriver) CreateSecret(ctx context.Context, name string, payload []byte) (Secret, error) {
+	secret, err := c.driver.CreateSecret(ctx, name, payload)
+	if err != nil {
+		return Secret{}, err
+	}
+
+	c.Invalidate(name)
+	c.storeVersion(c.generation(name), name, secret)
+
+	return secret, nil
+}
+
+// AddVersion adds a new version, invalidates the cached "latest"
+// lookup and caches the new version
+func (c *CachedDriver) AddVersion(ctx context.Context, name string, payload []byte) (Secret, error) {
+	secret, err := c.driver.AddVersion(ctx, name, payload)
+
+	// invalidate even on error, the version might have been
+	// created although the driver returned an error
+	c.invalidateLatest(name)
+
+	if err != nil {
+		return Secret{}, err
+	}
+
+	c.storeVersion(c.generation(name), name, secret)
+
+	return secret, nil
+}
+
+// GetLatestVersion returns the latest version from cache if present
+// and not expired, otherwise it is fetched from the underlying driver
+func (c *CachedDriver) GetLatestVersion(ctx context.Context, name string) (Secret, error) {
+	return c.get(ctx, name, latestVersionKey, func(ctx context.Context) (Secret, error) {
+		return c.driver.GetLatestVersion(ctx, name)
+	})
+}
+
+// GetVersion returns the version from cache if present, otherwise
+// it is fetched from the underlying driver
+func (c *CachedDriver) GetVersion(ctx context.Context, name string, version int) (Secret, error) {
+	return c.get(ctx, name, strconv.Itoa(version), func(ctx context.Context) (Secret, error) {
+		return c.driver.GetVersion(ctx, name, version)
+	})
+}
+
+// DeleteSecret deletes the secret and drops all cached versions
+func (c *CachedDriver) DeleteSecret(ctx context.Context, name string) error {
+	defer c.Invalidate(name)
+
+	return c.driver.DeleteSecret(ctx, name)
+}
+
+// ListSecrets lists the secrets of the underlying driver, it is never cached
+func (c *CachedDriver) ListSecrets(ctx context.Context) ([]SecretMetadata, error) {
+	return c.driver.ListSecrets(ctx)
+}
+
+// ListVersions lists the versions of the underlying driver, it is never cached
+func (c *CachedDriver) ListVersions(ctx context.Context, name string) ([]Secret, error) {
+	return c.driver.ListVersions(ctx, name)
+}
+
+// EnableVersion enables the version and drops all cached versions,
+// as the latest version might have changed
+func (c *CachedDriver) EnableVersion(ctx context.Context, name string, version int) error {
+	defer c.Invalidate(name)
+
+	return c.driver.EnableVersion(ctx, name, version)
+}
+
+// DisableVersion disables the version and drops all cached versions,
+// so that the disabled version is no longer served from cache
+func (c *CachedDriver) DisableVersion(ctx context.Context, name string, version int) error {
+	defer c.Invalidate(name)
+
+	return c.driver.DisableVersion(ctx, name, version)
+}
+
+// DestroyVersion destroys the version and drops all cached versions,
+// so that the destroyed payload is no longer held in memory
+func (c *CachedDriver) DestroyVersion(ctx context.Context, name string, version int) error {
+	defer c.Invalidate(name)
+
+	return c.driver.DestroyVersion(ctx, name, version)
+}
+
+// Invalidate drops all cached versions of the secret with the given name
+func (c *CachedDriver) Invalidate(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, name)
+	c.generations[name]++
+}
+
+// Purge drops all cached secrets
+func (c *CachedDriver) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]map[string]*cacheEntry)
+	c.generations = make(map[string]uint64)
+	c.epoch++
+}
+
+// Project returns the project of the underlying driver, if it has one
+func (c *CachedDriver) Project() string {
+	if projector, ok := c.driver.(Projector); ok {
+		return projector.Project()
+	}
+
+	return ""
+}
+
+// Close closes the underlying driver if it is closable
+func (c *CachedDriver) Close() error {
+	c.Purge()
+
+	if closer, ok := c.driver.(Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+func (c *CachedDriver) get(
+	ctx context.Context,
+	name, version string,
+	fetch func(context.Context) (Secret, error),
+) (Secret, error) {
+	if secret, err, ok := c.lookup(name, version); ok {
+		return secret, err
+	}
+
+	ch := c.group.DoChan(name+"#"+version, func() (any, error) {
+		// another caller might have populated the cache in the meantime
+		if secret, err, ok := c.lookup(name, version); ok {
+			return secret, err
+		}
+
+		gen := c.generation(name)
+
+		// the lookup is shared, so it must not fail
+		// because the first caller gave up
+		secret, err := fetch(context.WithoutCancel(ctx))
+		if err != nil {
+			c.storeError(gen, name, version, err)
+			return Secret{}, err
+		}
+
+		if version == latestVersionKey {
+			c.storeLatest(gen, name, secret)
+		} else {
+			c.storeVersion(gen, name, secret)
+		}
+
+		return secret, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return Secret{}, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return Secret{}, res.Err
+		}
+
+		return c.clone(res.Val.(Secret)), nil
+	}
+}
+
+// generation returns the current generation of the secret
+func (c *CachedDriver) generation(name string) generation {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return generation{epoch: c.epoch, name: c.generations[name]}
+}
+
+// lookup returns the cached secret or error and whether the entry was found
+func (c *CachedDriver) lookup(name, version string) (Secret, error, bool) {
+	c.mu.RLock()
+	entry, ok := c.entries[name][version]
+	c.mu.RUnlock()
+
+	if !ok || entry.expired(c.now()) {
+		return Secret{}, nil, false
+	}
+
+	if entry.err != nil {
+		return Secret{}, entry.err, true
+	}
+
+	secret, err := c.open(entry.secret)
+	if err != nil {
+		// a corrupt entry is treated as a cache miss
+		return Secret{}, nil, false
+	}
+
+	return secret, nil, true
+}
+
+func (c *CachedDriver) storeVersion(gen generation, name string, secret Secret) {
+	c.store(gen, name, strconv.Itoa(secret.Version), secret, nil, time.Time{})
+}
+
+func (c *CachedDriver) storeLatest(gen generation, name string, secret Secret) {
+	c.storeVersion(gen, name, secret)
+
+	if c.opts.LatestTTL > 0 {
+		c.store(gen, name, latestVersionKey, secret, nil, c.now().Add(c.opts.LatestTTL))
+	}
+}
+
+func (c *CachedDriver) storeError(gen generation, name, version string, err error) {
+	if c.opts.NegativeTTL <= 0 {
+		return
+	}
+
+	// only cache definite answers, transient failures like timeouts
+	// or permission errors must not outlive the underlying problem
+	if !IsNotFound(err) {
+		return
+	}
+
+	c.store(gen, name, version, Secret{}, err, c.now().Add(c.opts.NegativeTTL))
+}
+
+// store caches the result of a lookup, unless the secret was invalidated
+// after the generation was taken, as the result may be stale
+func (c *CachedDriver) store(gen generation, name, version string, secret Secret, err error, expiresAt time.Time) {
+	if err == nil {
+		sealed, sealErr := c.seal(secret)
+		if sealErr != nil {
+			return
+		}
+		secret = sealed
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gen != (generation{epoch: c.epoch, name: c.generations[name]}) {
+		return
+	}
+
+	versions, ok := c.entries[name]
+	if !ok {
+		versions = make(map[string]*cacheEntry)
+		c.entries[name] = versions
+	}
+
+	versions[version] = &cacheEntry{
+		secret:    secret,
+		err:       err,
+		expiresAt: expiresAt,
+	}
+}
+
+func (c *CachedDriver) invalidateLatest(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries[name], latestVersionKey)
+	c.generations[name]++
+}
+
+// seal returns a copy of the secret with an encrypted payload if
+// encryption is enabled, otherwise a plain copy
+func (c *CachedDriver) seal(secret Secret) (Secret, error) {
+	if c.aead == nil {
+		return c.clone(secret), nil
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return Secret{}, err
+	}
+
+	secret.Payload = c.aead.Seal(nonce, nonce, secret.Payload, nil)
+
+	return secret, nil
+}
+
+// open returns a copy of the secret with a decrypted payload
+func (c *CachedDriver) open(secret Secret) (Secret, error) {
+	if c.aead == nil {
+		return c.clone(secret), nil
+	}
+
+	nonceSize := c.aead.NonceSize()
+	if len(secret.Payload) < nonceSize {
+		return Secret{}, errors.New("cached payload too short")
+	}
+
+	nonce, ciphertext := secret.Payload[:nonceSize], secret.Payload[nonceSize:]
+
+	payload, err := c.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return Secret{}, err
+	}
+
+	secret.Payload = payload
+
+	return secret, nil
+}
+
+// clone copies the secret, so that callers cannot mutate cached payloads
+func (c *CachedDriver) clone(secret Secret) Secret {
+	if secret.Payload != nil {
+		secret.Payload = append([]byte(nil), secret.Payload...)
+	}
+
+	return secret
+}
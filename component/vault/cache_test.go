@@ -0,0 +1,298 @@
+package vault_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fruitsco/goji/component/vault"
+	vaultmemory "github.com/fruitsco/goji/component/vault/memory"
+	"github.com/fruitsco/goji/component/vault/vaulttest"
+)
+
+// countingDriver is a minimal in-memory driver counting remote lookups
+type countingDriver struct {
+	mu       sync.Mutex
+	versions map[string][][]byte
+	gets     atomic.Int64
+	delay    time.Duration
+	err      error
+}
+
+func newCountingDriver() *countingDriver {
+	return &countingDriver{versions: make(map[string][][]byte)}
+}
+
+func (d *countingDriver) CreateSecret(ctx context.Context, name string, payload []byte) (vault.Secret, error) {
+	return d.AddVersion(ctx, name, payload)
+}
+
+func (d *countingDriver) AddVersion(_ context.Context, name string, payload []byte) (vault.Secret, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.versions[name] = append(d.versions[name], payload)
+	return vault.Secret{Name: name, Version: len(d.versions[name]), Payload: payload}, nil
+}
+
+func (d *countingDriver) GetLatestVersion(ctx context.Context, name string) (vault.Secret, error) {
+	d.mu.Lock()
+	n := len(d.versions[name])
+	d.mu.Unlock()
+	return d.GetVersion(ctx, name, n)
+}
+
+func (d *countingDriver) GetVersion(_ context.Context, name string, version int) (vault.Secret, error) {
+	d.gets.Add(1)
+	time.Sleep(d.delay)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.err != nil {
+		return vault.Secret{}, d.err
+	}
+	if version < 1 || version > len(d.versions[name]) {
+		return vault.Secret{}, vault.ErrVersionNotFound
+	}
+	return vault.Secret{Name: name, Version: version, Payload: d.versions[name][version-1]}, nil
+}
+
+func (d *countingDriver) DeleteSecret(_ context.Context, name string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.versions, name)
+	return nil
+}
+
+func (d *countingDriver) ListSecrets(context.Context) ([]vault.SecretMetadata, error) {
+	return nil, vault.ErrNotSupported
+}
+
+func (d *countingDriver) ListVersions(context.Context, string) ([]vault.Secret, error) {
+	return nil, vault.ErrNotSupported
+}
+
+func (d *countingDriver) EnableVersion(context.Context, string, int) error {
+	return nil
+}
+
+func (d *countingDriver) DisableVersion(context.Context, string, int) error {
+	return nil
+}
+
+func (d *countingDriver) DestroyVersion(context.Context, string, int) error {
+	return nil
+}
+
+func TestCachedDriver(t *testing.T) {
+	ctx := context.Background()
+
+	for _, encrypt := range []bool{false, true} {
+		remote := newCountingDriver()
+
+		c, err := vault.NewCachedDriver(remote, vault.CacheOptions{
+			LatestTTL: time.Minute,
+			Encrypt:   encrypt,
+		})
+		require.NoError(t, err)
+
+		_, err = remote.CreateSecret(ctx, "key", []byte("v1"))
+		require.NoError(t, err)
+
+		// latest is fetched once and then served from cache
+		for range 3 {
+			s, err := c.GetLatestVersion(ctx, "key")
+			require.NoError(t, err)
+			assert.Equal(t, []byte("v1"), s.Payload)
+		}
+		assert.Equal(t, int64(1), remote.gets.Load())
+
+		// the latest lookup also populated the version cache
+		s, err := c.GetVersion(ctx, "key", 1)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v1"), s.Payload)
+		assert.Equal(t, int64(1), remote.gets.Load())
+
+		// mutating a returned payload does not affect the cache
+		s.Payload[0] = 'x'
+		s, err = c.GetVersion(ctx, "key", 1)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v1"), s.Payload)
+
+		// adding a version invalidates latest
+		_, err = c.AddVersion(ctx, "key", []byte("v2"))
+		require.NoError(t, err)
+
+		s, err = c.GetLatestVersion(ctx, "key")
+		require.NoError(t, err)
+		assert.Equal(t, 2, s.Version)
+		assert.Equal(t, []byte("v2"), s.Payload)
+
+		// deleting drops all versions
+		require.NoError(t, c.DeleteSecret(ctx, "key"))
+		_, err = c.GetVersion(ctx, "key", 1)
+		require.Error(t, err)
+	}
+}
+
+func TestCachedDriver_DeduplicatesConcurrentLookups(t *testing.T) {
+	ctx := context.Background()
+
+	remote := newCountingDriver()
+	remote.delay = 50 * time.Millisecond
+
+	c, err := vault.NewCachedDriver(remote, vault.CacheOptions{})
+	require.NoError(t, err)
+
+	_, err = remote.CreateSecret(ctx, "key", []byte("v1"))
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s, err := c.GetVersion(ctx, "key", 1)
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("v1"), s.Payload)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(1), remote.gets.Load())
+}
+
+func TestCachedDriver_DropsStaleLookups(t *testing.T) {
+	ctx := context.Background()
+
+	remote := newCountingDriver()
+	remote.delay = 50 * time.Millisecond
+
+	c, err := vault.NewCachedDriver(remote, vault.CacheOptions{LatestTTL: time.Minute})
+	require.NoError(t, err)
+
+	_, err = remote.CreateSecret(ctx, "key", []byte("v1"))
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = c.GetLatestVersion(ctx, "key")
+	}()
+
+	// the version is added while the lookup of v1 is in flight
+	time.Sleep(10 * time.Millisecond)
+	_, err = c.AddVersion(ctx, "key", []byte("v2"))
+	require.NoError(t, err)
+	<-done
+
+	remote.delay = 0
+
+	s, err := c.GetLatestVersion(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v2"), s.Payload, "stale lookup is not cached")
+}
+
+func TestCachedDriver_CanceledCallerDoesNotFailOthers(t *testing.T) {
+	ctx := context.Background()
+
+	remote := newCountingDriver()
+	remote.delay = 50 * time.Millisecond
+
+	c, err := vault.NewCachedDriver(remote, vault.CacheOptions{})
+	require.NoError(t, err)
+
+	_, err = remote.CreateSecret(ctx, "key", []byte("v1"))
+	require.NoError(t, err)
+
+	canceled, cancel := context.WithCancel(ctx)
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := c.GetVersion(canceled, "key", 1)
+		errs <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		s, err := c.GetVersion(ctx, "key", 1)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("v1"), s.Payload)
+	}()
+
+	cancel()
+	assert.ErrorIs(t, <-errs, context.Canceled)
+
+	wg.Wait()
+	assert.Equal(t, int64(1), remote.gets.Load())
+}
+
+func TestCachedDriver_NegativeCaching(t *testing.T) {
+	ctx := context.Background()
+
+	remote := newCountingDriver()
+
+	c, err := vault.NewCachedDriver(remote, vault.CacheOptions{NegativeTTL: time.Minute})
+	require.NoError(t, err)
+
+	for range 3 {
+		_, err := c.GetVersion(ctx, "missing", 1)
+		require.Error(t, err)
+	}
+	assert.Equal(t, int64(1), remote.gets.Load())
+
+	// creating the secret drops the negative entry
+	_, err = c.CreateSecret(ctx, "missing", []byte("v1"))
+	require.NoError(t, err)
+
+	s, err := c.GetVersion(ctx, "missing", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v1"), s.Payload)
+}
+
+func TestCachedDriver_DoesNotCacheTransientErrors(t *testing.T) {
+	ctx := context.Background()
+
+	remote := newCountingDriver()
+	remote.err = errors.New("connection refused")
+
+	c, err := vault.NewCachedDriver(remote, vault.CacheOptions{NegativeTTL: time.Minute})
+	require.NoError(t, err)
+
+	_, err = remote.CreateSecret(ctx, "key", []byte("v1"))
+	require.NoError(t, err)
+
+	_, err = c.GetVersion(ctx, "key", 1)
+	require.Error(t, err)
+
+	// the backend recovers, the next lookup must reach it
+	remote.mu.Lock()
+	remote.err = nil
+	remote.mu.Unlock()
+
+	s, err := c.GetVersion(ctx, "key", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v1"), s.Payload)
+	assert.Equal(t, int64(2), remote.gets.Load())
+}
+
+func TestCachedDriver_Conformance(t *testing.T) {
+	vaulttest.TestDriver(t, func(t *testing.T) vault.Driver {
+		c, err := vault.NewCachedDriver(vaultmemory.NewMemoryDriver(vaultmemory.MemoryDriverParams{}), vault.CacheOptions{
+			LatestTTL:   time.Minute,
+			NegativeTTL: time.Minute,
+		})
+		require.NoError(t, err)
+		return c
+	})
+}
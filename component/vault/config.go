@@ -19,6 +19,12 @@ const (
 
 	// Redis is a driver for Redis
 	Redis DriverName = "redis"
+
+	// Memory is an in-memory driver for development and tests
+	Memory DriverName = "memory"
+
+	// File is a driver for a local, encrypted secrets file
+	File DriverName = "file"
 )
 
 type Config struct {
@@ -36,12 +42,40 @@ type Config struct {
 
 	// Redis is the configuration for Redis
 	Redis *RedisConfig `conf:"redis"`
+
+	// File is the configuration for the secrets file
+	File *FileConfig `conf:"file"`
+
+	// Cache is the configuration for the secret cache
+	Cache *CacheConfig `conf:"cache"`
 }
 
 // DefaultConfig is the default configuration for the vault
 var DefaultConfig = conf.DefaultConfig{
-	"vault.driver":                "redis",
-	"vault.redis.connection_name": "default",
+	"vault.driver":                   "redis",
+	"vault.redis.connection_name":    "default",
+	"vault.cache.latest_ttl_seconds": "60",
+	"vault.infisical.max_versions":   "100",
+}
+
+// MARK: - Cache
+
+// CacheConfig is the configuration for the secret cache
+type CacheConfig struct {
+	// Enabled wraps the configured driver in a caching driver
+	Enabled bool `conf:"enabled"`
+
+	// LatestTTLSeconds is the time in seconds a "latest" lookup is cached.
+	// Specific versions are immutable and cached until invalidated.
+	LatestTTLSeconds int `conf:"latest_ttl_seconds"`
+
+	// NegativeTTLSeconds is the time in seconds a failed lookup is cached.
+	// Negative caching is disabled if zero.
+	NegativeTTLSeconds int `conf:"negative_ttl_seconds"`
+
+	// Encrypt keeps cached payloads encrypted in memory using
+	// a random key generated on startup
+	Encrypt bool `conf:"encrypt"`
 }
 
 // MARK: - GCP
@@ -111,6 +145,11 @@ type InfisicalConfig struct {
 
 	// Auth is the configuration for authentication
 	Auth InfisicalAuthConfig `conf:"auth"`
+
+	// MaxVersions is the number of the most recent versions listed by
+	// ListVersions, as each version is retrieved with a separate request.
+	// All versions are listed if zero.
+	MaxVersions int `conf:"max_versions"`
 }
 
 // MARK: - HCP Vault
@@ -175,3 +214,31 @@ type RedisConfig struct {
 	// EncryptionKey is the key to use for encryption
 	EncryptionKey string `conf:"encryption_key"`
 }
+
+// MARK: - File
+
+// FileFormat is the format of the secrets file
+type FileFormat string
+
+const (
+	// FileFormatJSON stores secrets and their versions in a JSON document
+	FileFormatJSON FileFormat = "json"
+
+	// FileFormatDotenv stores one `name@version=value` line per version,
+	// preceded by a `# created_at=` comment with its creation time
+	FileFormatDotenv FileFormat = "dotenv"
+)
+
+// FileConfig is the configuration for the File driver
+type FileConfig struct {
+	// Path is the path of the secrets file
+	Path string `conf:"path"`
+
+	// Format is the format of the secrets file. If empty, the format
+	// is derived from the file extension, defaulting to JSON.
+	Format FileFormat `conf:"format"`
+
+	// EncryptionKey is the AES key used to encrypt the secret values.
+	// Values are stored in plain text if empty.
+	EncryptionKey string `conf:"encryption_key"`
+}
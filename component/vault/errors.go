@@ -0,0 +1,30 @@
+package vault
+
+import "errors"
+
+var (
+	// ErrSecretNotFound is returned by drivers if a secret does not exist
+	ErrSecretNotFound = errors.New("secret not found")
+
+	// ErrVersionNotFound is returned by drivers if a version of an existing
+	// secret does not exist or cannot be accessed, because it is disabled
+	// or destroyed
+	ErrVersionNotFound = errors.New("secret version not found")
+
+	// ErrSecretExists is returned by drivers if a secret to be created
+	// already exists
+	ErrSecretExists = errors.New("secret already exists")
+
+	// ErrPermissionDenied is returned by drivers if the backend
+	// rejected the operation for missing permissions
+	ErrPermissionDenied = errors.New("permission denied")
+
+	// ErrNotSupported is returned by drivers for operations
+	// their backend does not support
+	ErrNotSupported = errors.New("operation not supported by vault driver")
+)
+
+// IsNotFound reports whether the error is caused by a missing secret or version
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrSecretNotFound) || errors.Is(err, ErrVersionNotFound)
+}
@@ -0,0 +1,503 @@
+package vaultfile
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"github.com/fruitsco/goji/component/vault"
+	"github.com/fruitsco/goji/x/driver"
+)
+
+const (
+	// encryptedPrefix marks an encrypted value in the secrets file
+	encryptedPrefix = "ENC[AES_GCM,"
+
+	// encryptedSuffix terminates an encrypted value in the secrets file
+	encryptedSuffix = "]"
+)
+
+// FileDriver is a vault driver backed by a local secrets file. Each value
+// is encrypted individually, so that the file can be diffed and committed
+// like a SOPS file while the secret names stay readable.
+type FileDriver struct {
+	config *vault.FileConfig
+	format vault.FileFormat
+	aead   cipher.AEAD
+	log    *zap.Logger
+
+	mu sync.Mutex
+}
+
+// FileDriverParams is the parameters for the file driver
+type FileDriverParams struct {
+	fx.In
+
+	// Config is the configuration for the file driver
+	Config *vault.FileConfig
+
+	// Log is the logger for the file driver
+	Log *zap.Logger
+}
+
+// NewFileDriverFactory creates a new file driver factory
+func NewFileDriverFactory(params FileDriverParams) driver.FactoryResult[vault.DriverName, vault.Driver] {
+	return driver.NewFactory(vault.File, func() (vault.Driver, error) {
+		return NewFileDriver(params)
+	})
+}
+
+// NewFileDriver creates a new file driver
+func NewFileDriver(params FileDriverParams) (*FileDriver, error) {
+	if params.Config == nil {
+		return nil, fmt.Errorf("config is required for file driver")
+	}
+
+	if params.Config.Path == "" {
+		return nil, fmt.Errorf("path is required for file driver")
+	}
+
+	if params.Log == nil {
+		params.Log = zap.NewNop()
+	}
+
+	format := params.Config.Format
+	if format == "" {
+		format = formatFromPath(params.Config.Path)
+	}
+
+	if format != vault.FileFormatJSON && format != vault.FileFormatDotenv {
+		return nil, fmt.Errorf("unsupported file format: %s", format)
+	}
+
+	d := &FileDriver{
+		config: params.Config,
+		format: format,
+		log:    params.Log.Named("file"),
+	}
+
+	if params.Config.EncryptionKey == "" {
+		d.log.Warn("no encryption key configured, secrets are stored in plain text")
+		return d, nil
+	}
+
+	block, err := aes.NewCipher([]byte(params.Config.EncryptionKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+
+	d.aead, err = cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM cipher: %w", err)
+	}
+
+	return d, nil
+}
+
+var _ = vault.Driver(&FileDriver{})
+
+// CreateSecret creates a new secret with the payload as first version
+func (d *FileDriver) CreateSecret(
+	_ context.Context,
+	name string,
+	payload []byte,
+) (vault.Secret, error) {
+	var secret vault.Secret
+
+	err := d.update(func(f *secretsFile) error {
+		if _, ok := f.Secrets[name]; ok {
+			return fmt.Errorf("%w: %s", vault.ErrSecretExists, name)
+		}
+
+		v, err := d.newVersion(name, 1, payload)
+		if err != nil {
+			return err
+		}
+
+		f.Secrets[name] = []fileVersion{v}
+		secret = vault.Secret{Name: name, Version: 1, Payload: payload, CreatedAt: v.CreatedAt, State: vault.VersionEnabled}
+
+		return nil
+	})
+
+	return secret, err
+}
+
+// AddVersion adds a new version to an existing secret
+func (d *FileDriver) AddVersion(
+	_ context.Context,
+	name string,
+	payload []byte,
+) (vault.Secret, error) {
+	var secret vault.Secret
+
+	err := d.update(func(f *secretsFile) error {
+		versions, ok := f.Secrets[name]
+		if !ok {
+			return fmt.Errorf("%w: %s", vault.ErrSecretNotFound, name)
+		}
+
+		// hand-edited files may list a secret without versions
+		version := 1
+		if len(versions) > 0 {
+			version = versions[len(versions)-1].Version + 1
+		}
+
+		v, err := d.newVersion(name, version, payload)
+		if err != nil {
+			return err
+		}
+
+		f.Secrets[name] = append(versions, v)
+		secret = vault.Secret{Name: name, Version: version, Payload: payload, CreatedAt: v.CreatedAt, State: vault.VersionEnabled}
+
+		return nil
+	})
+
+	return secret, err
+}
+
+// GetVersion retrieves a specific version of a secret
+func (d *FileDriver) GetVersion(
+	_ context.Context,
+	name string,
+	version int,
+) (vault.Secret, error) {
+	f, err := d.read()
+	if err != nil {
+		return vault.Secret{}, err
+	}
+
+	versions, ok := f.Secrets[name]
+	if !ok {
+		return vault.Secret{}, fmt.Errorf("%w: %s", vault.ErrSecretNotFound, name)
+	}
+
+	for _, v := range versions {
+		if v.Version != version {
+			continue
+		}
+
+		if state := v.state(); state != vault.VersionEnabled {
+			return vault.Secret{}, fmt.Errorf("%w: version %d of secret %s is %s", vault.ErrVersionNotFound, version, name, state)
+		}
+
+		return d.mapSecret(name, v)
+	}
+
+	return vault.Secret{}, fmt.Errorf("%w: version %d of secret %s", vault.ErrVersionNotFound, version, name)
+}
+
+// GetLatestVersion retrieves the latest enabled version of a secret
+func (d *FileDriver) GetLatestVersion(
+	_ context.Context,
+	name string,
+) (vault.Secret, error) {
+	f, err := d.read()
+	if err != nil {
+		return vault.Secret{}, err
+	}
+
+	versions, ok := f.Secrets[name]
+	if !ok || len(versions) == 0 {
+		return vault.Secret{}, fmt.Errorf("%w: %s", vault.ErrSecretNotFound, name)
+	}
+
+	for i := len(versions) - 1; i >= 0; i-- {
+		if versions[i].state() == vault.VersionEnabled {
+			return d.mapSecret(name, versions[i])
+		}
+	}
+
+	return vault.Secret{}, fmt.Errorf("%w: secret %s has no enabled version", vault.ErrVersionNotFound, name)
+}
+
+// DeleteSecret deletes a secret and all of its versions
+func (d *FileDriver) DeleteSecret(_ context.Context, name string) error {
+	return d.update(func(f *secretsFile) error {
+		if _, ok := f.Secrets[name]; !ok {
+			return fmt.Errorf("%w: %s", vault.ErrSecretNotFound, name)
+		}
+
+		delete(f.Secrets, name)
+
+		return nil
+	})
+}
+
+// ListSecrets lists all secrets in the file ordered by name
+func (d *FileDriver) ListSecrets(_ context.Context) ([]vault.SecretMetadata, error) {
+	f, err := d.read()
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := make([]vault.SecretMetadata, 0, len(f.Secrets))
+	for name, versions := range f.Secrets {
+		var createdAt time.Time
+		if len(versions) > 0 {
+			createdAt = versions[0].CreatedAt
+		}
+
+		secrets = append(secrets, vault.SecretMetadata{
+			Name:      name,
+			CreatedAt: createdAt,
+		})
+	}
+
+	sort.Slice(secrets, func(i, j int) bool {
+		return secrets[i].Name < secrets[j].Name
+	})
+
+	return secrets, nil
+}
+
+// ListVersions lists all versions of a secret without their payload
+func (d *FileDriver) ListVersions(_ context.Context, name string) ([]vault.Secret, error) {
+	f, err := d.read()
+	if err != nil {
+		return nil, err
+	}
+
+	versions, ok := f.Secrets[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", vault.ErrSecretNotFound, name)
+	}
+
+	secrets := make([]vault.Secret, len(versions))
+	for i, v := range versions {
+		secrets[i] = vault.Secret{
+			Name:      name,
+			Version:   v.Version,
+			CreatedAt: v.CreatedAt,
+			State:     v.state(),
+		}
+	}
+
+	return secrets, nil
+}
+
+// EnableVersion enables a disabled version of a secret
+func (d *FileDriver) EnableVersion(_ context.Context, name string, version int) error {
+	return d.setState(name, version, vault.VersionEnabled)
+}
+
+// DisableVersion disables a version of a secret
+func (d *FileDriver) DisableVersion(_ context.Context, name string, version int) error {
+	return d.setState(name, version, vault.VersionDisabled)
+}
+
+// DestroyVersion removes the value of a version from the file
+func (d *FileDriver) DestroyVersion(_ context.Context, name string, version int) error {
+	return d.setState(name, version, vault.VersionDestroyed)
+}
+
+// setState changes the state of a version. Destroyed versions
+// cannot change their state anymore.
+func (d *FileDriver) setState(name string, version int, state vault.VersionState) error {
+	return d.update(func(f *secretsFile) error {
+		versions, ok := f.Secrets[name]
+		if !ok {
+			return fmt.Errorf("%w: %s", vault.ErrSecretNotFound, name)
+		}
+
+		for i := range versions {
+			v := &versions[i]
+			if v.Version != version {
+				continue
+			}
+
+			if v.state() == vault.VersionDestroyed {
+				return fmt.Errorf("%w: version %d of secret %s is destroyed", vault.ErrVersionNotFound, version, name)
+			}
+
+			v.State = state
+			if state == vault.VersionEnabled {
+				// keep the file free of redundant state markers
+				v.State = ""
+			}
+
+			if state == vault.VersionDestroyed {
+				v.Value = ""
+			}
+
+			return nil
+		}
+
+		return fmt.Errorf("%w: version %d of secret %s", vault.ErrVersionNotFound, version, name)
+	})
+}
+
+// read loads the secrets file. A missing file is treated as empty.
+func (d *FileDriver) read() (*secretsFile, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.load()
+}
+
+// update loads the secrets file, applies the given mutation
+// and atomically writes the file back to disk
+func (d *FileDriver) update(fn func(*secretsFile) error) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	f, err := d.load()
+	if err != nil {
+		return err
+	}
+
+	if err := fn(f); err != nil {
+		return err
+	}
+
+	return d.store(f)
+}
+
+func (d *FileDriver) load() (*secretsFile, error) {
+	data, err := os.ReadFile(d.config.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return newSecretsFile(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets file: %w", err)
+	}
+
+	f, err := decodeFile(d.format, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse secrets file: %w", err)
+	}
+
+	return f, nil
+}
+
+func (d *FileDriver) store(f *secretsFile) error {
+	data, err := encodeFile(d.format, f)
+	if err != nil {
+		return fmt.Errorf("failed to encode secrets file: %w", err)
+	}
+
+	// write to a temporary file first and rename it afterwards,
+	// so that the secrets file is never left half-written.
+	tmp, err := os.CreateTemp(filepath.Dir(d.config.Path), filepath.Base(d.config.Path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary secrets file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write secrets file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write secrets file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), d.config.Path); err != nil {
+		return fmt.Errorf("failed to replace secrets file: %w", err)
+	}
+
+	return nil
+}
+
+func (d *FileDriver) newVersion(name string, version int, payload []byte) (fileVersion, error) {
+	value, err := d.encrypt(name, version, payload)
+	if err != nil {
+		return fileVersion{}, fmt.Errorf("failed to encrypt payload: %w", err)
+	}
+
+	return fileVersion{
+		Version:   version,
+		Value:     value,
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
+
+func (d *FileDriver) mapSecret(name string, v fileVersion) (vault.Secret, error) {
+	payload, err := d.decrypt(name, v.Version, v.Value)
+	if err != nil {
+		return vault.Secret{}, fmt.Errorf("failed to decrypt payload: %w", err)
+	}
+
+	return vault.Secret{
+		Name:      name,
+		Version:   v.Version,
+		Payload:   payload,
+		CreatedAt: v.CreatedAt,
+		State:     v.state(),
+	}, nil
+}
+
+// encrypt seals the payload, binding it to the secret name and version
+// so that values cannot be swapped within the file unnoticed
+func (d *FileDriver) encrypt(name string, version int, payload []byte) (string, error) {
+	if d.aead == nil {
+		return string(payload), nil
+	}
+
+	nonce := make([]byte, d.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := d.aead.Seal(nonce, nonce, payload, associatedData(name, version))
+
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed) + encryptedSuffix, nil
+}
+
+// decrypt opens an encrypted value. Plain text values are returned as is,
+// which allows hand-written development files.
+func (d *FileDriver) decrypt(name string, version int, value string) ([]byte, error) {
+	if !strings.HasPrefix(value, encryptedPrefix) || !strings.HasSuffix(value, encryptedSuffix) {
+		return []byte(value), nil
+	}
+
+	if d.aead == nil {
+		return nil, errors.New("value is encrypted, but no encryption key is configured")
+	}
+
+	encoded := strings.TrimSuffix(strings.TrimPrefix(value, encryptedPrefix), encryptedSuffix)
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to base64decode data: %w", err)
+	}
+
+	nonceSize := d.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return nil, errors.New("encrypted value too short")
+	}
+
+	nonce, ciphertext := sealed[:nonceSize], sealed[nonceSize:]
+
+	return d.aead.Open(nil, nonce, ciphertext, associatedData(name, version))
+}
+
+func associatedData(name string, version int) []byte {
+	return []byte(name + "@" + strconv.Itoa(version))
+}
+
+func formatFromPath(path string) vault.FileFormat {
+	base := filepath.Base(path)
+
+	if filepath.Ext(base) == ".env" || strings.HasPrefix(base, ".env") {
+		return vault.FileFormatDotenv
+	}
+
+	return vault.FileFormatJSON
+}
@@ -0,0 +1,223 @@
+package vaultfile_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/fruitsco/goji/component/vault"
+	vaultfile "github.com/fruitsco/goji/component/vault/file"
+	"github.com/fruitsco/goji/component/vault/vaulttest"
+)
+
+const testKey = "0123456789abcdef0123456789abcdef"
+
+func newDriver(t *testing.T, path string, key string) *vaultfile.FileDriver {
+	d, err := vaultfile.NewFileDriver(vaultfile.FileDriverParams{
+		Config: &vault.FileConfig{
+			Path:          path,
+			EncryptionKey: key,
+		},
+		Log: zap.NewNop(),
+	})
+	require.NoError(t, err)
+	return d
+}
+
+func TestFileDriver(t *testing.T) {
+	ctx := context.Background()
+
+	for _, name := range []string{"secrets.json", ".env.secrets"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+
+			d := newDriver(t, path, testKey)
+
+			_, err := d.CreateSecret(ctx, "db/password", []byte("hunter2"))
+			require.NoError(t, err)
+
+			_, err = d.CreateSecret(ctx, "db/password", []byte("hunter2"))
+			require.Error(t, err)
+
+			s, err := d.AddVersion(ctx, "db/password", []byte("correct horse"))
+			require.NoError(t, err)
+			assert.Equal(t, 2, s.Version)
+
+			// the file only contains encrypted values
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Contains(t, string(data), "db/password")
+			assert.NotContains(t, string(data), "hunter2")
+
+			// a new driver instance reads the persisted secrets
+			d = newDriver(t, path, testKey)
+
+			s, err = d.GetLatestVersion(ctx, "db/password")
+			require.NoError(t, err)
+			assert.Equal(t, 2, s.Version)
+			assert.Equal(t, []byte("correct horse"), s.Payload)
+
+			s, err = d.GetVersion(ctx, "db/password", 1)
+			require.NoError(t, err)
+			assert.Equal(t, []byte("hunter2"), s.Payload)
+
+			_, err = d.GetVersion(ctx, "db/password", 3)
+			require.Error(t, err)
+
+			// a wrong key cannot decrypt the values
+			_, err = newDriver(t, path, strings.Repeat("x", 32)).GetVersion(ctx, "db/password", 1)
+			require.Error(t, err)
+
+			require.NoError(t, d.DeleteSecret(ctx, "db/password"))
+
+			_, err = d.GetLatestVersion(ctx, "db/password")
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestFileDriver_ReadsPlainDotenv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(path, []byte("# local secrets\nAPI_KEY=plain\nTOKEN@2=\"second\"\nTOKEN@1=first\n"), 0o600))
+
+	d := newDriver(t, path, "")
+
+	s, err := d.GetLatestVersion(context.Background(), "API_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, 1, s.Version)
+	assert.Equal(t, []byte("plain"), s.Payload)
+
+	s, err = d.GetLatestVersion(context.Background(), "TOKEN")
+	require.NoError(t, err)
+	assert.Equal(t, 2, s.Version)
+	assert.Equal(t, []byte("second"), s.Payload)
+}
+
+func TestFileDriver_EmptyVersions(t *testing.T) {
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"secrets":{"API_KEY":[]}}`), 0o600))
+
+	d := newDriver(t, path, "")
+
+	_, err := d.GetLatestVersion(ctx, "API_KEY")
+	require.ErrorIs(t, err, vault.ErrSecretNotFound)
+
+	s, err := d.AddVersion(ctx, "API_KEY", []byte("first"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, s.Version)
+}
+
+func TestFileDriver_RejectsDuplicateVersions(t *testing.T) {
+	files := map[string]string{
+		".env":         "TOKEN@1=first\nTOKEN@1=second\n",
+		"secrets.json": `{"secrets":{"TOKEN":[{"version":1,"value":"a"},{"version":1,"value":"b"}]}}`,
+		".env.zero":    "TOKEN@0=first\n",
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+			_, err := newDriver(t, path, "").GetLatestVersion(context.Background(), "TOKEN")
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestFileDriver_VersionStates(t *testing.T) {
+	ctx := context.Background()
+
+	for _, name := range []string{"secrets.json", ".env.secrets"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+
+			d := newDriver(t, path, testKey)
+
+			_, err := d.CreateSecret(ctx, "token", []byte("v1"))
+			require.NoError(t, err)
+			_, err = d.AddVersion(ctx, "token", []byte("v2"))
+			require.NoError(t, err)
+			_, err = d.AddVersion(ctx, "token", []byte("v3"))
+			require.NoError(t, err)
+
+			require.NoError(t, d.DisableVersion(ctx, "token", 3))
+			require.NoError(t, d.DestroyVersion(ctx, "token", 1))
+
+			// the states survive a reload of the file
+			d = newDriver(t, path, testKey)
+
+			versions, err := d.ListVersions(ctx, "token")
+			require.NoError(t, err)
+			require.Len(t, versions, 3)
+			assert.Equal(t, vault.VersionDestroyed, versions[0].State)
+			assert.Equal(t, vault.VersionEnabled, versions[1].State)
+			assert.Equal(t, vault.VersionDisabled, versions[2].State)
+
+			// latest skips the disabled version
+			s, err := d.GetLatestVersion(ctx, "token")
+			require.NoError(t, err)
+			assert.Equal(t, 2, s.Version)
+
+			_, err = d.GetVersion(ctx, "token", 3)
+			require.Error(t, err)
+
+			// destroyed versions cannot be enabled again
+			require.Error(t, d.EnableVersion(ctx, "token", 1))
+
+			require.NoError(t, d.EnableVersion(ctx, "token", 3))
+			s, err = d.GetLatestVersion(ctx, "token")
+			require.NoError(t, err)
+			assert.Equal(t, []byte("v3"), s.Payload)
+
+			secrets, err := d.ListSecrets(ctx)
+			require.NoError(t, err)
+			require.Len(t, secrets, 1)
+			assert.Equal(t, "token", secrets[0].Name)
+		})
+	}
+}
+
+func TestFileDriver_CreatedAt(t *testing.T) {
+	ctx := context.Background()
+
+	for _, name := range []string{"secrets.json", ".env.secrets"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+
+			d := newDriver(t, path, testKey)
+
+			first, err := d.CreateSecret(ctx, "token", []byte("v1"))
+			require.NoError(t, err)
+			second, err := d.AddVersion(ctx, "token", []byte("v2"))
+			require.NoError(t, err)
+
+			// the creation times survive a reload of the file
+			d = newDriver(t, path, testKey)
+
+			versions, err := d.ListVersions(ctx, "token")
+			require.NoError(t, err)
+			require.Len(t, versions, 2)
+			assert.True(t, first.CreatedAt.Equal(versions[0].CreatedAt))
+			assert.True(t, second.CreatedAt.Equal(versions[1].CreatedAt))
+		})
+	}
+}
+
+func TestFileDriver_Conformance(t *testing.T) {
+	for _, name := range []string{"secrets.json", ".env.secrets"} {
+		t.Run(name, func(t *testing.T) {
+			vaulttest.TestDriver(t, func(t *testing.T) vault.Driver {
+				return newDriver(t, filepath.Join(t.TempDir(), name), testKey)
+			})
+		})
+	}
+}
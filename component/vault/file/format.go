@@ -0,0 +1,222 @@
+package vaultfile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fruitsco/goji/component/vault"
+)
+
+// secretsFile is the in-memory representation of the secrets file
+type secretsFile struct {
+	Secrets map[string][]fileVersion `json:"secrets"`
+}
+
+// fileVersion is a single version of a secret. The value is either
+// plain text or an `ENC[...]` envelope if encryption is enabled.
+type fileVersion struct {
+	Version   int                `json:"version"`
+	Value     string             `json:"value"`
+	CreatedAt time.Time          `json:"created_at,omitzero"`
+	State     vault.VersionState `json:"state,omitempty"`
+}
+
+// state returns the state of the version, versions
+// without an explicit state are enabled
+func (v fileVersion) state() vault.VersionState {
+	if v.State == "" {
+		return vault.VersionEnabled
+	}
+	return v.State
+}
+
+func newSecretsFile() *secretsFile {
+	return &secretsFile{
+		Secrets: make(map[string][]fileVersion),
+	}
+}
+
+func decodeFile(format vault.FileFormat, data []byte) (*secretsFile, error) {
+	switch format {
+	case vault.FileFormatJSON:
+		return decodeJSON(data)
+	case vault.FileFormatDotenv:
+		return decodeDotenv(data)
+	}
+
+	return nil, fmt.Errorf("unsupported file format: %s", format)
+}
+
+func encodeFile(format vault.FileFormat, f *secretsFile) ([]byte, error) {
+	switch format {
+	case vault.FileFormatJSON:
+		return json.MarshalIndent(f, "", "  ")
+	case vault.FileFormatDotenv:
+		return encodeDotenv(f), nil
+	}
+
+	return nil, fmt.Errorf("unsupported file format: %s", format)
+}
+
+func decodeJSON(data []byte) (*secretsFile, error) {
+	f := newSecretsFile()
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		return f, nil
+	}
+
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, err
+	}
+
+	if f.Secrets == nil {
+		f.Secrets = make(map[string][]fileVersion)
+	}
+
+	if err := sortVersions(f); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// createdAtComment is the prefix of the comment line which precedes a
+// version in dotenv files and holds the time it was created
+const createdAtComment = "# created_at="
+
+// decodeDotenv parses `name@version=value` lines. Lines without a
+// version are treated as version 1, so that plain dotenv files can
+// be used as well. Versions that are not enabled carry their state
+// as suffix, e.g. `name@version:disabled=value`. The creation time
+// of a version is read from a `# created_at=<RFC 3339 time>` comment
+// on the line before it.
+func decodeDotenv(data []byte) (*secretsFile, error) {
+	f := newSecretsFile()
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	var createdAt time.Time
+
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		if ts, ok := strings.CutPrefix(line, createdAtComment); ok {
+			t, err := time.Parse(time.RFC3339Nano, ts)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid creation time: %w", lineNo, err)
+			}
+
+			createdAt = t
+			continue
+		}
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: missing '='", lineNo)
+		}
+
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+
+		name, version, state := key, 1, vault.VersionState("")
+		if i := strings.LastIndex(key, "@"); i != -1 {
+			versionStr, stateStr, hasState := strings.Cut(key[i+1:], ":")
+
+			v, err := strconv.Atoi(versionStr)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid version: %w", lineNo, err)
+			}
+
+			if hasState {
+				state = vault.VersionState(stateStr)
+				if state != vault.VersionEnabled && state != vault.VersionDisabled && state != vault.VersionDestroyed {
+					return nil, fmt.Errorf("line %d: invalid state: %s", lineNo, stateStr)
+				}
+			}
+
+			name, version = key[:i], v
+		}
+
+		f.Secrets[name] = append(f.Secrets[name], fileVersion{
+			Version:   version,
+			Value:     value,
+			CreatedAt: createdAt,
+			State:     state,
+		})
+
+		createdAt = time.Time{}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := sortVersions(f); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func encodeDotenv(f *secretsFile) []byte {
+	names := make([]string, 0, len(f.Secrets))
+	for name := range f.Secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		for _, v := range f.Secrets[name] {
+			if !v.CreatedAt.IsZero() {
+				fmt.Fprintf(&buf, "%s%s\n", createdAtComment, v.CreatedAt.Format(time.RFC3339Nano))
+			}
+
+			if state := v.state(); state != vault.VersionEnabled {
+				fmt.Fprintf(&buf, "%s@%d:%s=%s\n", name, v.Version, state, strconv.Quote(v.Value))
+				continue
+			}
+			fmt.Fprintf(&buf, "%s@%d=%s\n", name, v.Version, strconv.Quote(v.Value))
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// sortVersions sorts the versions of all secrets, rejecting versions
+// which are not positive or listed twice
+func sortVersions(f *secretsFile) error {
+	for name, versions := range f.Secrets {
+		sort.Slice(versions, func(i, j int) bool {
+			return versions[i].Version < versions[j].Version
+		})
+
+		for i, v := range versions {
+			if v.Version < 1 {
+				return fmt.Errorf("secret %s: invalid version %d", name, v.Version)
+			}
+
+			if i > 0 && versions[i-1].Version == v.Version {
+				return fmt.Errorf("secret %s: duplicate version %d", name, v.Version)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,16 @@
+package vaultfile
+
+import (
+	"go.uber.org/fx"
+
+	"github.com/fruitsco/goji/component/vault"
+)
+
+func Module() fx.Option {
+	return fx.Options(
+		fx.Provide(func(cfg *vault.Config) *vault.FileConfig {
+			return cfg.File
+		}),
+		fx.Provide(NewFileDriverFactory),
+	)
+}
@@ -3,6 +3,7 @@ package vaultgcp
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -10,6 +11,9 @@ import (
 	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/fruitsco/goji/component/vault"
 	"github.com/fruitsco/goji/x/driver"
@@ -74,6 +78,12 @@ func NewGCPSecretManagerDriver(
 }
 
 var _ = vault.Driver(&GCPSecretManagerDriver{})
+var _ = vault.Projector(&GCPSecretManagerDriver{})
+
+// Project returns the configured project ID
+func (d *GCPSecretManagerDriver) Project() string {
+	return d.config.ProjectID
+}
 
 var _ = vault.Closer(&GCPSecretManagerDriver{})
 
@@ -98,7 +108,7 @@ func (d *GCPSecretManagerDriver) CreateSecret(
 
 	_, err := d.client.CreateSecret(ctx, createSecretReq)
 	if err != nil {
-		return vault.Secret{}, err
+		return vault.Secret{}, mapError(err)
 	}
 
 	return d.AddVersion(ctx, name, payload)
@@ -119,7 +129,7 @@ func (d *GCPSecretManagerDriver) AddVersion(
 
 	addSecretVersionResp, err := d.client.AddSecretVersion(ctx, addSecretVersionReq)
 	if err != nil {
-		return vault.Secret{}, err
+		return vault.Secret{}, mapError(err)
 	}
 
 	version, err := d.getVersionFromName(addSecretVersionResp.Name)
@@ -128,9 +138,11 @@ func (d *GCPSecretManagerDriver) AddVersion(
 	}
 
 	return vault.Secret{
-		Name:    name,
-		Version: version,
-		Payload: payload,
+		Name:      name,
+		Version:   version,
+		Payload:   payload,
+		CreatedAt: addSecretVersionResp.GetCreateTime().AsTime(),
+		State:     mapState(addSecretVersionResp.GetState()),
 	}, nil
 }
 
@@ -143,12 +155,38 @@ func (d *GCPSecretManagerDriver) GetVersion(
 	return d.getSecretVersion(ctx, name, fmt.Sprintf("%d", version))
 }
 
-// GetLatestVersion retrieves the latest version of a secret from Google Cloud Secret Manager
+// GetLatestVersion retrieves the latest enabled version of a secret from Google Cloud Secret Manager
 func (d *GCPSecretManagerDriver) GetLatestVersion(
 	ctx context.Context,
 	name string,
 ) (vault.Secret, error) {
-	return d.getSecretVersion(ctx, name, "latest")
+	secret, err := d.getSecretVersion(ctx, name, "latest")
+	if status.Code(err) != codes.FailedPrecondition {
+		return secret, err
+	}
+
+	// the `latest` alias points to the most recently created version,
+	// even if it is disabled. fall back to the newest enabled version.
+	it := d.client.ListSecretVersions(ctx, &secretmanagerpb.ListSecretVersionsRequest{
+		Parent:   fmt.Sprintf("projects/%s/secrets/%s", d.config.ProjectID, name),
+		Filter:   "state:ENABLED",
+		PageSize: 1,
+	})
+
+	version, listErr := it.Next()
+	if listErr == iterator.Done {
+		return vault.Secret{}, err
+	}
+	if listErr != nil {
+		return vault.Secret{}, mapError(listErr)
+	}
+
+	versionParsed, err := d.getVersionFromName(version.GetName())
+	if err != nil {
+		return vault.Secret{}, err
+	}
+
+	return d.getSecretVersion(ctx, name, strconv.Itoa(versionParsed))
 }
 
 // DeleteSecret deletes a secret from Google Cloud Secret Manager
@@ -157,7 +195,98 @@ func (d *GCPSecretManagerDriver) DeleteSecret(ctx context.Context, name string)
 		Name: fmt.Sprintf("projects/%s/secrets/%s", d.config.ProjectID, name),
 	}
 
-	return d.client.DeleteSecret(ctx, deleteSecretReq)
+	return mapError(d.client.DeleteSecret(ctx, deleteSecretReq))
+}
+
+// ListSecrets lists all secrets of the project in Google Cloud Secret Manager
+func (d *GCPSecretManagerDriver) ListSecrets(ctx context.Context) ([]vault.SecretMetadata, error) {
+	it := d.client.ListSecrets(ctx, &secretmanagerpb.ListSecretsRequest{
+		Parent: fmt.Sprintf("projects/%s", d.config.ProjectID),
+	})
+
+	var secrets []vault.SecretMetadata
+
+	for {
+		secret, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, mapError(err)
+		}
+
+		secrets = append(secrets, vault.SecretMetadata{
+			Name:      d.getSecretFromName(secret.GetName()),
+			CreatedAt: secret.GetCreateTime().AsTime(),
+		})
+	}
+
+	return secrets, nil
+}
+
+// ListVersions lists all versions of a secret in Google Cloud Secret Manager
+func (d *GCPSecretManagerDriver) ListVersions(ctx context.Context, name string) ([]vault.Secret, error) {
+	it := d.client.ListSecretVersions(ctx, &secretmanagerpb.ListSecretVersionsRequest{
+		Parent: fmt.Sprintf("projects/%s/secrets/%s", d.config.ProjectID, name),
+	})
+
+	var versions []vault.Secret
+
+	for {
+		version, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, mapError(err)
+		}
+
+		versionParsed, err := d.getVersionFromName(version.GetName())
+		if err != nil {
+			return nil, err
+		}
+
+		versions = append(versions, vault.Secret{
+			Name:      name,
+			Version:   versionParsed,
+			CreatedAt: version.GetCreateTime().AsTime(),
+			State:     mapState(version.GetState()),
+		})
+	}
+
+	// secret manager lists versions in descending order
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Version < versions[j].Version
+	})
+
+	return versions, nil
+}
+
+// EnableVersion enables a version of a secret in Google Cloud Secret Manager
+func (d *GCPSecretManagerDriver) EnableVersion(ctx context.Context, name string, version int) error {
+	_, err := d.client.EnableSecretVersion(ctx, &secretmanagerpb.EnableSecretVersionRequest{
+		Name: fmt.Sprintf("projects/%s/secrets/%s/versions/%d", d.config.ProjectID, name, version),
+	})
+
+	return mapError(err)
+}
+
+// DisableVersion disables a version of a secret in Google Cloud Secret Manager
+func (d *GCPSecretManagerDriver) DisableVersion(ctx context.Context, name string, version int) error {
+	_, err := d.client.DisableSecretVersion(ctx, &secretmanagerpb.DisableSecretVersionRequest{
+		Name: fmt.Sprintf("projects/%s/secrets/%s/versions/%d", d.config.ProjectID, name, version),
+	})
+
+	return mapError(err)
+}
+
+// DestroyVersion destroys a version of a secret in Google Cloud Secret Manager
+func (d *GCPSecretManagerDriver) DestroyVersion(ctx context.Context, name string, version int) error {
+	_, err := d.client.DestroySecretVersion(ctx, &secretmanagerpb.DestroySecretVersionRequest{
+		Name: fmt.Sprintf("projects/%s/secrets/%s/versions/%d", d.config.ProjectID, name, version),
+	})
+
+	return mapError(err)
 }
 
 // Close closes the Google Cloud Secret Manager driver
@@ -177,7 +306,7 @@ func (d *GCPSecretManagerDriver) getSecretVersion(
 
 	accessSecretVersionResp, err := d.client.AccessSecretVersion(ctx, accessSecretVersionReq)
 	if err != nil {
-		return vault.Secret{}, err
+		return vault.Secret{}, mapError(err)
 	}
 
 	versionParsed, err := d.getVersionFromName(accessSecretVersionResp.Name)
@@ -189,9 +318,17 @@ func (d *GCPSecretManagerDriver) getSecretVersion(
 		Name:    name,
 		Version: versionParsed,
 		Payload: accessSecretVersionResp.Payload.Data,
+		// only enabled versions can be accessed
+		State: vault.VersionEnabled,
 	}, nil
 }
 
+// getSecretFromName extracts the secret id from a secret name
+func (d *GCPSecretManagerDriver) getSecretFromName(name string) string {
+	nameParts := strings.Split(name, "/")
+	return nameParts[len(nameParts)-1]
+}
+
 // getVersionFromName extracts the version from a secret version name
 func (d *GCPSecretManagerDriver) getVersionFromName(name string) (int, error) {
 	nameParts := strings.Split(name, "/")
@@ -203,3 +340,43 @@ func (d *GCPSecretManagerDriver) getVersionFromName(name string) (int, error) {
 
 	return versionParsed, nil
 }
+
+// mapError maps gRPC status errors of Google Cloud Secret Manager to vault errors
+func mapError(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	switch st.Code() {
+	case codes.NotFound:
+		// secret manager uses the same code for missing secrets and versions
+		if strings.Contains(st.Message(), "Secret Version") {
+			return fmt.Errorf("%w: %w", vault.ErrVersionNotFound, err)
+		}
+		return fmt.Errorf("%w: %w", vault.ErrSecretNotFound, err)
+	case codes.FailedPrecondition:
+		// accessing a disabled or destroyed version
+		return fmt.Errorf("%w: %w", vault.ErrVersionNotFound, err)
+	case codes.AlreadyExists:
+		return fmt.Errorf("%w: %w", vault.ErrSecretExists, err)
+	case codes.PermissionDenied, codes.Unauthenticated:
+		return fmt.Errorf("%w: %w", vault.ErrPermissionDenied, err)
+	}
+
+	return err
+}
+
+// mapState maps a Google Cloud Secret Manager version state to a vault version state
+func mapState(state secretmanagerpb.SecretVersion_State) vault.VersionState {
+	switch state {
+	case secretmanagerpb.SecretVersion_ENABLED:
+		return vault.VersionEnabled
+	case secretmanagerpb.SecretVersion_DISABLED:
+		return vault.VersionDisabled
+	case secretmanagerpb.SecretVersion_DESTROYED:
+		return vault.VersionDestroyed
+	}
+
+	return ""
+}
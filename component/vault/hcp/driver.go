@@ -2,7 +2,12 @@ package vaulthcp
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
 
 	vaultapi "github.com/hashicorp/vault/api"
 	gcpAuth "github.com/hashicorp/vault/api/auth/gcp"
@@ -96,14 +101,16 @@ func (d *HCPVaultDriver) CreateSecret(
 	name string,
 	payload []byte,
 ) (vault.Secret, error) {
+	// a check-and-set version of 0 only allows the write
+	// if the secret does not exist yet
 	secret, err := d.kv().Put(ctx, name, map[string]interface{}{
 		"data": payload,
-	})
+	}, vaultapi.WithCheckAndSet(0))
 	if err != nil {
-		return vault.Secret{}, err
+		return vault.Secret{}, mapError(err)
 	}
 
-	return d.mapSecret(name, secret)
+	return d.mapWrittenSecret(name, payload, secret)
 }
 
 // AddVersion adds a new version to a secret in HashiCorp Vault
@@ -112,14 +119,19 @@ func (d *HCPVaultDriver) AddVersion(
 	name string,
 	payload []byte,
 ) (vault.Secret, error) {
+	// a put would implicitly create missing secrets
+	if _, err := d.kv().GetMetadata(ctx, name); err != nil {
+		return vault.Secret{}, mapError(err)
+	}
+
 	secret, err := d.kv().Put(ctx, name, map[string]interface{}{
 		"data": payload,
 	})
 	if err != nil {
-		return vault.Secret{}, err
+		return vault.Secret{}, mapError(err)
 	}
 
-	return d.mapSecret(name, secret)
+	return d.mapWrittenSecret(name, payload, secret)
 }
 
 // GetVersion gets a specific version of a secret from HashiCorp Vault
@@ -129,47 +141,288 @@ func (d *HCPVaultDriver) GetVersion(
 	version int,
 ) (vault.Secret, error) {
 	secret, err := d.kv().GetVersion(ctx, name, version)
+	if errors.Is(err, vaultapi.ErrSecretNotFound) {
+		// vault does not tell missing secrets and versions apart
+		if _, mdErr := d.kv().GetMetadata(ctx, name); mdErr != nil {
+			return vault.Secret{}, mapError(mdErr)
+		}
+
+		return vault.Secret{}, fmt.Errorf("%w: version %d of secret %s", vault.ErrVersionNotFound, version, name)
+	}
 	if err != nil {
-		return vault.Secret{}, err
+		return vault.Secret{}, mapError(err)
 	}
 
 	return d.mapSecret(name, secret)
 }
 
-// GetLatestVersion gets the latest version of a secret from HashiCorp Vault
+// GetLatestVersion gets the latest enabled version of a secret from HashiCorp Vault
 func (d *HCPVaultDriver) GetLatestVersion(
 	ctx context.Context,
 	name string,
 ) (vault.Secret, error) {
 	secret, err := d.kv().Get(ctx, name)
 	if err != nil {
-		return vault.Secret{}, err
+		return vault.Secret{}, mapError(err)
 	}
 
-	return d.mapSecret(name, secret)
+	if secret.Data != nil {
+		return d.mapSecret(name, secret)
+	}
+
+	// the current version is deleted or destroyed,
+	// fall back to the newest version still readable
+	versions, err := d.kv().GetVersionsAsList(ctx, name)
+	if err != nil {
+		return vault.Secret{}, mapError(err)
+	}
+
+	for i := len(versions) - 1; i >= 0; i-- {
+		if mapState(versions[i]) == vault.VersionEnabled {
+			return d.GetVersion(ctx, name, versions[i].Version)
+		}
+	}
+
+	return vault.Secret{}, fmt.Errorf("%w: secret %s has no enabled version", vault.ErrVersionNotFound, name)
 }
 
-// DeleteSecret deletes a secret from HashiCorp Vault
+// DeleteSecret deletes the latest version of a secret from HashiCorp Vault.
+// The delete is soft, the version can be undeleted and the other versions
+// are kept. Use PurgeSecret to remove all versions.
 func (d *HCPVaultDriver) DeleteSecret(
 	ctx context.Context,
 	name string,
 ) error {
-	return d.kv().Delete(ctx, name)
+	// deleting a missing secret succeeds silently
+	if _, err := d.kv().GetMetadata(ctx, name); err != nil {
+		return mapError(err)
+	}
+
+	return mapError(d.kv().Delete(ctx, name))
 }
 
-func (d *HCPVaultDriver) mapSecret(name string, secret *vaultapi.KVSecret) (vault.Secret, error) {
-	payload, ok := secret.Data["data"].([]byte)
+// PurgeSecret permanently deletes a secret with all of its versions and
+// their history from HashiCorp Vault
+func (d *HCPVaultDriver) PurgeSecret(
+	ctx context.Context,
+	name string,
+) error {
+	// deleting the metadata of a missing secret succeeds silently
+	if _, err := d.kv().GetMetadata(ctx, name); err != nil {
+		return mapError(err)
+	}
+
+	return mapError(d.kv().DeleteMetadata(ctx, name))
+}
+
+// ListSecrets recursively lists all secrets of the mount in HashiCorp Vault
+func (d *HCPVaultDriver) ListSecrets(ctx context.Context) ([]vault.SecretMetadata, error) {
+	var secrets []vault.SecretMetadata
+
+	folders := []string{""}
+
+	for len(folders) > 0 {
+		folder := folders[0]
+		folders = folders[1:]
+
+		res, err := d.client.Logical().ListWithContext(ctx, fmt.Sprintf("%s/metadata/%s", d.config.MountPath, folder))
+		if err != nil {
+			return nil, mapError(err)
+		}
+		if res == nil {
+			continue
+		}
+
+		keys, _ := res.Data["keys"].([]interface{})
+		for _, k := range keys {
+			key, ok := k.(string)
+			if !ok {
+				continue
+			}
+
+			// keys ending with a slash are folders containing further secrets
+			if strings.HasSuffix(key, "/") {
+				folders = append(folders, folder+key)
+				continue
+			}
+
+			secrets = append(secrets, vault.SecretMetadata{Name: folder + key})
+		}
+	}
+
+	return secrets, nil
+}
+
+// ListVersions lists all versions of a secret in HashiCorp Vault
+func (d *HCPVaultDriver) ListVersions(ctx context.Context, name string) ([]vault.Secret, error) {
+	versions, err := d.kv().GetVersionsAsList(ctx, name)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	secrets := make([]vault.Secret, len(versions))
+	for i, v := range versions {
+		secrets[i] = vault.Secret{
+			Name:      name,
+			Version:   v.Version,
+			CreatedAt: v.CreatedTime,
+			State:     mapState(v),
+		}
+	}
+
+	return secrets, nil
+}
+
+// EnableVersion restores a deleted version of a secret in HashiCorp Vault
+func (d *HCPVaultDriver) EnableVersion(ctx context.Context, name string, version int) error {
+	if err := d.checkVersion(ctx, name, version); err != nil {
+		return err
+	}
+
+	return mapError(d.kv().Undelete(ctx, name, []int{version}))
+}
+
+// DisableVersion soft deletes a version of a secret in HashiCorp Vault,
+// it can be restored by EnableVersion
+func (d *HCPVaultDriver) DisableVersion(ctx context.Context, name string, version int) error {
+	if err := d.checkVersion(ctx, name, version); err != nil {
+		return err
+	}
+
+	return mapError(d.kv().DeleteVersions(ctx, name, []int{version}))
+}
+
+// DestroyVersion permanently removes a version of a secret in HashiCorp Vault
+func (d *HCPVaultDriver) DestroyVersion(ctx context.Context, name string, version int) error {
+	if err := d.checkVersion(ctx, name, version); err != nil {
+		return err
+	}
+
+	return mapError(d.kv().Destroy(ctx, name, []int{version}))
+}
+
+// checkVersion ensures that the version exists and is not destroyed,
+// as vault silently ignores state changes of unknown versions
+func (d *HCPVaultDriver) checkVersion(ctx context.Context, name string, version int) error {
+	md, err := d.kv().GetMetadata(ctx, name)
+	if err != nil {
+		return mapError(err)
+	}
+
+	v, ok := md.Versions[strconv.Itoa(version)]
 	if !ok {
+		return fmt.Errorf("%w: version %d of secret %s", vault.ErrVersionNotFound, version, name)
+	}
+
+	if v.Destroyed {
+		return fmt.Errorf("%w: version %d of secret %s is destroyed", vault.ErrVersionNotFound, version, name)
+	}
+
+	return nil
+}
+
+// mapWrittenSecret maps the response of a write, which only carries
+// the version metadata, to a vault secret
+func (d *HCPVaultDriver) mapWrittenSecret(
+	name string,
+	payload []byte,
+	secret *vaultapi.KVSecret,
+) (vault.Secret, error) {
+	if secret.VersionMetadata == nil {
+		return vault.Secret{}, fmt.Errorf("unable to parse secret metadata")
+	}
+
+	return vault.Secret{
+		Name:      name,
+		Version:   secret.VersionMetadata.Version,
+		Payload:   payload,
+		CreatedAt: secret.VersionMetadata.CreatedTime,
+		State:     vault.VersionEnabled,
+	}, nil
+}
+
+func (d *HCPVaultDriver) mapSecret(name string, secret *vaultapi.KVSecret) (vault.Secret, error) {
+	if secret.VersionMetadata == nil {
+		return vault.Secret{}, fmt.Errorf("unable to parse secret metadata")
+	}
+
+	if secret.Data == nil {
+		return vault.Secret{}, fmt.Errorf(
+			"%w: version %d of secret %s is %s",
+			vault.ErrVersionNotFound, secret.VersionMetadata.Version, name, mapState(*secret.VersionMetadata),
+		)
+	}
+
+	var payload []byte
+
+	// the payload is written as []byte, which is encoded as base64 string
+	// in json, but keep accepting raw bytes for completeness
+	switch data := secret.Data["data"].(type) {
+	case []byte:
+		payload = data
+	case string:
+		decoded, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return vault.Secret{}, fmt.Errorf("unable to decode secret data: %w", err)
+		}
+		payload = decoded
+	default:
 		return vault.Secret{}, fmt.Errorf("unable to parse secret data")
 	}
 
 	return vault.Secret{
-		Name:    name,
-		Version: secret.VersionMetadata.Version,
-		Payload: payload,
+		Name:      name,
+		Version:   secret.VersionMetadata.Version,
+		Payload:   payload,
+		CreatedAt: secret.VersionMetadata.CreatedTime,
+		State:     mapState(*secret.VersionMetadata),
 	}, nil
 }
 
+// mapError maps HashiCorp Vault api errors to vault errors
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, vaultapi.ErrSecretNotFound) {
+		return fmt.Errorf("%w: %w", vault.ErrSecretNotFound, err)
+	}
+
+	var respErr *vaultapi.ResponseError
+	if !errors.As(err, &respErr) {
+		return err
+	}
+
+	switch respErr.StatusCode {
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %w", vault.ErrSecretNotFound, err)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("%w: %w", vault.ErrPermissionDenied, err)
+	case http.StatusBadRequest:
+		for _, e := range respErr.Errors {
+			if strings.Contains(e, "check-and-set") {
+				return fmt.Errorf("%w: %w", vault.ErrSecretExists, err)
+			}
+		}
+	}
+
+	return err
+}
+
+// mapState maps the metadata of a KV v2 version to a vault version state
+func mapState(metadata vaultapi.KVVersionMetadata) vault.VersionState {
+	if metadata.Destroyed {
+		return vault.VersionDestroyed
+	}
+
+	if !metadata.DeletionTime.IsZero() {
+		return vault.VersionDisabled
+	}
+
+	return vault.VersionEnabled
+}
+
 // hcpAuth authenticates to HashiCorp Vault
 func hcpAuth(
 	ctx context.Context,
@@ -2,13 +2,17 @@ package vaultinfisical
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 
 	infisical "github.com/infisical/go-sdk"
+	infisicalErrors "github.com/infisical/go-sdk/packages/errors"
 	infisicalModels "github.com/infisical/go-sdk/packages/models"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/fruitsco/goji/component/vault"
 	"github.com/fruitsco/goji/x/driver"
@@ -111,7 +115,7 @@ func (d *InfisicalDriver) CreateSecret(
 		SecretValue: string(payload),
 	})
 	if err != nil {
-		return vault.Secret{}, err
+		return vault.Secret{}, mapError(err)
 	}
 
 	return d.mapSecret(secret), nil
@@ -134,7 +138,7 @@ func (d *InfisicalDriver) AddVersion(
 		NewSecretValue: string(payload),
 	})
 	if err != nil {
-		return vault.Secret{}, err
+		return vault.Secret{}, mapError(err)
 	}
 
 	return d.mapSecret(secret), nil
@@ -154,10 +158,20 @@ func (d *InfisicalDriver) GetVersion(
 
 		SecretKey:  key,
 		SecretPath: path,
-		// TODO: add version as soon as it's supported by the sdk
-		// Version:     version,
+		Version:    version,
 	})
 	if err != nil {
+		err = mapError(err)
+
+		// infisical does not tell missing secrets and versions apart
+		if errors.Is(err, vault.ErrSecretNotFound) {
+			if _, latestErr := d.GetLatestVersion(ctx, name); latestErr != nil {
+				return vault.Secret{}, latestErr
+			}
+
+			return vault.Secret{}, fmt.Errorf("%w: version %d of secret %s", vault.ErrVersionNotFound, version, name)
+		}
+
 		return vault.Secret{}, err
 	}
 
@@ -179,7 +193,7 @@ func (d *InfisicalDriver) GetLatestVersion(
 		SecretPath: path,
 	})
 	if err != nil {
-		return vault.Secret{}, err
+		return vault.Secret{}, mapError(err)
 	}
 
 	return d.mapSecret(secret), nil
@@ -199,9 +213,127 @@ func (d *InfisicalDriver) DeleteSecret(
 		SecretKey:  key,
 		SecretPath: path,
 	})
+	return mapError(err)
+}
+
+// ListSecrets recursively lists all secrets of the environment in Infisical
+func (d *InfisicalDriver) ListSecrets(ctx context.Context) ([]vault.SecretMetadata, error) {
+	secrets, err := d.client.Secrets().List(infisical.ListSecretsOptions{
+		ProjectID:   d.config.ProjectID,
+		Environment: d.config.Environment,
+
+		SecretPath: "/",
+		Recursive:  true,
+	})
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	result := make([]vault.SecretMetadata, len(secrets))
+	for i, secret := range secrets {
+		name := secret.SecretKey
+		if path := strings.Trim(secret.SecretPath, "/"); path != "" {
+			name = path + "/" + name
+		}
+
+		result[i] = vault.SecretMetadata{Name: name}
+	}
+
+	return result, nil
+}
+
+// listConcurrency is the number of versions ListVersions retrieves at once
+const listConcurrency = 8
+
+// ListVersions lists the versions of a secret in Infisical. The sdk has no
+// endpoint listing versions, so each version is retrieved with a separate
+// request, up to listConcurrency at once. Only the MaxVersions most recent
+// versions of the config are listed, which bounds the cost of a call to as
+// many requests. Versions removed by the retention of the project are
+// skipped.
+func (d *InfisicalDriver) ListVersions(ctx context.Context, name string) ([]vault.Secret, error) {
+	latest, err := d.GetLatestVersion(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	latest.Payload = nil
+
+	first := 1
+	if d.config.MaxVersions > 0 {
+		first = max(first, latest.Version-d.config.MaxVersions+1)
+	}
+
+	path, key := d.getSecretPathAndKey(name)
+
+	// versions are retrieved into their slot, missing ones stay nil
+	found := make([]*vault.Secret, latest.Version-first)
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(listConcurrency)
+
+	for i := range found {
+		g.Go(func() error {
+			if err := gctx.Err(); err != nil {
+				return err
+			}
+
+			secret, err := d.client.Secrets().Retrieve(infisical.RetrieveSecretOptions{
+				ProjectID:   d.config.ProjectID,
+				Environment: d.config.Environment,
+
+				SecretKey:  key,
+				SecretPath: path,
+				Version:    first + i,
+			})
+			if err != nil {
+				if err = mapError(err); errors.Is(err, vault.ErrSecretNotFound) {
+					return nil
+				}
+
+				return err
+			}
+
+			version := d.mapVersion(secret)
+			found[i] = &version
+
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	versions := make([]vault.Secret, 0, len(found)+1)
+	for _, version := range found {
+		if version != nil {
+			versions = append(versions, *version)
+		}
+	}
+
+	return append(versions, latest), nil
+}
+
+// EnableVersion checks that the version exists, versions cannot be
+// disabled in Infisical and are always enabled
+func (d *InfisicalDriver) EnableVersion(ctx context.Context, name string, version int) error {
+	_, err := d.GetVersion(ctx, name, version)
 	return err
 }
 
+// DisableVersion is not supported by Infisical, which has no states of
+// versions
+func (d *InfisicalDriver) DisableVersion(ctx context.Context, name string, version int) error {
+	return fmt.Errorf("infisical: disable version: %w", vault.ErrNotSupported)
+}
+
+// DestroyVersion is not supported by Infisical, which keeps the values of
+// all versions until the secret is deleted
+func (d *InfisicalDriver) DestroyVersion(ctx context.Context, name string, version int) error {
+	return fmt.Errorf("infisical: destroy version: %w", vault.ErrNotSupported)
+}
+
 // getSecretPathAndKey splits the secret name into path and key
 func (d *InfisicalDriver) getSecretPathAndKey(name string) (string, string) {
 	lastSlash := strings.LastIndex(name, "/")
@@ -218,9 +350,42 @@ func (d *InfisicalDriver) mapSecret(secret infisicalModels.Secret) vault.Secret
 		Name:    fmt.Sprintf("%s/%s", secret.SecretPath, secret.SecretKey),
 		Version: secret.Version,
 		Payload: []byte(secret.SecretValue),
+		// infisical has no states of versions
+		State: vault.VersionEnabled,
 	}
 }
 
+// mapVersion maps an Infisical secret to a vault secret without payload
+func (d *InfisicalDriver) mapVersion(secret infisicalModels.Secret) vault.Secret {
+	s := d.mapSecret(secret)
+	s.Payload = nil
+	return s
+}
+
+// mapError maps Infisical api errors to vault errors
+func mapError(err error) error {
+	var apiErr *infisicalErrors.APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+
+	switch apiErr.StatusCode {
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %w", vault.ErrSecretNotFound, err)
+	case http.StatusConflict:
+		return fmt.Errorf("%w: %w", vault.ErrSecretExists, err)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("%w: %w", vault.ErrPermissionDenied, err)
+	case http.StatusBadRequest:
+		// infisical rejects duplicate secrets as bad request
+		if strings.Contains(strings.ToLower(apiErr.ErrorMessage), "already exist") {
+			return fmt.Errorf("%w: %w", vault.ErrSecretExists, err)
+		}
+	}
+
+	return err
+}
+
 // infisicalAuth authenticates the infisical client based on the configuration
 func infisicalAuth(
 	client infisical.AuthInterface,
@@ -0,0 +1,93 @@
+package vaultinfisical
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	infisical "github.com/infisical/go-sdk"
+	infisicalErrors "github.com/infisical/go-sdk/packages/errors"
+	infisicalModels "github.com/infisical/go-sdk/packages/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/fruitsco/goji/component/vault"
+)
+
+// fakeClient serves the versions of secrets by key
+type fakeClient struct {
+	infisical.InfisicalClientInterface
+	secrets *fakeSecrets
+}
+
+func (c *fakeClient) Secrets() infisical.SecretsInterface {
+	return c.secrets
+}
+
+type fakeSecrets struct {
+	infisical.SecretsInterface
+	versions map[string]map[int]string
+	latest   map[string]int
+}
+
+func (s *fakeSecrets) Retrieve(options infisical.RetrieveSecretOptions) (infisicalModels.Secret, error) {
+	version := options.Version
+	if version == 0 {
+		version = s.latest[options.SecretKey]
+	}
+
+	value, ok := s.versions[options.SecretKey][version]
+	if !ok {
+		return infisicalModels.Secret{}, &infisicalErrors.APIError{StatusCode: http.StatusNotFound}
+	}
+
+	return infisicalModels.Secret{
+		SecretKey:   options.SecretKey,
+		SecretPath:  options.SecretPath,
+		SecretValue: value,
+		Version:     version,
+	}, nil
+}
+
+func TestInfisicalDriver_Versions(t *testing.T) {
+	ctx := context.Background()
+
+	d := &InfisicalDriver{
+		config: &vault.InfisicalConfig{ProjectID: "project", Environment: "prod"},
+		client: &fakeClient{secrets: &fakeSecrets{
+			// version 2 was removed by the retention of the project
+			versions: map[string]map[int]string{"token": {1: "v1", 3: "v3", 4: "v4"}},
+			latest:   map[string]int{"token": 4},
+		}},
+		log: zap.NewNop(),
+	}
+
+	versions, err := d.ListVersions(ctx, "token")
+	require.NoError(t, err)
+	require.Len(t, versions, 3)
+
+	for i, version := range []int{1, 3, 4} {
+		assert.Equal(t, version, versions[i].Version)
+		assert.Equal(t, vault.VersionEnabled, versions[i].State)
+		assert.Nil(t, versions[i].Payload)
+	}
+
+	// only the most recent versions are listed if bounded
+	d.config.MaxVersions = 2
+
+	versions, err = d.ListVersions(ctx, "token")
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, 3, versions[0].Version)
+	assert.Equal(t, 4, versions[1].Version)
+
+	_, err = d.ListVersions(ctx, "missing")
+	require.ErrorIs(t, err, vault.ErrSecretNotFound)
+
+	require.NoError(t, d.EnableVersion(ctx, "token", 3))
+	require.ErrorIs(t, d.EnableVersion(ctx, "token", 2), vault.ErrVersionNotFound)
+
+	require.ErrorIs(t, d.DisableVersion(ctx, "token", 3), vault.ErrNotSupported)
+	require.ErrorIs(t, d.DestroyVersion(ctx, "token", 3), vault.ErrNotSupported)
+}
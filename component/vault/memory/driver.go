@@ -0,0 +1,285 @@
+package vaultmemory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"github.com/fruitsco/goji/component/vault"
+	"github.com/fruitsco/goji/x/driver"
+)
+
+// memorySecret is a secret with all of its versions
+type memorySecret struct {
+	createdAt time.Time
+	versions  []*memoryVersion
+}
+
+// memoryVersion is a single version of a secret
+type memoryVersion struct {
+	payload   []byte
+	createdAt time.Time
+	state     vault.VersionState
+}
+
+// MemoryDriver is an in-memory vault driver. Secrets are lost when the
+// process exits, which makes it suitable for development and tests only.
+type MemoryDriver struct {
+	mu      sync.RWMutex
+	secrets map[string]*memorySecret
+	log     *zap.Logger
+}
+
+// MemoryDriverParams is the parameters for the memory driver
+type MemoryDriverParams struct {
+	fx.In
+
+	// Log is the logger for the memory driver
+	Log *zap.Logger `optional:"true"`
+}
+
+// NewMemoryDriverFactory creates a new memory driver factory
+func NewMemoryDriverFactory(params MemoryDriverParams) driver.FactoryResult[vault.DriverName, vault.Driver] {
+	return driver.NewFactory(vault.Memory, func() (vault.Driver, error) {
+		return NewMemoryDriver(params), nil
+	})
+}
+
+// NewMemoryDriver creates a new memory driver
+func NewMemoryDriver(params MemoryDriverParams) *MemoryDriver {
+	if params.Log == nil {
+		params.Log = zap.NewNop()
+	}
+
+	return &MemoryDriver{
+		secrets: make(map[string]*memorySecret),
+		log:     params.Log.Named("memory"),
+	}
+}
+
+var _ = vault.Driver(&MemoryDriver{})
+
+// CreateSecret creates a new secret with the payload as first version
+func (d *MemoryDriver) CreateSecret(
+	_ context.Context,
+	name string,
+	payload []byte,
+) (vault.Secret, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.secrets[name]; ok {
+		return vault.Secret{}, fmt.Errorf("%w: %s", vault.ErrSecretExists, name)
+	}
+
+	v := newVersion(payload)
+
+	d.secrets[name] = &memorySecret{
+		createdAt: v.createdAt,
+		versions:  []*memoryVersion{v},
+	}
+
+	return mapSecret(name, 1, v), nil
+}
+
+// AddVersion adds a new version to an existing secret
+func (d *MemoryDriver) AddVersion(
+	_ context.Context,
+	name string,
+	payload []byte,
+) (vault.Secret, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	secret, ok := d.secrets[name]
+	if !ok {
+		return vault.Secret{}, fmt.Errorf("%w: %s", vault.ErrSecretNotFound, name)
+	}
+
+	v := newVersion(payload)
+	secret.versions = append(secret.versions, v)
+
+	return mapSecret(name, len(secret.versions), v), nil
+}
+
+// GetVersion retrieves a specific, 1-based version of a secret
+func (d *MemoryDriver) GetVersion(
+	_ context.Context,
+	name string,
+	version int,
+) (vault.Secret, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	v, err := d.getVersion(name, version)
+	if err != nil {
+		return vault.Secret{}, err
+	}
+
+	if v.state != vault.VersionEnabled {
+		return vault.Secret{}, fmt.Errorf("%w: version %d of secret %s is %s", vault.ErrVersionNotFound, version, name, v.state)
+	}
+
+	return mapSecret(name, version, v), nil
+}
+
+// GetLatestVersion retrieves the latest enabled version of a secret
+func (d *MemoryDriver) GetLatestVersion(
+	_ context.Context,
+	name string,
+) (vault.Secret, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	secret, ok := d.secrets[name]
+	if !ok {
+		return vault.Secret{}, fmt.Errorf("%w: %s", vault.ErrSecretNotFound, name)
+	}
+
+	for i := len(secret.versions) - 1; i >= 0; i-- {
+		if v := secret.versions[i]; v.state == vault.VersionEnabled {
+			return mapSecret(name, i+1, v), nil
+		}
+	}
+
+	return vault.Secret{}, fmt.Errorf("%w: secret %s has no enabled version", vault.ErrVersionNotFound, name)
+}
+
+// DeleteSecret deletes a secret and all of its versions
+func (d *MemoryDriver) DeleteSecret(_ context.Context, name string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.secrets[name]; !ok {
+		return fmt.Errorf("%w: %s", vault.ErrSecretNotFound, name)
+	}
+
+	delete(d.secrets, name)
+
+	return nil
+}
+
+// ListSecrets lists all secrets ordered by name
+func (d *MemoryDriver) ListSecrets(_ context.Context) ([]vault.SecretMetadata, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	secrets := make([]vault.SecretMetadata, 0, len(d.secrets))
+	for name, secret := range d.secrets {
+		secrets = append(secrets, vault.SecretMetadata{
+			Name:      name,
+			CreatedAt: secret.createdAt,
+		})
+	}
+
+	sort.Slice(secrets, func(i, j int) bool {
+		return secrets[i].Name < secrets[j].Name
+	})
+
+	return secrets, nil
+}
+
+// ListVersions lists all versions of a secret without their payload
+func (d *MemoryDriver) ListVersions(_ context.Context, name string) ([]vault.Secret, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	secret, ok := d.secrets[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", vault.ErrSecretNotFound, name)
+	}
+
+	versions := make([]vault.Secret, len(secret.versions))
+	for i, v := range secret.versions {
+		versions[i] = vault.Secret{
+			Name:      name,
+			Version:   i + 1,
+			CreatedAt: v.createdAt,
+			State:     v.state,
+		}
+	}
+
+	return versions, nil
+}
+
+// EnableVersion enables a disabled version of a secret
+func (d *MemoryDriver) EnableVersion(_ context.Context, name string, version int) error {
+	return d.setState(name, version, vault.VersionEnabled)
+}
+
+// DisableVersion disables a version of a secret
+func (d *MemoryDriver) DisableVersion(_ context.Context, name string, version int) error {
+	return d.setState(name, version, vault.VersionDisabled)
+}
+
+// DestroyVersion destroys the payload of a version of a secret
+func (d *MemoryDriver) DestroyVersion(_ context.Context, name string, version int) error {
+	return d.setState(name, version, vault.VersionDestroyed)
+}
+
+// setState changes the state of a version. Destroyed versions
+// cannot change their state anymore.
+func (d *MemoryDriver) setState(name string, version int, state vault.VersionState) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	v, err := d.getVersion(name, version)
+	if err != nil {
+		return err
+	}
+
+	if v.state == vault.VersionDestroyed {
+		return fmt.Errorf("%w: version %d of secret %s is destroyed", vault.ErrVersionNotFound, version, name)
+	}
+
+	v.state = state
+
+	if state == vault.VersionDestroyed {
+		v.payload = nil
+	}
+
+	return nil
+}
+
+// getVersion returns a version of a secret, the lock must be held by the caller
+func (d *MemoryDriver) getVersion(name string, version int) (*memoryVersion, error) {
+	secret, ok := d.secrets[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", vault.ErrSecretNotFound, name)
+	}
+
+	if version < 1 || version > len(secret.versions) {
+		return nil, fmt.Errorf("%w: version %d of secret %s", vault.ErrVersionNotFound, version, name)
+	}
+
+	return secret.versions[version-1], nil
+}
+
+func newVersion(payload []byte) *memoryVersion {
+	return &memoryVersion{
+		payload:   clone(payload),
+		createdAt: time.Now().UTC(),
+		state:     vault.VersionEnabled,
+	}
+}
+
+func mapSecret(name string, version int, v *memoryVersion) vault.Secret {
+	return vault.Secret{
+		Name:      name,
+		Version:   version,
+		Payload:   clone(v.payload),
+		CreatedAt: v.createdAt,
+		State:     v.state,
+	}
+}
+
+// clone copies the payload, so that neither the caller nor
+// the driver can mutate each others data
+func clone(payload []byte) []byte {
+	return append([]byte{}, payload...)
+}
@@ -0,0 +1,15 @@
+package vaultmemory_test
+
+import (
+	"testing"
+
+	"github.com/fruitsco/goji/component/vault"
+	vaultmemory "github.com/fruitsco/goji/component/vault/memory"
+	"github.com/fruitsco/goji/component/vault/vaulttest"
+)
+
+func TestMemoryDriver_Conformance(t *testing.T) {
+	vaulttest.TestDriver(t, func(t *testing.T) vault.Driver {
+		return vaultmemory.NewMemoryDriver(vaultmemory.MemoryDriverParams{})
+	})
+}
@@ -0,0 +1,11 @@
+package vaultmemory
+
+import (
+	"go.uber.org/fx"
+)
+
+func Module() fx.Option {
+	return fx.Options(
+		fx.Provide(NewMemoryDriverFactory),
+	)
+}
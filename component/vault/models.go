@@ -1,7 +1,38 @@
 package vault
 
+import "time"
+
+// VersionState is the state of a secret version
+type VersionState string
+
+const (
+	// VersionEnabled marks a version that can be accessed
+	VersionEnabled VersionState = "enabled"
+
+	// VersionDisabled marks a version that cannot be accessed
+	// until it is enabled again
+	VersionDisabled VersionState = "disabled"
+
+	// VersionDestroyed marks a version whose payload is irrevocably gone
+	VersionDestroyed VersionState = "destroyed"
+)
+
 type Secret struct {
 	Name    string
 	Version int
 	Payload []byte
+
+	// CreatedAt is the creation time of the version, zero if unknown
+	CreatedAt time.Time
+
+	// State is the state of the version, empty if unknown
+	State VersionState
+}
+
+// SecretMetadata describes a secret without any of its versions
+type SecretMetadata struct {
+	Name string
+
+	// CreatedAt is the creation time of the secret, zero if unknown
+	CreatedAt time.Time
 }
@@ -0,0 +1,61 @@
+package vaultredis
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/fruitsco/goji/component/vault"
+)
+
+func TestRedisDriver_ListSecretsCluster(t *testing.T) {
+	ctx := context.Background()
+
+	// the slots are split across two nodes
+	first, second := miniredis.RunT(t), miniredis.RunT(t)
+
+	client := goredis.NewClusterClient(&goredis.ClusterOptions{
+		ClusterSlots: func(context.Context) ([]goredis.ClusterSlot, error) {
+			return []goredis.ClusterSlot{
+				{Start: 0, End: 8191, Nodes: []goredis.ClusterNode{{Addr: first.Addr()}}},
+				{Start: 8192, End: 16383, Nodes: []goredis.ClusterNode{{Addr: second.Addr()}}},
+			}, nil
+		},
+	})
+	t.Cleanup(func() { client.Close() })
+
+	d := &RedisDriver{
+		config: &vault.RedisConfig{EncryptionKey: "0123456789abcdef0123456789abcdef"},
+		redis:  client,
+		log:    zap.NewNop(),
+	}
+
+	var names []string
+	for i := range 10 {
+		name := "secret-" + strconv.Itoa(i)
+		names = append(names, name)
+
+		_, err := d.CreateSecret(ctx, name, []byte("v1"))
+		require.NoError(t, err)
+	}
+
+	// both nodes store secrets
+	require.NotEmpty(t, first.Keys())
+	require.NotEmpty(t, second.Keys())
+
+	secrets, err := d.ListSecrets(ctx)
+	require.NoError(t, err)
+
+	var listed []string
+	for _, s := range secrets {
+		listed = append(listed, s.Name)
+	}
+	assert.ElementsMatch(t, names, listed)
+
+}
@@ -6,8 +6,15 @@ import (
 	"crypto/cipher"
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	goredis "github.com/redis/go-redis/v9"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 
@@ -16,10 +23,76 @@ import (
 	"github.com/fruitsco/goji/x/driver"
 )
 
+// versionMeta is the metadata of a single version, stored in a hash next
+// to the list of versions. Versions without metadata are enabled.
+type versionMeta struct {
+	State     vault.VersionState `json:"state"`
+	CreatedAt time.Time          `json:"created_at,omitzero"`
+}
+
+// createScript pushes the first version with its metadata only if the
+// secret does not exist yet
+var createScript = goredis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return 0
+end
+local version = redis.call("LPUSH", KEYS[1], ARGV[1])
+redis.call("HSET", KEYS[2], tostring(version), ARGV[2])
+return version
+`)
+
+// addScript pushes a version with its metadata only if the secret exists
+var addScript = goredis.NewScript(`
+local version = redis.call("LPUSHX", KEYS[1], ARGV[1])
+if version > 0 then
+	redis.call("HSET", KEYS[2], tostring(version), ARGV[2])
+end
+return version
+`)
+
+// migrateScript moves a secret from the keys used before they were hash
+// tagged, unless the secret already exists at the new keys
+var migrateScript = goredis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 or redis.call("EXISTS", KEYS[3]) == 0 then
+	return 0
+end
+redis.call("RENAME", KEYS[3], KEYS[1])
+if redis.call("EXISTS", KEYS[4]) == 1 then
+	redis.call("RENAME", KEYS[4], KEYS[2])
+end
+return 1
+`)
+
+// readScript reads the metadata and payload of a version in one step, so
+// that they cannot be changed in between. The latest enabled version is
+// read if the version is 0. It returns 0 if the secret does not exist and
+// -1 if the version does not exist.
+var readScript = goredis.NewScript(`
+local count = redis.call("LLEN", KEYS[1])
+if count == 0 then
+	return {0}
+end
+local version = tonumber(ARGV[1])
+if version > count then
+	return {-1}
+end
+local first, last = version, version
+if version == 0 then
+	first, last = count, 1
+end
+for v = first, last, -1 do
+	local meta = redis.call("HGET", KEYS[2], tostring(v))
+	if version > 0 or not meta or cjson.decode(meta).state == ARGV[2] then
+		return {v, meta or "", redis.call("LINDEX", KEYS[1], -v)}
+	end
+end
+return {-1}
+`)
+
 // RedisDriver is the driver for Redis
 type RedisDriver struct {
 	config *vault.RedisConfig
-	redis  *redis.Client
+	redis  redis.Client
 	log    *zap.Logger
 }
 
@@ -85,19 +158,32 @@ func (d *RedisDriver) CreateSecret(
 		return vault.Secret{}, fmt.Errorf("failed to encrypt payload: %w", err)
 	}
 
-	res := d.redis.LPush(ctx, d.getKeyName(name), encryptedPayload)
-	if res.Err() != nil {
-		return vault.Secret{}, res.Err()
+	meta := versionMeta{State: vault.VersionEnabled, CreatedAt: time.Now().UTC()}
+	data, err := encodeMeta(meta)
+	if err != nil {
+		return vault.Secret{}, err
 	}
 
-	// `lpush` returns the length of the list after the push,
-	// which corresponds to the 1-based version number
-	version := int(res.Val())
+	// a secret stored at the legacy keys already exists
+	if _, err := d.migrateLegacy(ctx, name); err != nil {
+		return vault.Secret{}, err
+	}
+
+	res, err := createScript.Run(ctx, d.redis, d.keys(name), encryptedPayload, data).Int()
+	if err != nil {
+		return vault.Secret{}, mapError(err)
+	}
+
+	if res == 0 {
+		return vault.Secret{}, fmt.Errorf("%w: %s", vault.ErrSecretExists, name)
+	}
 
 	return vault.Secret{
-		Name:    name,
-		Version: version,
-		Payload: payload,
+		Name:      name,
+		Version:   res,
+		Payload:   payload,
+		CreatedAt: meta.CreatedAt,
+		State:     meta.State,
 	}, nil
 }
 
@@ -106,22 +192,45 @@ func (d *RedisDriver) AddVersion(
 	ctx context.Context,
 	name string,
 	payload []byte,
+) (vault.Secret, error) {
+	return withLegacy(ctx, d, name, func() (vault.Secret, error) {
+		return d.addVersion(ctx, name, payload)
+	})
+}
+
+func (d *RedisDriver) addVersion(
+	ctx context.Context,
+	name string,
+	payload []byte,
 ) (vault.Secret, error) {
 	encryptedPayload, err := d.encrypt(payload)
 	if err != nil {
 		return vault.Secret{}, fmt.Errorf("failed to encrypt payload: %w", err)
 	}
 
-	res := d.redis.LPush(ctx, d.getKeyName(name), encryptedPayload)
+	meta := versionMeta{State: vault.VersionEnabled, CreatedAt: time.Now().UTC()}
+	data, err := encodeMeta(meta)
+	if err != nil {
+		return vault.Secret{}, err
+	}
 
-	// `lpush` returns the length of the list after the push,
+	// the script returns the length of the list after the push,
 	// which corresponds to the 1-based version number
-	version := int(res.Val())
+	version, err := addScript.Run(ctx, d.redis, d.keys(name), encryptedPayload, data).Int()
+	if err != nil {
+		return vault.Secret{}, mapError(err)
+	}
+
+	if version == 0 {
+		return vault.Secret{}, fmt.Errorf("%w: %s", vault.ErrSecretNotFound, name)
+	}
 
 	return vault.Secret{
-		Name:    name,
-		Version: version,
-		Payload: payload,
+		Name:      name,
+		Version:   version,
+		Payload:   payload,
+		CreatedAt: meta.CreatedAt,
+		State:     meta.State,
 	}, nil
 }
 
@@ -131,61 +240,365 @@ func (d *RedisDriver) GetVersion(
 	name string,
 	version int,
 ) (vault.Secret, error) {
-	res := d.redis.LIndex(ctx, d.getKeyName(name), int64(-version))
-	if res.Err() != nil {
-		return vault.Secret{}, res.Err()
-	}
+	return withLegacy(ctx, d, name, func() (vault.Secret, error) {
+		return d.getVersion(ctx, name, version)
+	})
+}
 
-	decryptedPayload, err := d.decrypt(res.Val())
-	if err != nil {
-		return vault.Secret{}, fmt.Errorf("failed to decrypt payload: %w", err)
+func (d *RedisDriver) getVersion(
+	ctx context.Context,
+	name string,
+	version int,
+) (vault.Secret, error) {
+	// a negative index would silently address versions from the other end
+	if version < 1 {
+		return vault.Secret{}, fmt.Errorf("%w: version %d of secret %s", vault.ErrVersionNotFound, version, name)
 	}
 
-	return vault.Secret{
-		Name:    name,
-		Version: version,
-		Payload: decryptedPayload,
-	}, nil
+	return d.read(ctx, name, version)
 }
 
-// GetLatestVersion retrieves the latest version of a secret from Redis
+// GetLatestVersion retrieves the latest enabled version of a secret from Redis
 func (d *RedisDriver) GetLatestVersion(
 	ctx context.Context,
 	name string,
 ) (vault.Secret, error) {
-	itemRes := d.redis.LIndex(ctx, d.getKeyName(name), 0)
-	if itemRes.Err() != nil {
-		return vault.Secret{}, itemRes.Err()
+	return withLegacy(ctx, d, name, func() (vault.Secret, error) {
+		return d.read(ctx, name, 0)
+	})
+}
+
+// read returns an enabled version of a secret, the latest if version is 0
+func (d *RedisDriver) read(ctx context.Context, name string, version int) (vault.Secret, error) {
+	res, err := readScript.Run(ctx, d.redis, d.keys(name), version, string(vault.VersionEnabled)).Slice()
+	if err != nil {
+		return vault.Secret{}, mapError(err)
+	}
+
+	found, _ := res[0].(int64)
+
+	switch {
+	case found == 0:
+		return vault.Secret{}, fmt.Errorf("%w: %s", vault.ErrSecretNotFound, name)
+	case found < 0 && version == 0:
+		return vault.Secret{}, fmt.Errorf("%w: secret %s has no enabled version", vault.ErrVersionNotFound, name)
+	case found < 0 || len(res) < 3:
+		return vault.Secret{}, fmt.Errorf("%w: version %d of secret %s", vault.ErrVersionNotFound, version, name)
 	}
 
-	// decrypt the payload
-	decryptedPayload, err := d.decrypt(itemRes.Val())
+	data, _ := res[1].(string)
+	payload, _ := res[2].(string)
+
+	meta, err := decodeMeta(data)
 	if err != nil {
-		return vault.Secret{}, fmt.Errorf("failed to decrypt payload: %w", err)
+		return vault.Secret{}, err
 	}
 
-	// get the length of the list, which corresponds to the version number
-	lenRes := d.redis.LLen(ctx, d.getKeyName(name))
-	if lenRes.Err() != nil {
-		return vault.Secret{}, lenRes.Err()
+	// destroyed versions keep an empty entry in the list
+	if meta.State != vault.VersionEnabled || payload == "" {
+		return vault.Secret{}, fmt.Errorf("%w: version %d of secret %s is %s", vault.ErrVersionNotFound, found, name, meta.State)
+	}
+
+	decryptedPayload, err := d.decrypt(payload)
+	if err != nil {
+		return vault.Secret{}, fmt.Errorf("failed to decrypt payload: %w", err)
 	}
 
 	return vault.Secret{
-		Name:    name,
-		Version: int(lenRes.Val()),
-		Payload: decryptedPayload,
+		Name:      name,
+		Version:   int(found),
+		Payload:   decryptedPayload,
+		CreatedAt: meta.CreatedAt,
+		State:     meta.State,
 	}, nil
 }
 
 // DeleteSecret deletes a secret from Redis
 func (d *RedisDriver) DeleteSecret(ctx context.Context, name string) error {
-	res := d.redis.Del(ctx, d.getKeyName(name))
-	return res.Err()
+	_, err := withLegacy(ctx, d, name, func() (struct{}, error) {
+		return struct{}{}, d.deleteSecret(ctx, name)
+	})
+
+	return err
+}
+
+func (d *RedisDriver) deleteSecret(ctx context.Context, name string) error {
+	res := d.redis.Del(ctx, d.keys(name)...)
+	if res.Err() != nil {
+		return mapError(res.Err())
+	}
+
+	if res.Val() == 0 {
+		return fmt.Errorf("%w: %s", vault.ErrSecretNotFound, name)
+	}
+
+	return nil
 }
 
-// getKeyname returns the key name for the secret
+// ListSecrets lists all secrets stored in Redis. In a cluster, the keys
+// of all masters are scanned.
+func (d *RedisDriver) ListSecrets(ctx context.Context) ([]vault.SecretMetadata, error) {
+	var (
+		mu      sync.Mutex
+		secrets []vault.SecretMetadata
+	)
+
+	seen := make(map[string]bool)
+
+	scan := func(ctx context.Context, client goredis.Cmdable) error {
+		it := client.Scan(ctx, 0, keyPrefix+"*", 100).Iterator()
+		for it.Next(ctx) {
+			name := strings.TrimPrefix(it.Val(), keyPrefix)
+
+			// secrets are listed by their hash tagged keys,
+			// but may still be stored at the legacy keys
+			if strings.HasPrefix(name, "{") && strings.HasSuffix(name, "}") {
+				name = name[1 : len(name)-1]
+			}
+
+			mu.Lock()
+			if !seen[name] {
+				seen[name] = true
+				secrets = append(secrets, vault.SecretMetadata{Name: name})
+			}
+			mu.Unlock()
+		}
+
+		return it.Err()
+	}
+
+	var err error
+	if cluster, ok := d.redis.(*goredis.ClusterClient); ok {
+		err = cluster.ForEachMaster(ctx, func(ctx context.Context, client *goredis.Client) error {
+			return scan(ctx, client)
+		})
+	} else {
+		err = scan(ctx, d.redis)
+	}
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return secrets, nil
+}
+
+// ListVersions lists all versions of a secret stored in Redis
+func (d *RedisDriver) ListVersions(ctx context.Context, name string) ([]vault.Secret, error) {
+	return withLegacy(ctx, d, name, func() ([]vault.Secret, error) {
+		return d.listVersions(ctx, name)
+	})
+}
+
+func (d *RedisDriver) listVersions(ctx context.Context, name string) ([]vault.Secret, error) {
+	var lenRes *goredis.IntCmd
+	var metaRes *goredis.MapStringStringCmd
+
+	// read both keys in a transaction to get a consistent view,
+	// they share their hash slot
+	_, err := d.redis.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		lenRes = pipe.LLen(ctx, d.getKeyName(name))
+		metaRes = pipe.HGetAll(ctx, d.getMetaKeyName(name))
+		return nil
+	})
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	count := int(lenRes.Val())
+	if count == 0 {
+		return nil, fmt.Errorf("%w: %s", vault.ErrSecretNotFound, name)
+	}
+
+	versions := make([]vault.Secret, count)
+	for i := range versions {
+		meta, err := decodeMeta(metaRes.Val()[strconv.Itoa(i+1)])
+		if err != nil {
+			return nil, err
+		}
+
+		versions[i] = vault.Secret{
+			Name:      name,
+			Version:   i + 1,
+			CreatedAt: meta.CreatedAt,
+			State:     meta.State,
+		}
+	}
+
+	return versions, nil
+}
+
+// EnableVersion enables a disabled version of a secret in Redis
+func (d *RedisDriver) EnableVersion(ctx context.Context, name string, version int) error {
+	return d.setState(ctx, name, version, vault.VersionEnabled)
+}
+
+// DisableVersion disables a version of a secret in Redis
+func (d *RedisDriver) DisableVersion(ctx context.Context, name string, version int) error {
+	return d.setState(ctx, name, version, vault.VersionDisabled)
+}
+
+// DestroyVersion overwrites the payload of a version of a secret in Redis
+func (d *RedisDriver) DestroyVersion(ctx context.Context, name string, version int) error {
+	return d.setState(ctx, name, version, vault.VersionDestroyed)
+}
+
+// setState changes the state of a version. Destroyed versions
+// cannot change their state anymore.
+func (d *RedisDriver) setState(ctx context.Context, name string, version int, state vault.VersionState) error {
+	_, err := withLegacy(ctx, d, name, func() (struct{}, error) {
+		meta, err := d.getMeta(ctx, name, version)
+		if err != nil {
+			return struct{}{}, err
+		}
+
+		if meta.State == vault.VersionDestroyed {
+			return struct{}{}, fmt.Errorf("%w: version %d of secret %s is destroyed", vault.ErrVersionNotFound, version, name)
+		}
+
+		meta.State = state
+
+		data, err := encodeMeta(meta)
+		if err != nil {
+			return struct{}{}, err
+		}
+
+		_, err = d.redis.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+			if state == vault.VersionDestroyed {
+				// the list keeps its length, so that version numbers stay stable
+				pipe.LSet(ctx, d.getKeyName(name), int64(-version), "")
+			}
+
+			pipe.HSet(ctx, d.getMetaKeyName(name), strconv.Itoa(version), data)
+
+			return nil
+		})
+
+		return struct{}{}, mapError(err)
+	})
+
+	return err
+}
+
+// getMeta returns the metadata of an existing version
+func (d *RedisDriver) getMeta(ctx context.Context, name string, version int) (versionMeta, error) {
+	lenRes := d.redis.LLen(ctx, d.getKeyName(name))
+	if lenRes.Err() != nil {
+		return versionMeta{}, mapError(lenRes.Err())
+	}
+
+	if lenRes.Val() == 0 {
+		return versionMeta{}, fmt.Errorf("%w: %s", vault.ErrSecretNotFound, name)
+	}
+
+	// a negative index would silently address versions from the other end
+	if version < 1 || version > int(lenRes.Val()) {
+		return versionMeta{}, fmt.Errorf("%w: version %d of secret %s", vault.ErrVersionNotFound, version, name)
+	}
+
+	res := d.redis.HGet(ctx, d.getMetaKeyName(name), strconv.Itoa(version))
+	if res.Err() != nil && !errors.Is(res.Err(), goredis.Nil) {
+		return versionMeta{}, mapError(res.Err())
+	}
+
+	return decodeMeta(res.Val())
+}
+
+func encodeMeta(meta versionMeta) (string, error) {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode version metadata: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// withLegacy runs fn, and runs it again if the secret was not found but
+// could be migrated from the legacy keys
+func withLegacy[T any](ctx context.Context, d *RedisDriver, name string, fn func() (T, error)) (T, error) {
+	res, err := fn()
+	if !errors.Is(err, vault.ErrSecretNotFound) {
+		return res, err
+	}
+
+	migrated, migrateErr := d.migrateLegacy(ctx, name)
+	if migrateErr != nil {
+		return res, migrateErr
+	}
+
+	if !migrated {
+		return res, err
+	}
+
+	return fn()
+}
+
+// migrateLegacy moves a secret from the keys used before they were hash
+// tagged. Cluster mode was introduced after the keys were hash tagged,
+// so there are no legacy keys in a cluster.
+func (d *RedisDriver) migrateLegacy(ctx context.Context, name string) (bool, error) {
+	if _, ok := d.redis.(*goredis.ClusterClient); ok {
+		return false, nil
+	}
+
+	// the legacy keys have the name without hash tag
+	keys := append(d.keys(name), keyPrefix+name, metaKeyPrefix+name)
+
+	res, err := migrateScript.Run(ctx, d.redis, keys).Int()
+	if err != nil {
+		return false, mapError(err)
+	}
+
+	return res == 1, nil
+}
+
+// mapError maps ACL errors of Redis to vault.ErrPermissionDenied
+func mapError(err error) error {
+	if err != nil && strings.HasPrefix(err.Error(), "NOPERM") {
+		return fmt.Errorf("%w: %w", vault.ErrPermissionDenied, err)
+	}
+
+	return err
+}
+
+// decodeMeta decodes the metadata of a version. Versions written before
+// metadata was tracked have none and are treated as enabled.
+func decodeMeta(data string) (versionMeta, error) {
+	meta := versionMeta{State: vault.VersionEnabled}
+
+	if data == "" {
+		return meta, nil
+	}
+
+	if err := json.Unmarshal([]byte(data), &meta); err != nil {
+		return versionMeta{}, fmt.Errorf("failed to decode version metadata: %w", err)
+	}
+
+	return meta, nil
+}
+
+const (
+	// keyPrefix is the prefix of the keys of the secrets
+	keyPrefix = "vault:"
+
+	// metaKeyPrefix is the prefix of the keys of the version metadata. It
+	// must not share the `vault:` prefix, so that it is not listed as secret.
+	metaKeyPrefix = "vault_meta:"
+)
+
+// getKeyName returns the key name for the secret. The name is a hash tag,
+// so that the keys of a secret share their slot in a cluster.
 func (d *RedisDriver) getKeyName(name string) string {
-	return fmt.Sprintf("vault:%s", name)
+	return keyPrefix + "{" + name + "}"
+}
+
+// getMetaKeyName returns the key name for the version metadata of the secret
+func (d *RedisDriver) getMetaKeyName(name string) string {
+	return metaKeyPrefix + "{" + name + "}"
+}
+
+// keys returns the keys of the secret, which must be updated together
+func (d *RedisDriver) keys(name string) []string {
+	return []string{d.getKeyName(name), d.getMetaKeyName(name)}
 }
 
 func (d *RedisDriver) encrypt(data []byte) (string, error) {
@@ -230,6 +643,10 @@ func (d *RedisDriver) decrypt(data string) ([]byte, error) {
 	}
 
 	nonceSize := gcm.NonceSize()
+	if len(decodedData) < nonceSize {
+		return nil, fmt.Errorf("failed to decrypt data: ciphertext too short")
+	}
+
 	nonce, ciphertext := decodedData[:nonceSize], decodedData[nonceSize:]
 
 	plaintext, err := gcm.Open(nil, []byte(nonce), []byte(ciphertext), nil)
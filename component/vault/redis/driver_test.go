@@ -0,0 +1,109 @@
+package vaultredis_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/fruitsco/goji/component/redis/redistest"
+	"github.com/fruitsco/goji/component/vault"
+	vaultredis "github.com/fruitsco/goji/component/vault/redis"
+	"github.com/fruitsco/goji/component/vault/vaulttest"
+)
+
+func newDriver(t *testing.T, srv *miniredis.Miniredis) vault.Driver {
+	d, err := vaultredis.NewRedisDriver(vaultredis.RedisDriverParams{
+		Config: &vault.RedisConfig{
+			EncryptionKey: "0123456789abcdef0123456789abcdef",
+		},
+		Redis: redistest.Connect(t, srv),
+		Log:   zap.NewNop(),
+	})
+	require.NoError(t, err)
+
+	return d
+}
+
+func TestRedisDriver_Conformance(t *testing.T) {
+	srv := miniredis.RunT(t)
+
+	vaulttest.TestDriver(t, func(t *testing.T) vault.Driver {
+		return newDriver(t, srv)
+	})
+}
+
+func TestRedisDriver_HashTaggedKeys(t *testing.T) {
+	ctx := context.Background()
+	srv := miniredis.RunT(t)
+	d := newDriver(t, srv)
+
+	_, err := d.CreateSecret(ctx, "api", []byte("v1"))
+	require.NoError(t, err)
+
+	// the keys of a secret share their slot in a cluster
+	assert.ElementsMatch(t, []string{"vault:{api}", "vault_meta:{api}"}, srv.Keys())
+}
+
+func TestRedisDriver_MigratesLegacyKeys(t *testing.T) {
+	ctx := context.Background()
+	srv := miniredis.RunT(t)
+	d := newDriver(t, srv)
+
+	// secrets written by earlier versions use keys without hash tag
+	_, err := d.CreateSecret(ctx, "legacy", []byte("v1"))
+	require.NoError(t, err)
+	_, err = d.AddVersion(ctx, "legacy", []byte("v2"))
+	require.NoError(t, err)
+	versions, err := srv.List("vault:{legacy}")
+	require.NoError(t, err)
+	srv.Del("vault:{legacy}")
+	srv.Del("vault_meta:{legacy}")
+	_, err = srv.Push("vault:legacy", versions...)
+	require.NoError(t, err)
+
+	secrets, err := d.ListSecrets(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []vault.SecretMetadata{{Name: "legacy"}}, secrets)
+
+	s, err := d.GetLatestVersion(ctx, "legacy")
+	require.NoError(t, err)
+	assert.Equal(t, 2, s.Version)
+	assert.Equal(t, []byte("v2"), s.Payload)
+
+	assert.ElementsMatch(t, []string{"vault:{legacy}"}, srv.Keys())
+
+	_, err = d.CreateSecret(ctx, "legacy", []byte("v1"))
+	assert.ErrorIs(t, err, vault.ErrSecretExists)
+}
+
+func TestRedisDriver_EmptyEntry(t *testing.T) {
+	ctx := context.Background()
+	srv := miniredis.RunT(t)
+	d := newDriver(t, srv)
+
+	_, err := d.CreateSecret(ctx, "api", []byte("v1"))
+	require.NoError(t, err)
+
+	// an entry cleared by a destroy whose metadata was not read yet
+	_, err = srv.Lpop("vault:{api}")
+	require.NoError(t, err)
+	srv.Lpush("vault:{api}", "")
+
+	_, err = d.GetVersion(ctx, "api", 1)
+	require.ErrorIs(t, err, vault.ErrVersionNotFound)
+
+	_, err = d.GetLatestVersion(ctx, "api")
+	require.ErrorIs(t, err, vault.ErrVersionNotFound)
+
+	// payloads too short to hold a nonce are rejected
+	_, err = srv.Lpop("vault:{api}")
+	require.NoError(t, err)
+	srv.Lpush("vault:{api}", "AAAA")
+
+	_, err = d.GetVersion(ctx, "api", 1)
+	require.Error(t, err)
+}
@@ -0,0 +1,70 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fruitsco/goji/conf"
+)
+
+// SecretResolver resolves secret references in config values, e.g.
+// `vault://db-password#latest`, through vault drivers.
+type SecretResolver struct {
+	drivers map[string]Driver
+}
+
+var _ = conf.SecretResolver(&SecretResolver{})
+
+// NewSecretResolver creates a secret resolver that resolves
+// `vault://` references through the given driver
+func NewSecretResolver(driver Driver) *SecretResolver {
+	return &SecretResolver{
+		drivers: map[string]Driver{
+			conf.SchemeVault: driver,
+		},
+	}
+}
+
+// WithScheme registers the driver to resolve references of the given
+// scheme, e.g. a Google Cloud Secret Manager driver for `gcpsm://`.
+// References naming a project are only resolved by drivers implementing
+// Projector for the same project.
+func (r *SecretResolver) WithScheme(scheme string, driver Driver) *SecretResolver {
+	r.drivers[scheme] = driver
+	return r
+}
+
+// ResolveSecret returns the payload of the referenced secret
+func (r *SecretResolver) ResolveSecret(ctx context.Context, ref conf.SecretRef) ([]byte, error) {
+	driver, ok := r.drivers[ref.Scheme]
+	if !ok || driver == nil {
+		return nil, fmt.Errorf("no vault driver registered for scheme %s", ref.Scheme)
+	}
+
+	// drivers resolve secrets in their configured project only,
+	// other projects must not silently resolve a secret of the same name
+	if ref.Project != "" {
+		project := ""
+		if projector, ok := driver.(Projector); ok {
+			project = projector.Project()
+		}
+
+		if project != ref.Project {
+			return nil, fmt.Errorf("secret of project %q cannot be resolved by vault driver for project %q", ref.Project, project)
+		}
+	}
+
+	var secret Secret
+	var err error
+
+	if ref.Version > 0 {
+		secret, err = driver.GetVersion(ctx, ref.Name, ref.Version)
+	} else {
+		secret, err = driver.GetLatestVersion(ctx, ref.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return secret.Payload, nil
+}
@@ -0,0 +1,53 @@
+package vault_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fruitsco/goji/component/vault"
+	vaultmemory "github.com/fruitsco/goji/component/vault/memory"
+	"github.com/fruitsco/goji/conf"
+)
+
+// projectDriver stores secrets in a single project
+type projectDriver struct {
+	vault.Driver
+	project string
+}
+
+func (d projectDriver) Project() string {
+	return d.project
+}
+
+func TestSecretResolver_Project(t *testing.T) {
+	ctx := context.Background()
+
+	d := vaultmemory.NewMemoryDriver(vaultmemory.MemoryDriverParams{})
+	_, err := d.CreateSecret(ctx, "db-password", []byte("secret"))
+	require.NoError(t, err)
+
+	cached, err := vault.NewCachedDriver(projectDriver{Driver: d, project: "app"}, vault.CacheOptions{})
+	require.NoError(t, err)
+
+	resolver := vault.NewSecretResolver(d).WithScheme(conf.SchemeGCPSecretManager, cached)
+
+	payload, err := resolver.ResolveSecret(ctx, conf.SecretRef{Scheme: conf.SchemeGCPSecretManager, Project: "app", Name: "db-password"})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("secret"), payload)
+
+	// the secret of the same name in another project is not resolved
+	_, err = resolver.ResolveSecret(ctx, conf.SecretRef{Scheme: conf.SchemeGCPSecretManager, Project: "other", Name: "db-password"})
+	assert.ErrorContains(t, err, `project "other"`)
+
+	// drivers without project cannot resolve references naming one
+	_, err = vault.NewSecretResolver(d).WithScheme(conf.SchemeGCPSecretManager, d).
+		ResolveSecret(ctx, conf.SecretRef{Scheme: conf.SchemeGCPSecretManager, Project: "app", Name: "db-password"})
+	assert.Error(t, err)
+
+	payload, err = resolver.ResolveSecret(ctx, conf.SecretRef{Scheme: conf.SchemeVault, Name: "db-password"})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("secret"), payload)
+}
@@ -15,6 +15,23 @@ type Driver interface {
 	GetLatestVersion(context.Context, string) (Secret, error)
 	GetVersion(context.Context, string, int) (Secret, error)
 	DeleteSecret(context.Context, string) error
+
+	// ListSecrets lists the metadata of all secrets
+	ListSecrets(context.Context) ([]SecretMetadata, error)
+
+	// ListVersions lists all versions of a secret in ascending order.
+	// The payload of the returned versions is not populated.
+	ListVersions(context.Context, string) ([]Secret, error)
+
+	// EnableVersion enables a previously disabled version
+	EnableVersion(context.Context, string, int) error
+
+	// DisableVersion disables a version, so that it can no longer be accessed
+	// and is skipped by GetLatestVersion
+	DisableVersion(context.Context, string, int) error
+
+	// DestroyVersion irrevocably destroys the payload of a version
+	DestroyVersion(context.Context, string, int) error
 }
 
 type Vault interface {
@@ -28,6 +45,13 @@ type Closer interface {
 	Close() error
 }
 
+// Projector is implemented by drivers storing secrets in a single project,
+// e.g. Google Cloud Secret Manager
+type Projector interface {
+	// Project returns the project the driver stores secrets in
+	Project() string
+}
+
 type VaultParams struct {
 	fx.In
 
@@ -45,8 +69,14 @@ type Manager struct {
 var _ = Vault(&Manager{})
 
 func New(params VaultParams) Vault {
+	drivers := params.Drivers
+
+	if params.Config.Cache != nil && params.Config.Cache.Enabled {
+		drivers = withCache(drivers, NewCacheOptions(params.Config.Cache))
+	}
+
 	return &Manager{
-		drivers: driver.NewPool(params.Drivers),
+		drivers: driver.NewPool(drivers),
 		config:  params.Config,
 		log:     params.Log.Named("vault"),
 	}
@@ -101,6 +131,51 @@ func (v *Manager) DeleteSecret(ctx context.Context, name string) error {
 	return driver.DeleteSecret(ctx, name)
 }
 
+func (v *Manager) ListSecrets(ctx context.Context) ([]SecretMetadata, error) {
+	driver, err := v.resolveDriver()
+	if err != nil {
+		return nil, err
+	}
+
+	return driver.ListSecrets(ctx)
+}
+
+func (v *Manager) ListVersions(ctx context.Context, name string) ([]Secret, error) {
+	driver, err := v.resolveDriver()
+	if err != nil {
+		return nil, err
+	}
+
+	return driver.ListVersions(ctx, name)
+}
+
+func (v *Manager) EnableVersion(ctx context.Context, name string, version int) error {
+	driver, err := v.resolveDriver()
+	if err != nil {
+		return err
+	}
+
+	return driver.EnableVersion(ctx, name, version)
+}
+
+func (v *Manager) DisableVersion(ctx context.Context, name string, version int) error {
+	driver, err := v.resolveDriver()
+	if err != nil {
+		return err
+	}
+
+	return driver.DisableVersion(ctx, name, version)
+}
+
+func (v *Manager) DestroyVersion(ctx context.Context, name string, version int) error {
+	driver, err := v.resolveDriver()
+	if err != nil {
+		return err
+	}
+
+	return driver.DestroyVersion(ctx, name, version)
+}
+
 func (v *Manager) Close() error {
 	driver, err := v.resolveDriver()
 	if err != nil {
@@ -117,3 +192,27 @@ func (v *Manager) Close() error {
 func (v *Manager) Driver(name DriverName) (Driver, error) {
 	return v.drivers.Resolve(name)
 }
+
+// withCache wraps the drivers created by the given factories in a CachedDriver
+func withCache(factories []*driver.Factory[DriverName, Driver], opts CacheOptions) []*driver.Factory[DriverName, Driver] {
+	cached := make([]*driver.Factory[DriverName, Driver], 0, len(factories))
+
+	for _, f := range factories {
+		create := f.Create
+
+		cached = append(cached, &driver.Factory[DriverName, Driver]{
+			Provides: f.Provides,
+			Optional: f.Optional,
+			Create: func() (Driver, error) {
+				d, err := create()
+				if err != nil {
+					return nil, err
+				}
+
+				return NewCachedDriver(d, opts)
+			},
+		})
+	}
+
+	return cached
+}
@@ -0,0 +1,202 @@
+// Package vaultcli provides CLI commands to manage the secrets of the
+// configured vault, to be added to the app using `CLIRoot.AddCommand`.
+package vaultcli
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/urfave/cli/v3"
+	"go.uber.org/fx"
+
+	"github.com/fruitsco/goji"
+	"github.com/fruitsco/goji/component/vault"
+)
+
+// SecretsCommand creates the `secrets` command with the `get`, `put` and
+// `delete` subcommands. The shell must provide the vault component:
+//
+//	app secrets put db-password --file password.txt
+//	app secrets get db-password --version 2
+func SecretsCommand[C any](shell *goji.Shell[C]) *cli.Command {
+	return &cli.Command{
+		Name:  "secrets",
+		Usage: "manage the secrets of the vault",
+		Commands: []*cli.Command{
+			getCommand(shell),
+			putCommand(shell),
+			deleteCommand(shell),
+		},
+	}
+}
+
+func getCommand[C any](shell *goji.Shell[C]) *cli.Command {
+	return &cli.Command{
+		Name:      "get",
+		Usage:     "print the payload of a secret",
+		ArgsUsage: "<name>",
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:  "version",
+				Usage: "version of the secret, defaults to the latest version",
+			},
+			&cli.BoolFlag{
+				Name:  "base64",
+				Usage: "print the payload base64 encoded",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			name, err := NameArg(cmd)
+			if err != nil {
+				return err
+			}
+
+			var v vault.Vault
+
+			return shell.RunTask(ctx, func(ctx context.Context) error {
+				var (
+					secret vault.Secret
+					err    error
+				)
+
+				if version := cmd.Int("version"); version > 0 {
+					secret, err = v.GetVersion(ctx, name, version)
+				} else {
+					secret, err = v.GetLatestVersion(ctx, name)
+				}
+				if err != nil {
+					return err
+				}
+
+				payload := secret.Payload
+				if cmd.Bool("base64") {
+					payload = []byte(base64.StdEncoding.EncodeToString(payload) + "\n")
+				}
+
+				_, err = cmd.Root().Writer.Write(payload)
+				return err
+			}, fx.Populate(&v))
+		},
+	}
+}
+
+func putCommand[C any](shell *goji.Shell[C]) *cli.Command {
+	return &cli.Command{
+		Name:      "put",
+		Usage:     "store a new version of a secret, creating the secret if needed",
+		ArgsUsage: "<name>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "value",
+				Usage: "payload of the secret",
+			},
+			&cli.StringFlag{
+				Name:  "file",
+				Usage: "file to read the payload from, `-` reads from stdin (default)",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			name, err := NameArg(cmd)
+			if err != nil {
+				return err
+			}
+
+			payload, err := readPayload(cmd)
+			if err != nil {
+				return err
+			}
+
+			var v vault.Vault
+
+			return shell.RunTask(ctx, func(ctx context.Context) error {
+				secret, err := PutSecret(ctx, v, name, payload)
+				if err != nil {
+					return err
+				}
+
+				fmt.Fprintf(cmd.Root().Writer, "stored %s version %d\n", secret.Name, secret.Version)
+
+				return nil
+			}, fx.Populate(&v))
+		},
+	}
+}
+
+func deleteCommand[C any](shell *goji.Shell[C]) *cli.Command {
+	return &cli.Command{
+		Name:      "delete",
+		Usage:     "delete a secret with all versions, or destroy a single version",
+		ArgsUsage: "<name>",
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:  "version",
+				Usage: "only destroy the given version",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			name, err := NameArg(cmd)
+			if err != nil {
+				return err
+			}
+
+			var v vault.Vault
+
+			return shell.RunTask(ctx, func(ctx context.Context) error {
+				if version := cmd.Int("version"); version > 0 {
+					if err := v.DestroyVersion(ctx, name, version); err != nil {
+						return err
+					}
+
+					fmt.Fprintf(cmd.Root().Writer, "destroyed %s version %d\n", name, version)
+
+					return nil
+				}
+
+				if err := v.DeleteSecret(ctx, name); err != nil {
+					return err
+				}
+
+				fmt.Fprintf(cmd.Root().Writer, "deleted %s\n", name)
+
+				return nil
+			}, fx.Populate(&v))
+		},
+	}
+}
+
+// PutSecret adds a version to the secret, or creates it if it does not exist
+func PutSecret(ctx context.Context, d vault.Driver, name string, payload []byte) (vault.Secret, error) {
+	secret, err := d.AddVersion(ctx, name, payload)
+	if errors.Is(err, vault.ErrSecretNotFound) {
+		return d.CreateSecret(ctx, name, payload)
+	}
+
+	return secret, err
+}
+
+// NameArg returns the secret name given as first argument
+func NameArg(cmd *cli.Command) (string, error) {
+	name := cmd.Args().First()
+	if name == "" {
+		return "", fmt.Errorf("missing argument %s", cmd.ArgsUsage)
+	}
+
+	return name, nil
+}
+
+func readPayload(cmd *cli.Command) ([]byte, error) {
+	if cmd.IsSet("value") {
+		return []byte(cmd.String("value")), nil
+	}
+
+	path := cmd.String("file")
+	if path == "" || path == "-" {
+		return io.ReadAll(cmd.Root().Reader)
+	}
+
+	return os.ReadFile(path)
+}
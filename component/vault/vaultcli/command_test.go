@@ -0,0 +1,29 @@
+package vaultcli_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	vaultmemory "github.com/fruitsco/goji/component/vault/memory"
+	"github.com/fruitsco/goji/component/vault/vaultcli"
+)
+
+func TestPutSecret(t *testing.T) {
+	ctx := context.Background()
+	d := vaultmemory.NewMemoryDriver(vaultmemory.MemoryDriverParams{})
+
+	s, err := vaultcli.PutSecret(ctx, d, "token", []byte("v1"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, s.Version)
+
+	s, err = vaultcli.PutSecret(ctx, d, "token", []byte("v2"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, s.Version)
+
+	s, err = d.GetLatestVersion(ctx, "token")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v2"), s.Payload)
+}
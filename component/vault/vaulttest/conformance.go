@@ -0,0 +1,246 @@
+// Package vaulttest provides a conformance test suite for vault drivers.
+//
+// Drivers, including third-party ones, run the suite against a local
+// stand-in of their backend to verify that they implement the semantics
+// callers of vault.Driver rely on, most notably the typed errors:
+//
+//	func TestConformance(t *testing.T) {
+//		vaulttest.TestDriver(t, func(t *testing.T) vault.Driver {
+//			return newDriver(t)
+//		})
+//	}
+package vaulttest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fruitsco/goji/component/vault"
+)
+
+// NewDriverFunc creates a driver for a single test. Drivers returned by
+// separate calls may share their backend, the suite uses unique names.
+type NewDriverFunc func(t *testing.T) vault.Driver
+
+// TestDriver runs the conformance test suite against the driver.
+// Optional operations reporting vault.ErrNotSupported are skipped.
+func TestDriver(t *testing.T, newDriver NewDriverFunc) {
+	t.Helper()
+
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, ctx context.Context, d vault.Driver, name string)
+	}{
+		{"CreateSecret", testCreateSecret},
+		{"AddVersion", testAddVersion},
+		{"GetVersion", testGetVersion},
+		{"GetLatestVersion", testGetLatestVersion},
+		{"DeleteSecret", testDeleteSecret},
+		{"ListSecrets", testListSecrets},
+		{"ListVersions", testListVersions},
+		{"DisableVersion", testDisableVersion},
+		{"DestroyVersion", testDestroyVersion},
+	}
+
+	// a run specific suffix keeps runs against persistent backends apart.
+	// names are restricted to characters every backend accepts.
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := fmt.Sprintf("vaulttest-%s-%s", strings.ToLower(tt.name), suffix)
+			tt.fn(t, context.Background(), newDriver(t), name)
+		})
+	}
+}
+
+func testCreateSecret(t *testing.T, ctx context.Context, d vault.Driver, name string) {
+	s, err := d.CreateSecret(ctx, name, []byte("v1"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, s.Version)
+	assert.Equal(t, []byte("v1"), s.Payload)
+
+	_, err = d.CreateSecret(ctx, name, []byte("v1"))
+	assert.ErrorIs(t, err, vault.ErrSecretExists)
+}
+
+func testAddVersion(t *testing.T, ctx context.Context, d vault.Driver, name string) {
+	_, err := d.AddVersion(ctx, name, []byte("v1"))
+	assert.ErrorIs(t, err, vault.ErrSecretNotFound)
+
+	createSecret(t, ctx, d, name, "v1", "v2", "v3")
+
+	s, err := d.AddVersion(ctx, name, []byte("v4"))
+	require.NoError(t, err)
+	assert.Equal(t, 4, s.Version)
+	assert.Equal(t, []byte("v4"), s.Payload)
+}
+
+func testGetVersion(t *testing.T, ctx context.Context, d vault.Driver, name string) {
+	_, err := d.GetVersion(ctx, name, 1)
+	assert.ErrorIs(t, err, vault.ErrSecretNotFound)
+
+	createSecret(t, ctx, d, name, "v1", "v2")
+
+	for version, payload := range map[int]string{1: "v1", 2: "v2"} {
+		s, err := d.GetVersion(ctx, name, version)
+		require.NoError(t, err)
+		assert.Equal(t, version, s.Version)
+		assert.Equal(t, []byte(payload), s.Payload)
+	}
+
+	// out of range versions must never resolve to another version
+	for _, version := range []int{-1, 0, 3} {
+		_, err := d.GetVersion(ctx, name, version)
+		assert.ErrorIs(t, err, vault.ErrVersionNotFound, "version %d", version)
+	}
+}
+
+func testGetLatestVersion(t *testing.T, ctx context.Context, d vault.Driver, name string) {
+	_, err := d.GetLatestVersion(ctx, name)
+	assert.ErrorIs(t, err, vault.ErrSecretNotFound)
+
+	createSecret(t, ctx, d, name, "v1", "v2")
+
+	s, err := d.GetLatestVersion(ctx, name)
+	require.NoError(t, err)
+	assert.Equal(t, 2, s.Version)
+	assert.Equal(t, []byte("v2"), s.Payload)
+}
+
+func testDeleteSecret(t *testing.T, ctx context.Context, d vault.Driver, name string) {
+	assert.ErrorIs(t, d.DeleteSecret(ctx, name), vault.ErrSecretNotFound)
+
+	createSecret(t, ctx, d, name, "v1", "v2")
+
+	require.NoError(t, d.DeleteSecret(ctx, name))
+
+	_, err := d.GetLatestVersion(ctx, name)
+	assert.ErrorIs(t, err, vault.ErrSecretNotFound)
+
+	_, err = d.GetVersion(ctx, name, 1)
+	assert.ErrorIs(t, err, vault.ErrSecretNotFound)
+
+	// the name can be reused after deletion
+	s, err := d.CreateSecret(ctx, name, []byte("v1"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, s.Version)
+}
+
+func testListSecrets(t *testing.T, ctx context.Context, d vault.Driver, name string) {
+	createSecret(t, ctx, d, name, "v1")
+
+	secrets, err := d.ListSecrets(ctx)
+	skipIfNotSupported(t, err)
+	require.NoError(t, err)
+
+	assert.True(t, slices.ContainsFunc(secrets, func(s vault.SecretMetadata) bool {
+		return s.Name == name
+	}), "secret %s is not listed", name)
+}
+
+func testListVersions(t *testing.T, ctx context.Context, d vault.Driver, name string) {
+	_, err := d.ListVersions(ctx, name)
+	skipIfNotSupported(t, err)
+	assert.ErrorIs(t, err, vault.ErrSecretNotFound)
+
+	createSecret(t, ctx, d, name, "v1", "v2", "v3")
+
+	versions, err := d.ListVersions(ctx, name)
+	require.NoError(t, err)
+	require.Len(t, versions, 3)
+
+	for i, v := range versions {
+		assert.Equal(t, name, v.Name)
+		assert.Equal(t, i+1, v.Version)
+		assert.Equal(t, vault.VersionEnabled, v.State)
+		assert.Nil(t, v.Payload)
+	}
+}
+
+func testDisableVersion(t *testing.T, ctx context.Context, d vault.Driver, name string) {
+	err := d.DisableVersion(ctx, name, 1)
+	skipIfNotSupported(t, err)
+	assert.ErrorIs(t, err, vault.ErrSecretNotFound)
+
+	createSecret(t, ctx, d, name, "v1", "v2")
+
+	assert.ErrorIs(t, d.DisableVersion(ctx, name, 3), vault.ErrVersionNotFound)
+
+	require.NoError(t, d.DisableVersion(ctx, name, 2))
+
+	_, err = d.GetVersion(ctx, name, 2)
+	assert.ErrorIs(t, err, vault.ErrVersionNotFound)
+
+	// latest skips the disabled version
+	s, err := d.GetLatestVersion(ctx, name)
+	require.NoError(t, err)
+	assert.Equal(t, 1, s.Version)
+
+	require.NoError(t, d.EnableVersion(ctx, name, 2))
+
+	s, err = d.GetVersion(ctx, name, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v2"), s.Payload)
+
+	s, err = d.GetLatestVersion(ctx, name)
+	require.NoError(t, err)
+	assert.Equal(t, 2, s.Version)
+}
+
+func testDestroyVersion(t *testing.T, ctx context.Context, d vault.Driver, name string) {
+	err := d.DestroyVersion(ctx, name, 1)
+	skipIfNotSupported(t, err)
+	assert.ErrorIs(t, err, vault.ErrSecretNotFound)
+
+	createSecret(t, ctx, d, name, "v1", "v2")
+
+	require.NoError(t, d.DestroyVersion(ctx, name, 1))
+
+	_, err = d.GetVersion(ctx, name, 1)
+	assert.ErrorIs(t, err, vault.ErrVersionNotFound)
+
+	// destroyed versions are gone for good
+	assert.Error(t, d.EnableVersion(ctx, name, 1))
+
+	s, err := d.GetVersion(ctx, name, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v2"), s.Payload)
+
+	if versions, err := d.ListVersions(ctx, name); err == nil {
+		require.Len(t, versions, 2)
+		assert.Equal(t, vault.VersionDestroyed, versions[0].State)
+	}
+}
+
+// createSecret creates a secret with the given payloads as versions
+func createSecret(t *testing.T, ctx context.Context, d vault.Driver, name string, payloads ...string) {
+	t.Helper()
+
+	for i, payload := range payloads {
+		var err error
+		if i == 0 {
+			_, err = d.CreateSecret(ctx, name, []byte(payload))
+		} else {
+			_, err = d.AddVersion(ctx, name, []byte(payload))
+		}
+		require.NoError(t, err)
+	}
+}
+
+func skipIfNotSupported(t *testing.T, err error) {
+	t.Helper()
+
+	if errors.Is(err, vault.ErrNotSupported) {
+		t.Skipf("not supported by driver: %v", err)
+	}
+}
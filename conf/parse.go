@@ -1,6 +1,7 @@
 package conf
 
 import (
+	"context"
 	"strings"
 
 	"github.com/knadh/koanf/parsers/dotenv"
@@ -19,6 +20,13 @@ type ParseOptions struct {
 	Prefix      string
 	Log         *zap.Logger
 	FileName    string
+
+	// SecretResolver resolves secret references, e.g. `vault://db-password`,
+	// in any config value. References are kept as is if nil.
+	SecretResolver SecretResolver
+
+	// Context is used for resolving secrets. Defaults to context.Background.
+	Context context.Context
 }
 
 func Parse[C any](opt ParseOptions) (*C, error) {
@@ -79,6 +87,19 @@ func Parse[C any](opt ParseOptions) (*C, error) {
 		"app.env":  opt.Environment,
 	}, "."), nil)
 
+	// PRIO 7 - resolve secret references
+	if opt.SecretResolver != nil {
+		ctx := opt.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		if err := resolveSecrets(ctx, k, opt.SecretResolver); err != nil {
+			log.Error("error resolving secrets", zap.Error(err))
+			return nil, err
+		}
+	}
+
 	if err := k.UnmarshalWithConf("", &config, koanf.UnmarshalConf{Tag: "conf"}); err != nil {
 		log.Error("error unmarshalling config", zap.Error(err))
 		return nil, err
@@ -0,0 +1,209 @@
+package conf
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/knadh/koanf/v2"
+)
+
+const (
+	// SchemeVault references a secret by name, e.g. `vault://db-password#latest`
+	SchemeVault = "vault"
+
+	// SchemeGCPSecretManager references a secret by its Google Cloud Secret
+	// Manager resource name, e.g. `gcpsm://projects/x/secrets/y/versions/3`
+	SchemeGCPSecretManager = "gcpsm"
+)
+
+// SecretRef is a reference to a secret in a config value
+type SecretRef struct {
+	// Scheme is the scheme of the reference, e.g. `vault`
+	Scheme string
+
+	// Project is the project of the secret, if given by the reference
+	Project string
+
+	// Name is the name of the secret
+	Name string
+
+	// Version is the version of the secret, 0 references the latest version
+	Version int
+}
+
+func (r SecretRef) String() string {
+	version := "latest"
+	if r.Version > 0 {
+		version = strconv.Itoa(r.Version)
+	}
+
+	if r.Scheme == SchemeGCPSecretManager {
+		return fmt.Sprintf("%s://projects/%s/secrets/%s/versions/%s", r.Scheme, r.Project, r.Name, version)
+	}
+
+	return fmt.Sprintf("%s://%s#%s", r.Scheme, r.Name, version)
+}
+
+// SecretResolver resolves secret references found in config values
+type SecretResolver interface {
+	ResolveSecret(context.Context, SecretRef) ([]byte, error)
+}
+
+// ParseSecretRef parses a secret reference. The second return value is false
+// if the value is not a secret reference at all.
+func ParseSecretRef(value string) (SecretRef, bool, error) {
+	scheme, rest, ok := strings.Cut(value, "://")
+	if !ok {
+		return SecretRef{}, false, nil
+	}
+
+	switch scheme {
+	case SchemeVault:
+		name, version, _ := strings.Cut(rest, "#")
+		if name == "" {
+			return SecretRef{}, true, fmt.Errorf("secret reference %q is missing a name", value)
+		}
+
+		v, err := parseSecretVersion(version)
+		if err != nil {
+			return SecretRef{}, true, fmt.Errorf("secret reference %q has invalid version: %w", value, err)
+		}
+
+		return SecretRef{Scheme: scheme, Name: name, Version: v}, true, nil
+	case SchemeGCPSecretManager:
+		parts := strings.Split(rest, "/")
+		if (len(parts) != 4 && len(parts) != 6) || parts[0] != "projects" || parts[2] != "secrets" {
+			return SecretRef{}, true, fmt.Errorf(
+				"secret reference %q must be of form %s://projects/<project>/secrets/<name>[/versions/<version>]",
+				value, SchemeGCPSecretManager,
+			)
+		}
+
+		ref := SecretRef{Scheme: scheme, Project: parts[1], Name: parts[3]}
+
+		if len(parts) == 6 {
+			if parts[4] != "versions" {
+				return SecretRef{}, true, fmt.Errorf("secret reference %q is missing versions segment", value)
+			}
+
+			v, err := parseSecretVersion(parts[5])
+			if err != nil {
+				return SecretRef{}, true, fmt.Errorf("secret reference %q has invalid version: %w", value, err)
+			}
+
+			ref.Version = v
+		}
+
+		return ref, true, nil
+	}
+
+	// other schemes, e.g. `postgres://` urls, are regular values
+	return SecretRef{}, false, nil
+}
+
+func parseSecretVersion(version string) (int, error) {
+	if version == "" || version == "latest" {
+		return 0, nil
+	}
+
+	v, err := strconv.Atoi(version)
+	if err != nil {
+		return 0, err
+	}
+
+	if v < 1 {
+		return 0, fmt.Errorf("version must be positive, got %d", v)
+	}
+
+	return v, nil
+}
+
+// resolveSecrets replaces all secret references in the loaded config
+// with the payload of the referenced secret
+func resolveSecrets(ctx context.Context, k *koanf.Koanf, resolver SecretResolver) error {
+	for key, value := range k.All() {
+		switch v := value.(type) {
+		case string:
+			resolved, changed, err := resolveSecretValue(ctx, resolver, key, v)
+			if err != nil {
+				return err
+			}
+			if changed {
+				if err := k.Set(key, resolved); err != nil {
+					return fmt.Errorf("config key %s: %w", key, err)
+				}
+			}
+		case []string:
+			if err := resolveSecretList(ctx, k, resolver, key, toAnySlice(v)); err != nil {
+				return err
+			}
+		case []any:
+			if err := resolveSecretList(ctx, k, resolver, key, v); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func resolveSecretList(ctx context.Context, k *koanf.Koanf, resolver SecretResolver, key string, values []any) error {
+	resolved := make([]any, len(values))
+	changed := false
+
+	for i, item := range values {
+		resolved[i] = item
+
+		s, ok := item.(string)
+		if !ok {
+			continue
+		}
+
+		r, c, err := resolveSecretValue(ctx, resolver, fmt.Sprintf("%s[%d]", key, i), s)
+		if err != nil {
+			return err
+		}
+
+		if c {
+			resolved[i] = r
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	if err := k.Set(key, resolved); err != nil {
+		return fmt.Errorf("config key %s: %w", key, err)
+	}
+
+	return nil
+}
+
+func resolveSecretValue(ctx context.Context, resolver SecretResolver, key string, value string) (string, bool, error) {
+	ref, ok, err := ParseSecretRef(value)
+	if err != nil {
+		return "", false, fmt.Errorf("config key %s: %w", key, err)
+	}
+	if !ok {
+		return value, false, nil
+	}
+
+	payload, err := resolver.ResolveSecret(ctx, ref)
+	if err != nil {
+		return "", false, fmt.Errorf("config key %s: failed to resolve secret %s: %w", key, ref, err)
+	}
+
+	return string(payload), true, nil
+}
+
+func toAnySlice(values []string) []any {
+	r := make([]any, len(values))
+	for i, v := range values {
+		r[i] = v
+	}
+	return r
+}
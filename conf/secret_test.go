@@ -0,0 +1,95 @@
+package conf_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fruitsco/goji/conf"
+)
+
+type mapResolver map[string]string
+
+func (r mapResolver) ResolveSecret(_ context.Context, ref conf.SecretRef) ([]byte, error) {
+	if v, ok := r[ref.String()]; ok {
+		return []byte(v), nil
+	}
+
+	return nil, fmt.Errorf("secret %s not found", ref.Name)
+}
+
+func TestParseSecretRef(t *testing.T) {
+	tests := []struct {
+		value string
+		ref   conf.SecretRef
+		isRef bool
+		err   bool
+	}{
+		{"plain", conf.SecretRef{}, false, false},
+		{"postgres://user@host/db", conf.SecretRef{}, false, false},
+		{"vault://db-password", conf.SecretRef{Scheme: "vault", Name: "db-password"}, true, false},
+		{"vault://db-password#latest", conf.SecretRef{Scheme: "vault", Name: "db-password"}, true, false},
+		{"vault://app/db-password#3", conf.SecretRef{Scheme: "vault", Name: "app/db-password", Version: 3}, true, false},
+		{"vault://#3", conf.SecretRef{}, true, true},
+		{"vault://db-password#first", conf.SecretRef{}, true, true},
+		{"gcpsm://projects/x/secrets/y/versions/3", conf.SecretRef{Scheme: "gcpsm", Project: "x", Name: "y", Version: 3}, true, false},
+		{"gcpsm://projects/x/secrets/y/versions/latest", conf.SecretRef{Scheme: "gcpsm", Project: "x", Name: "y"}, true, false},
+		{"gcpsm://projects/x/secrets/y", conf.SecretRef{Scheme: "gcpsm", Project: "x", Name: "y"}, true, false},
+		{"gcpsm://secrets/y", conf.SecretRef{}, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			ref, isRef, err := conf.ParseSecretRef(tt.value)
+			assert.Equal(t, tt.isRef, isRef)
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.ref, ref)
+		})
+	}
+}
+
+type secretConfig struct {
+	DB struct {
+		Password string `conf:"password"`
+		Host     string `conf:"host"`
+	} `conf:"db"`
+	Keys []string `conf:"keys"`
+}
+
+func TestParse_ResolvesSecrets(t *testing.T) {
+	t.Setenv("GOJITEST__DB__PASSWORD", "vault://db-password#2")
+	t.Setenv("GOJITEST__KEYS", "vault://api-key, static")
+
+	cfg, err := conf.Parse[secretConfig](conf.ParseOptions{
+		Prefix: "GOJITEST",
+		Defaults: conf.DefaultConfig{
+			"db.host": "localhost",
+		},
+		SecretResolver: mapResolver{
+			"vault://db-password#2":  "hunter2",
+			"vault://api-key#latest": "secret-key",
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "hunter2", cfg.DB.Password)
+	assert.Equal(t, "localhost", cfg.DB.Host)
+	assert.Equal(t, []string{"secret-key", "static"}, cfg.Keys)
+}
+
+func TestParse_FailsForUnresolvableSecrets(t *testing.T) {
+	t.Setenv("GOJITEST__DB__PASSWORD", "vault://missing")
+
+	_, err := conf.Parse[secretConfig](conf.ParseOptions{
+		Prefix:         "GOJITEST",
+		SecretResolver: mapResolver{},
+	})
+	require.ErrorContains(t, err, "config key db.password")
+}
@@ -1,10 +1,13 @@
 package core
 
 import (
+	"github.com/fruitsco/goji/component/cache"
 	"github.com/fruitsco/goji/component/crypt"
 	"github.com/fruitsco/goji/component/database"
 	"github.com/fruitsco/goji/component/email"
+	"github.com/fruitsco/goji/component/lock"
 	"github.com/fruitsco/goji/component/queue"
+	"github.com/fruitsco/goji/component/ratelimit"
 	"github.com/fruitsco/goji/component/redis"
 	"github.com/fruitsco/goji/component/storage"
 	"github.com/fruitsco/goji/component/tasks"
@@ -13,20 +16,26 @@ import (
 )
 
 type Config struct {
-	Database *database.Config `conf:"db"`
-	Email    *email.Config    `conf:"email"`
-	Queue    *queue.Config    `conf:"queue"`
-	Redis    *redis.Config    `conf:"redis"`
-	Storage  *storage.Config  `conf:"storage"`
-	Vault    *vault.Config    `conf:"vault"`
-	Crypt    *crypt.Config    `conf:"crypt"`
-	Tasks    *tasks.Config    `conf:"tasks"`
+	Cache     *cache.Config     `conf:"cache"`
+	Database  *database.Config  `conf:"db"`
+	Email     *email.Config     `conf:"email"`
+	Lock      *lock.Config      `conf:"lock"`
+	Queue     *queue.Config     `conf:"queue"`
+	Ratelimit *ratelimit.Config `conf:"ratelimit"`
+	Redis     *redis.Config     `conf:"redis"`
+	Storage   *storage.Config   `conf:"storage"`
+	Vault     *vault.Config     `conf:"vault"`
+	Crypt     *crypt.Config     `conf:"crypt"`
+	Tasks     *tasks.Config     `conf:"tasks"`
 }
 
 var DefaultConfig = util.MergeMap(
+	cache.DefaultConfig,
 	database.DefaultConfig,
 	email.DefaultConfig,
+	lock.DefaultConfig,
 	queue.DefaultConfig,
+	ratelimit.DefaultConfig,
 	redis.DefaultConfig,
 	storage.DefaultConfig,
 	vault.DefaultConfig,
@@ -3,10 +3,14 @@ package core
 import (
 	"go.uber.org/fx"
 
+	"github.com/fruitsco/goji/component/cache"
 	"github.com/fruitsco/goji/component/crypt"
 	"github.com/fruitsco/goji/component/database"
 	"github.com/fruitsco/goji/component/email"
+	"github.com/fruitsco/goji/component/health"
+	"github.com/fruitsco/goji/component/lock"
 	"github.com/fruitsco/goji/component/queue"
+	"github.com/fruitsco/goji/component/ratelimit"
 	"github.com/fruitsco/goji/component/redis"
 	"github.com/fruitsco/goji/component/storage"
 	"github.com/fruitsco/goji/component/tasks"
@@ -16,9 +20,13 @@ import (
 
 func Module(config *Config) fx.Option {
 	return fx.Module("core",
+		cache.Module(config.Cache),
 		database.Module(config.Database),
 		email.Module(config.Email),
+		health.Module(),
+		lock.Module(config.Lock),
 		queue.Module(config.Queue),
+		ratelimit.Module(config.Ratelimit),
 		redis.Module(config.Redis),
 		storage.Module(config.Storage),
 		validation.Module(),
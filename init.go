@@ -14,6 +14,7 @@ type InitParams struct {
 	Environment    Environment
 	DefaultConfig  conf.DefaultConfig
 	ConfigFileName string
+	SecretResolver conf.SecretResolver
 }
 
 func Init[C any](ctx context.Context, params InitParams) (context.Context, error) {
@@ -28,12 +29,14 @@ func Init[C any](ctx context.Context, params InitParams) (context.Context, error
 
 	// parse config using env
 	cfg, err := conf.Parse[RootConfig[C]](conf.ParseOptions{
-		AppName:     params.AppName,
-		Environment: string(params.Environment),
-		Defaults:    params.DefaultConfig,
-		Prefix:      params.Prefix,
-		FileName:    params.ConfigFileName,
-		Log:         log,
+		AppName:        params.AppName,
+		Environment:    string(params.Environment),
+		Defaults:       params.DefaultConfig,
+		Prefix:         params.Prefix,
+		FileName:       params.ConfigFileName,
+		Log:            log,
+		SecretResolver: params.SecretResolver,
+		Context:        ctx,
 	})
 	if err != nil {
 		return nil, err
@@ -19,6 +19,7 @@ type RootParams struct {
 	DefaultConfig  conf.DefaultConfig
 	DefaultCommand string
 	ConfigFileName string
+	SecretResolver conf.SecretResolver
 }
 
 type CLIRoot struct {
@@ -77,6 +78,7 @@ func NewCommand[C any](params RootParams) *CLIRoot {
 				Environment:    environment,
 				DefaultConfig:  params.DefaultConfig,
 				ConfigFileName: params.ConfigFileName,
+				SecretResolver: params.SecretResolver,
 			})
 			if err != nil {
 				return ctx, err
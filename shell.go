@@ -102,6 +102,32 @@ func (s *Shell[C]) PrintGraph(ctx context.Context) error {
 	)
 }
 
+// RunTask runs the application until the task returns, e.g. for CLI commands.
+// The task is started once the application started, dependencies are
+// retrieved using `fx.Populate` in the options. The shell exits with
+// exit code 1 if the task fails.
+func (s *Shell[C]) RunTask(ctx context.Context, task func(context.Context) error, options ...fx.Option) error {
+	return s.Run(ctx, fx.Options(options...), fx.Invoke(func(
+		ctx context.Context,
+		lc fx.Lifecycle,
+		shutdowner fx.Shutdowner,
+		log *zap.Logger,
+	) {
+		lc.Append(fx.StartHook(func() {
+			go func() {
+				exitCode := 0
+
+				if err := task(ctx); err != nil {
+					log.Error("task failed", zap.Error(err))
+					exitCode = 1
+				}
+
+				shutdowner.Shutdown(fx.ExitCode(exitCode))
+			}()
+		}))
+	}))
+}
+
 func (s *Shell[C]) createFxApp(
 	ctx context.Context,
 	config *RootConfig[C],
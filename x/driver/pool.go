@@ -1,8 +1,12 @@
 package driver
 
-import "fmt"
+import (
+	"fmt"
+	"sync"
+)
 
 type Pool[K comparable, D any] struct {
+	mu          sync.Mutex
 	drivers     map[K]*Factory[K, D]
 	driverCache map[K]D
 }
@@ -22,6 +26,9 @@ func NewPool[K comparable, D any](drivers Factories[K, D]) *Pool[K, D] {
 }
 
 func (p *Pool[K, D]) Resolve(driverKey K) (D, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
 	if driver, ok := p.driverCache[driverKey]; ok {
 		return driver, nil
 	}